@@ -0,0 +1,82 @@
+package api
+
+import "github.com/zelenin/go-tdlib/client"
+
+// NewCallbackButton returns an inline keyboard button
+// that sends data to the bot via a callback query when tapped.
+func NewCallbackButton(text, data string) *client.InlineKeyboardButton {
+
+	return &client.InlineKeyboardButton{
+		Text: text,
+		Type: &client.InlineKeyboardButtonTypeCallback{
+			Data: []byte(data),
+		},
+	}
+
+}
+
+// SendKeyboardReplyText sends a plain text message with an inline keyboard
+// in reply to a certain replyToMessageID.
+func SendKeyboardReplyText(chatID, replyToMessageID int64, text string, rows [][]*client.InlineKeyboardButton) (*client.Message, error) {
+
+	request := client.SendMessageRequest{
+		ChatId:           chatID,
+		ReplyToMessageId: replyToMessageID,
+		ReplyMarkup:      inlineKeyboard(rows),
+		InputMessageContent: &client.InputMessageText{
+			Text: &client.FormattedText{
+				Text: text,
+			},
+		},
+	}
+
+	return tdlibClient.SendMessage(&request)
+
+}
+
+// EditKeyboardText replaces the text and the inline keyboard of a message.
+func EditKeyboardText(chatID, messageID int64, text string, rows [][]*client.InlineKeyboardButton) (*client.Message, error) {
+
+	request := client.EditMessageTextRequest{
+		ChatId:      chatID,
+		MessageId:   messageID,
+		ReplyMarkup: inlineKeyboard(rows),
+		InputMessageContent: &client.InputMessageText{
+			Text: &client.FormattedText{
+				Text: text,
+			},
+		},
+	}
+
+	return tdlibClient.EditMessageText(&request)
+
+}
+
+// AnswerCallbackQuery answers a callback query, stopping the loading animation
+// of the button. If text is not empty, it is shown to the user,
+// in an alert if showAlert is true.
+func AnswerCallbackQuery(queryID client.JsonInt64, text string, showAlert bool) error {
+
+	_, err := tdlibClient.AnswerCallbackQuery(&client.AnswerCallbackQueryRequest{
+		CallbackQueryId: queryID,
+		Text:            text,
+		ShowAlert:       showAlert,
+	})
+
+	return err
+
+}
+
+// inlineKeyboard returns the reply markup of an inline keyboard with the input rows,
+// or nil if there are no rows.
+func inlineKeyboard(rows [][]*client.InlineKeyboardButton) client.ReplyMarkup {
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return &client.ReplyMarkupInlineKeyboard{
+		Rows: rows,
+	}
+
+}
@@ -1,11 +1,17 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"github.com/zelenin/go-tdlib/client"
 )
 
 var (
+
+	// ErrUnsupportedMediaType is returned when sending a media of a type the bot can't send.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+	//
 	sendFunctions = map[string]func(int64, int64, string, string, []*client.TextEntity) (*client.Message, error){
 		client.TypeAnimation: SendAnimation,
 		client.TypePhoto:     SendPhoto,
@@ -26,7 +32,7 @@ func SendMedia(mediaType string, chatID, replyToMessageID int64, remoteFileID, c
 
 	send, found := sendFunctions[mediaType]
 	if !found {
-		return nil, fmt.Errorf("send function not found for media type %s", mediaType)
+		return nil, fmt.Errorf("send function not found for media type %s: %w", mediaType, ErrUnsupportedMediaType)
 	}
 
 	return send(chatID, replyToMessageID, remoteFileID, caption, entities)
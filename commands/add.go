@@ -5,9 +5,10 @@ import (
 	"github.com/shitpostingio/autopostingbot/analysisadapter"
 	"github.com/shitpostingio/autopostingbot/api"
 	"github.com/shitpostingio/autopostingbot/caption"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
 	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
 	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/repository"
 	"github.com/zelenin/go-tdlib/client"
 )
 
@@ -15,7 +16,8 @@ import (
 type AddCommandHandler struct{}
 
 // Handle handles the /add command.
-// /add forces the addition of a post to the database, skipping fingerprinting checks.
+// /add forces the addition of a post to the queue of the channel the user is working on,
+// skipping fingerprinting checks. The same media already on the channel is reported as a duplicate.
 func (AddCommandHandler) Handle(_ string, message, replyToMessage *client.Message) error {
 
 	//
@@ -63,7 +65,19 @@ func (AddCommandHandler) Handle(_ string, message, replyToMessage *client.Messag
 		postCaption = caption.ToHTMLCaption(ft)
 	}
 
-	err = dbwrapper.AddPost(replyToMessage.SenderUserId, media, postCaption)
+	channelID := GetTargetChannelID(message.SenderUserId)
+	err = repository.Posts.AddPost(replyToMessage.SenderUserId, channelID, media, postCaption)
+	if errors.Is(err, storage.ErrDuplicate) {
+
+		// The same media was added to the channel, maybe while the fingerprint was computed
+		duplicate, findErr := repository.Posts.FindPostByUniqueID(channelID, media.FileUniqueID)
+		if findErr == nil {
+			NotifyDuplicate(message, replyToMessage.SenderUserId, channelID, mediaType, &duplicate)
+			return nil
+		}
+
+	}
+
 	if err != nil {
 		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_ADD_ERROR))
 	} else {
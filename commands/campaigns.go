@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"github.com/shitpostingio/autopostingbot/utility"
+	"github.com/zelenin/go-tdlib/client"
+	"strings"
+	"time"
+)
+
+// CampaignsCommandHandler represents the handler of the /campaigns command.
+type CampaignsCommandHandler struct{}
+
+// Handle handles the /campaigns command.
+// /campaigns lists the campaigns of the channel the user is working on,
+// with the number of posts enqueued in their themed queues.
+func (CampaignsCommandHandler) Handle(_ string, message, _ *client.Message) error {
+
+	//
+	channelID := GetTargetChannelID(message.SenderUserId)
+	calendar := posting.GetCampaigns(channelID)
+	if len(calendar) == 0 {
+		_, err := api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_CAMPAIGNS_NO_CAMPAIGNS))
+		return err
+	}
+
+	//
+	location := posting.GetLocation(channelID)
+	now := time.Now()
+	b := strings.Builder{}
+	b.WriteString(l.GetString(l.COMMANDS_CAMPAIGNS_HEADER))
+
+	for _, campaign := range calendar {
+
+		//
+		modeKey := l.COMMANDS_CAMPAIGNS_PREFERRED
+		if campaign.Exclusive {
+			modeKey = l.COMMANDS_CAMPAIGNS_EXCLUSIVE
+		}
+
+		entryKey := l.COMMANDS_CAMPAIGNS_ENTRY
+		if campaign.IsRunning(now) {
+			entryKey = l.COMMANDS_CAMPAIGNS_RUNNING_ENTRY
+		}
+
+		//
+		enqueued := repository.Posts.GetQueueLength(channelID, selection.Policy{Campaign: campaign.Tag, Exclusive: true})
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf(l.GetString(entryKey), campaign.Name, campaign.Tag,
+			utility.FormatDate(campaign.Start.In(location)), utility.FormatDate(campaign.End.In(location)),
+			l.GetString(modeKey), enqueued))
+
+	}
+
+	//
+	_, err := api.SendPlainReplyText(message.ChatId, message.Id, b.String())
+	return err
+
+}
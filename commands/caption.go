@@ -4,8 +4,8 @@ import (
 	"errors"
 	"github.com/shitpostingio/autopostingbot/api"
 	"github.com/shitpostingio/autopostingbot/caption"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
 	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/repository"
 	log "github.com/sirupsen/logrus"
 	"github.com/zelenin/go-tdlib/client"
 )
@@ -48,7 +48,7 @@ func (CaptionCommandHandler) Handle(arguments string, message, replyToMessage *c
 	}
 
 	// Save new caption to database
-	err = dbwrapper.UpdatePostCaptionByUniqueID(fi.Remote.UniqueId, newCaption)
+	err = repository.Posts.UpdatePostCaptionByUniqueID(GetTargetChannelID(message.SenderUserId), fi.Remote.UniqueId, newCaption, message.SenderUserId)
 
 	// Send how the new post looks like
 	_ = PreviewCommandHandler{}.Handle("", message, replyToMessage)
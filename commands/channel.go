@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"github.com/zelenin/go-tdlib/client"
+	"strconv"
+	"strings"
+)
+
+// ChannelCommandHandler represents the handler of the /channel command.
+type ChannelCommandHandler struct{}
+
+// Handle handles the /channel command.
+// /channel lists the channels the bot posts on, highlighting the one the user is working on.
+// By using /channel followed by the number, the name or the id of a channel,
+// the media added by the user and the commands they use will refer to that channel.
+func (ChannelCommandHandler) Handle(arguments string, message, _ *client.Message) error {
+
+	//
+	channels := posting.GetChannels()
+	targetChannelID := GetTargetChannelID(message.SenderUserId)
+
+	//
+	arguments = strings.TrimSpace(arguments)
+	if arguments == "" {
+		return listChannels(message, channels, targetChannelID)
+	}
+
+	//
+	channel, found := findChannel(arguments, channels)
+	if !found {
+		reply := fmt.Sprintf(l.GetString(l.COMMANDS_CHANNEL_NOT_FOUND), arguments)
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, reply)
+		return fmt.Errorf("channel %s not found", arguments)
+	}
+
+	//
+	err := repository.Users.UpdateUserTargetChannel(message.SenderUserId, channel.ChannelID)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_CHANNEL_UNSUCCESSFUL))
+		return err
+	}
+
+	//
+	reply := fmt.Sprintf(l.GetString(l.COMMANDS_CHANNEL_SUCCESSFUL), channel.Name)
+	_, err = api.SendPlainReplyText(message.ChatId, message.Id, reply)
+	return err
+
+}
+
+// GetTargetChannelID returns the id of the channel a user is working on,
+// falling back on the default channel if the user hasn't selected one.
+func GetTargetChannelID(userID int32) int64 {
+
+	//
+	user, err := repository.Users.FindUserByTelegramID(userID)
+	if err == nil {
+
+		_, found := posting.GetChannel(user.TargetChannelID)
+		if found {
+			return user.TargetChannelID
+		}
+
+	}
+
+	return posting.GetChannels()[0].ChannelID
+
+}
+
+// listChannels sends the list of the channels the bot posts on.
+func listChannels(message *client.Message, channels []structs.ChannelConfiguration, targetChannelID int64) error {
+
+	//
+	b := strings.Builder{}
+	b.WriteString(l.GetString(l.COMMANDS_CHANNEL_LIST_HEADER))
+
+	for i, channel := range channels {
+
+		entryKey := l.COMMANDS_CHANNEL_LIST_ENTRY
+		if channel.ChannelID == targetChannelID {
+			entryKey = l.COMMANDS_CHANNEL_LIST_SELECTED_ENTRY
+		}
+
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf(l.GetString(entryKey), i+1, channel.Name, channel.Edition))
+
+	}
+
+	//
+	_, err := api.SendPlainReplyText(message.ChatId, message.Id, b.String())
+	return err
+
+}
+
+// findChannel finds a channel given its number in the list, its name or its id.
+func findChannel(text string, channels []structs.ChannelConfiguration) (structs.ChannelConfiguration, bool) {
+
+	//
+	number, err := strconv.ParseInt(text, 10, 64)
+	if err == nil && number >= 1 && number <= int64(len(channels)) {
+		return channels[number-1], true
+	}
+
+	//
+	for _, channel := range channels {
+		if strings.EqualFold(channel.Name, text) || (err == nil && channel.ChannelID == number) {
+			return channel, true
+		}
+	}
+
+	return structs.ChannelConfiguration{}, false
+
+}
@@ -8,3 +8,12 @@ type Handler interface {
 	// Handle is the method that command handlers implement.
 	Handle(arguments string, message, replyToMessage *client.Message) error
 }
+
+// CallbackHandler is the interface for the handlers of the callback queries
+// sent by the inline keyboards of a command.
+type CallbackHandler interface {
+
+	// HandleCallback handles a callback query, given its data without the handler prefix.
+	// Implementations must answer the query.
+	HandleCallback(data string, query *client.UpdateNewCallbackQuery) error
+}
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"github.com/shitpostingio/autopostingbot/api"
 	"github.com/shitpostingio/autopostingbot/caption"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
 	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/repository"
 	"github.com/zelenin/go-tdlib/client"
 	"strings"
 )
@@ -41,7 +41,7 @@ func (CreditCommandHandler) Handle(arguments string, message, replyToMessage *cl
 	}
 
 	//
-	err = dbwrapper.UpdatePostCaptionByUniqueID(fi.Remote.UniqueId, credit)
+	err = repository.Posts.UpdatePostCaptionByUniqueID(GetTargetChannelID(message.SenderUserId), fi.Remote.UniqueId, credit, message.SenderUserId)
 
 	//
 	_ = PreviewCommandHandler{}.Handle("", message, replyToMessage)
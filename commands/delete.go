@@ -3,8 +3,8 @@ package commands
 import (
 	"errors"
 	"github.com/shitpostingio/autopostingbot/api"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
 	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/repository"
 	"github.com/zelenin/go-tdlib/client"
 )
 
@@ -12,7 +12,8 @@ import (
 type DeleteCommandHandler struct{}
 
 // Handle handles the /delete command.
-// /delete deletes a post (that has yet to be posted) from the database.
+// /delete removes a post (that has yet to be posted) from the queue.
+// The removal can be undone with /undo until the post is purged.
 func (DeleteCommandHandler) Handle(_ string, message, replyToMessage *client.Message) error {
 
 	//
@@ -29,7 +30,7 @@ func (DeleteCommandHandler) Handle(_ string, message, replyToMessage *client.Mes
 	}
 
 	//
-	err = dbwrapper.DeletePostByUniqueID(fi.Remote.UniqueId)
+	err = repository.Posts.RemovePostByUniqueID(GetTargetChannelID(message.SenderUserId), fi.Remote.UniqueId, message.SenderUserId)
 	if err != nil {
 		_, _ = api.SendPlainReplyText(replyToMessage.ChatId, replyToMessage.Id, l.GetString(l.COMMANDS_DELETE_FAILURE))
 	} else {
@@ -1,4 +1,4 @@
-package updates
+package commands
 
 import (
 	"fmt"
@@ -8,6 +8,7 @@ import (
 	"github.com/shitpostingio/autopostingbot/repository"
 	"github.com/shitpostingio/autopostingbot/telegram"
 	"github.com/shitpostingio/autopostingbot/utility"
+	log "github.com/sirupsen/logrus"
 	"github.com/zelenin/go-tdlib/client"
 	"strconv"
 )
@@ -16,6 +17,32 @@ const (
 	telegramMessageIDConversionFactor = 1048576
 )
 
+// NotifyDuplicate records the submission to a channel of a media matching a stored post,
+// for the statistics of the channel, and replies to the message with the matching media.
+func NotifyDuplicate(message *client.Message, submittedBy int32, channelID int64, mediaType string, duplicate *entities.Post) {
+
+	//
+	log.Debugln("Match found: ", duplicate)
+	err := repository.Statistics.RecordDuplicate(entities.Duplicate{
+		SubmittedBy:   submittedBy,
+		ChannelID:     channelID,
+		MatchedPostID: duplicate.ID,
+	})
+
+	if err != nil {
+		log.Error("Unable to record the duplicate: ", err)
+	}
+
+	//
+	formattedText, err := getDuplicateCaption(duplicate)
+	if err != nil {
+		_, _ = api.SendMedia(mediaType, message.ChatId, message.Id, duplicate.Media.FileID, l.GetString(l.UPDATES_MEDIA_UNABLE_TO_GET_DUPLICATE_CAPTION), nil)
+	} else {
+		_, _ = api.SendMedia(mediaType, message.ChatId, message.Id, duplicate.Media.FileID, formattedText.Text, formattedText.Entities)
+	}
+
+}
+
 // getDuplicateCaption returns the caption to be sent in a duplicate notification message.
 func getDuplicateCaption(duplicatePost *entities.Post) (*client.FormattedText, error) {
 
@@ -41,7 +68,7 @@ func getDuplicateCaption(duplicatePost *entities.Post) (*client.FormattedText, e
 		// We then need to convert the messageID from tdlib to normal telegram
 		// Since bots cannot call the getLink method, we need to divide
 		// our message id by the magic number and add 1
-		chatIDStr := strconv.FormatInt(repository.Config.Autoposting.ChannelID, 10)
+		chatIDStr := strconv.FormatInt(duplicatePost.ChannelID, 10)
 		link := fmt.Sprintf("t.me/c/%s/%d", chatIDStr[4:], duplicatePost.MessageID/telegramMessageIDConversionFactor+1)
 		captionEnd := fmt.Sprintf(l.GetString(l.UPDATES_DUPLICATE_DUPLICATE_ADDED_AT), utility.FormatDate(*duplicatePost.PostedAt), link)
 		caption = fmt.Sprintf("%s\n%s", caption, captionEnd)
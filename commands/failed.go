@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"github.com/shitpostingio/autopostingbot/telegram"
+	"github.com/shitpostingio/autopostingbot/utility"
+	"github.com/zelenin/go-tdlib/client"
+	"strconv"
+	"strings"
+)
+
+const (
+	failedRequeueAction = "requeue"
+	failedDiscardAction = "discard"
+)
+
+// FailedCommandHandler represents the handler of the /failed command.
+type FailedCommandHandler struct{}
+
+// Handle handles the /failed command.
+// /failed lists the posts that could not be posted on the channel the user is working on.
+// By using /failed requeue or /failed discard with the number of a post in the list,
+// or in reply to a media, one can put a failed post back in the queue or remove it.
+func (FailedCommandHandler) Handle(arguments string, message, replyToMessage *client.Message) error {
+
+	//
+	failedPosts, err := repository.Posts.GetFailedPosts(GetTargetChannelID(message.SenderUserId))
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.DATABASE_UNABLE_TO_FIND_POST))
+		return err
+	}
+
+	//
+	fields := strings.Fields(arguments)
+	if len(fields) == 0 {
+		return listFailedPosts(message, failedPosts)
+	}
+
+	//
+	action := strings.ToLower(fields[0])
+	if action != failedRequeueAction && action != failedDiscardAction {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_FAILED_USAGE))
+		return fmt.Errorf("unknown /failed action %s", action)
+	}
+
+	//
+	post, err := getFailedPost(fields, replyToMessage, failedPosts)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_FAILED_USAGE))
+		return err
+	}
+
+	//
+	if action == failedDiscardAction {
+
+		err = repository.Posts.RemovePostByUniqueID(post.ChannelID, post.Media.FileUniqueID, message.SenderUserId)
+		if err != nil {
+			_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_DELETE_FAILURE))
+		} else {
+			_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_FAILED_DISCARDED))
+		}
+
+		return err
+
+	}
+
+	//
+	err = repository.Posts.RequeueFailedPostByUniqueID(post.ChannelID, post.Media.FileUniqueID)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_FAILED_NOT_FOUND))
+		return err
+	}
+
+	_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_FAILED_REQUEUED))
+
+	// The queue may have been empty
+	if repository.Posts.GetQueueLength(post.ChannelID, posting.GetQueueSelection(post.ChannelID)) == 1 {
+		posting.ForcePostScheduling(post.ChannelID)
+	}
+
+	return nil
+
+}
+
+// listFailedPosts sends the list of the failed posts.
+func listFailedPosts(message *client.Message, failedPosts []entities.Post) error {
+
+	//
+	if len(failedPosts) == 0 {
+		_, err := api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_FAILED_NO_FAILED_POSTS))
+		return err
+	}
+
+	//
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_FAILED_LIST_HEADER), len(failedPosts)))
+
+	for i, post := range failedPosts {
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_FAILED_LIST_ENTRY),
+			i+1, post.Media.Type, getUserName(post.AddedBy), utility.FormatDate(post.AddedAt), post.FailedAttempts, post.LastError))
+	}
+
+	//
+	_, err := api.SendPlainReplyText(message.ChatId, message.Id, b.String())
+	return err
+
+}
+
+// getFailedPost returns the failed post the command refers to,
+// either by its number in the list or by the media it replies to.
+func getFailedPost(fields []string, replyToMessage *client.Message, failedPosts []entities.Post) (entities.Post, error) {
+
+	//
+	if len(fields) > 1 {
+
+		index, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return entities.Post{}, err
+		}
+
+		if index < 1 || index > len(failedPosts) {
+			return entities.Post{}, fmt.Errorf("failed post %d out of range", index)
+		}
+
+		return failedPosts[index-1], nil
+
+	}
+
+	//
+	if replyToMessage == nil {
+		return entities.Post{}, errors.New("no failed post specified")
+	}
+
+	fi, err := api.GetMediaFileInfo(replyToMessage)
+	if err != nil {
+		return entities.Post{}, err
+	}
+
+	// Only failed posts can be handled with this command
+	for _, post := range failedPosts {
+		if post.Media.FileUniqueID == fi.Remote.UniqueId {
+			return post, nil
+		}
+	}
+
+	return entities.Post{}, errors.New("the media is not among the failed posts")
+
+}
+
+// getUserName returns the name of a user given its ID,
+// falling back to the ID itself if the user can't be retrieved.
+func getUserName(userID int32) string {
+
+	user, err := api.GetUserByID(userID)
+	if err != nil {
+		return strconv.Itoa(int(userID))
+	}
+
+	return telegram.GetNameFromUser(user)
+
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"github.com/shitpostingio/autopostingbot/utility"
+	"github.com/zelenin/go-tdlib/client"
+	"strconv"
+	"strings"
+)
+
+const (
+
+	// historyCaptionLength is the number of characters of each caption shown by /history,
+	// so that the whole history fits in a message.
+	historyCaptionLength = 150
+)
+
+// HistoryCommandHandler represents the handler of the /history command.
+type HistoryCommandHandler struct{}
+
+// Handle handles the /history command.
+// /history shows the previous captions of a post, who replaced them and when.
+func (HistoryCommandHandler) Handle(_ string, message, replyToMessage *client.Message) error {
+
+	//
+	post, err := findRepliedPost(message, replyToMessage)
+	if err != nil {
+		return err
+	}
+
+	//
+	if len(post.CaptionHistory) == 0 {
+		_, err = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_HISTORY_NO_REVISIONS))
+		return err
+	}
+
+	//
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_HISTORY_HEADER), len(post.CaptionHistory)))
+
+	for i, revision := range post.CaptionHistory {
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_HISTORY_ENTRY),
+			i+1, getEditorName(revision.EditedBy), utility.FormatDate(revision.EditedAt), shortenCaption(revision.Caption, historyCaptionLength)))
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_HISTORY_CURRENT), shortenCaption(post.Caption, historyCaptionLength)))
+
+	//
+	_, err = api.SendPlainReplyText(message.ChatId, message.Id, b.String())
+	return err
+
+}
+
+// RevertCommandHandler represents the handler of the /revert command.
+type RevertCommandHandler struct{}
+
+// Handle handles the /revert command.
+// /revert restores a previous caption of a post, by its number in the list shown by /history.
+// By using /revert without any additional argument, one can undo the last edit.
+// Reverting is an edit itself, and can be reverted in turn.
+func (RevertCommandHandler) Handle(arguments string, message, replyToMessage *client.Message) error {
+
+	//
+	post, err := findRepliedPost(message, replyToMessage)
+	if err != nil {
+		return err
+	}
+
+	//
+	if len(post.CaptionHistory) == 0 {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_HISTORY_NO_REVISIONS))
+		return errors.New("no caption to revert to")
+	}
+
+	//
+	index := len(post.CaptionHistory)
+	if arguments = strings.TrimSpace(arguments); arguments != "" {
+
+		index, err = strconv.Atoi(arguments)
+		if err != nil || index < 1 || index > len(post.CaptionHistory) {
+			_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_REVERT_USAGE))
+			return fmt.Errorf("invalid revision %s", arguments)
+		}
+
+	}
+
+	//
+	revision := post.CaptionHistory[index-1]
+	err = repository.Posts.UpdatePostCaptionByUniqueID(post.ChannelID, post.Media.FileUniqueID, revision.Caption, message.SenderUserId)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_REVERT_FAILURE))
+		return err
+	}
+
+	// Send how the post looks like now
+	_ = PreviewCommandHandler{}.Handle("", message, replyToMessage)
+	return nil
+
+}
+
+// findRepliedPost returns the post with the media a message replies to,
+// notifying the user if there's none.
+func findRepliedPost(message, replyToMessage *client.Message) (entities.Post, error) {
+
+	//
+	if replyToMessage == nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_REPLY_TO_MEDIA_FILE))
+		return entities.Post{}, errors.New("reply to message nil")
+	}
+
+	//
+	fi, err := api.GetMediaFileInfo(replyToMessage)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_REPLY_TO_MEDIA_FILE))
+		return entities.Post{}, err
+	}
+
+	//
+	post, err := repository.Posts.FindPostByUniqueID(GetTargetChannelID(message.SenderUserId), fi.Remote.UniqueId)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.DATABASE_UNABLE_TO_FIND_POST))
+	}
+
+	return post, err
+
+}
+
+// getEditorName returns the name of the user who edited a caption.
+// Captions edited on the channel have no editor.
+func getEditorName(userID int32) string {
+
+	if userID == 0 {
+		return l.GetString(l.COMMANDS_HISTORY_CHANNEL_EDITOR)
+	}
+
+	return getUserName(userID)
+
+}
+
+// shortenCaption returns up to length characters of a caption, or a placeholder if it's empty.
+func shortenCaption(caption string, length int) string {
+
+	runes := []rune(caption)
+	switch {
+	case len(runes) == 0:
+		return l.GetString(l.COMMANDS_HISTORY_EMPTY_CAPTION)
+	case len(runes) > length:
+		return string(runes[:length]) + "…"
+	}
+
+	return caption
+
+}
@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"github.com/hako/durafmt"
 	"github.com/shitpostingio/autopostingbot/api"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
 	l "github.com/shitpostingio/autopostingbot/localization"
 	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/repository"
 	"github.com/shitpostingio/autopostingbot/telegram"
 	"github.com/shitpostingio/autopostingbot/utility"
 	log "github.com/sirupsen/logrus"
@@ -39,7 +39,7 @@ func (InfoCommandHandler) Handle(_ string, message, replyToMessage *client.Messa
 	}
 
 	//
-	post, err := dbwrapper.FindPostByUniqueID(fi.Remote.UniqueId)
+	post, err := repository.Posts.FindPostByUniqueID(GetTargetChannelID(message.SenderUserId), fi.Remote.UniqueId)
 	if err != nil {
 		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.DATABASE_UNABLE_TO_FIND_POST))
 		return err
@@ -63,7 +63,7 @@ func (InfoCommandHandler) Handle(_ string, message, replyToMessage *client.Messa
 
 		//
 		reply = fmt.Sprintf(l.GetString(l.COMMANDS_INFO_ALREADY_POSTED),
-			post.AddedBy, name, utility.FormatDate(post.AddedAt), utility.FormatDate(*post.PostedAt), posting.GetPostingManager().GetEditionName(), post.MessageID)
+			post.AddedBy, name, utility.FormatDate(post.AddedAt), utility.FormatDate(*post.PostedAt), getEditionName(post.ChannelID), post.MessageID)
 
 		//
 		ft, err := api.GetFormattedText(reply)
@@ -79,12 +79,32 @@ func (InfoCommandHandler) Handle(_ string, message, replyToMessage *client.Messa
 
 	}
 
-	//
-	position := dbwrapper.GetQueuePositionByAddTime(post.AddedAt)
-	timeToPost := posting.GetNextPostTime().Add(posting.GetPostingManager().EstimatePostTime(position - 1))
-	durationUntilPost := durafmt.Parse(time.Until(timeToPost).Truncate(time.Minute))
-	reply = fmt.Sprintf(l.GetString(l.COMMANDS_INFO_NOT_YET_POSTED),
-		position, post.AddedBy, name, utility.FormatDate(post.AddedAt), durationUntilPost.String(), utility.FormatDate(timeToPost))
+	// Pinned posts are not part of the queue, while the ones the campaigns hold back
+	// have no position in it until the selection changes
+	policy := posting.GetQueueSelection(post.ChannelID)
+	switch {
+	case post.ScheduledAt != nil:
+		reply = fmt.Sprintf(l.GetString(l.COMMANDS_INFO_SCHEDULED),
+			post.AddedBy, name, utility.FormatDate(post.AddedAt), utility.FormatDate(*post.ScheduledAt))
+	case !policy.Admits(&post) && len(post.Tags) > 0:
+		reply = fmt.Sprintf(l.GetString(l.COMMANDS_INFO_HELD_FOR_CAMPAIGN),
+			formatTags(post.Tags), post.AddedBy, name, utility.FormatDate(post.AddedAt))
+	case !policy.Admits(&post):
+		reply = fmt.Sprintf(l.GetString(l.COMMANDS_INFO_HELD_BY_CAMPAIGN),
+			formatTags([]string{policy.Campaign}), post.AddedBy, name, utility.FormatDate(post.AddedAt))
+	default:
+		position := repository.Posts.GetQueuePosition(&post, policy)
+		timeToPost, paused := posting.EstimatePostTime(post.ChannelID, position)
+		if paused {
+			reply = fmt.Sprintf(l.GetString(l.COMMANDS_INFO_NOT_YET_POSTED_PAUSED),
+				position, post.AddedBy, name, utility.FormatDate(post.AddedAt))
+			break
+		}
+
+		durationUntilPost := durafmt.Parse(time.Until(timeToPost).Truncate(time.Minute))
+		reply = fmt.Sprintf(l.GetString(l.COMMANDS_INFO_NOT_YET_POSTED),
+			position, post.AddedBy, name, utility.FormatDate(post.AddedAt), durationUntilPost.String(), utility.FormatDate(timeToPost))
+	}
 
 	//
 	ft, err := api.GetFormattedText(reply)
@@ -100,3 +120,15 @@ func (InfoCommandHandler) Handle(_ string, message, replyToMessage *client.Messa
 	return err
 
 }
+
+// getEditionName returns the name of the edition running on a channel.
+func getEditionName(channelID int64) string {
+
+	e, err := posting.GetEdition(channelID)
+	if err != nil {
+		return ""
+	}
+
+	return e.GetEditionName()
+
+}
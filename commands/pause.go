@@ -5,7 +5,6 @@ import (
 	"github.com/shitpostingio/autopostingbot/api"
 	l "github.com/shitpostingio/autopostingbot/localization"
 	"github.com/shitpostingio/autopostingbot/posting"
-	"github.com/shitpostingio/autopostingbot/telegram"
 	log "github.com/sirupsen/logrus"
 	"github.com/zelenin/go-tdlib/client"
 	"strconv"
@@ -13,33 +12,31 @@ import (
 	"time"
 )
 
+const (
+	pauseUntilKeyword = "until"
+)
+
 // PauseCommandHandler represents the handler of the /pause command.
 type PauseCommandHandler struct{}
 
 // Handle handles the /pause command.
-// /pause pauses the posting for a certain amount of hours, 1 by default.
+// /pause pauses the posting on the channel the user is working on
+// until /resume is used, for a certain amount of time (30m, 2h, 2d, hours by default)
+// or until a certain time (until 18:00).
 // The posting manager will not allow pauses too close between each other,
 // as to prevent accidental pauses.
 func (PauseCommandHandler) Handle(arguments string, message, replyToMessage *client.Message) error {
 
 	//
-	var toParse string
-	if arguments == "" {
-		toParse = "1h"
-	} else if strings.HasSuffix(arguments, "h") {
-		toParse = arguments
-	} else {
-		toParse = arguments + "h"
-	}
-
-	//
-	duration, err := time.ParseDuration(toParse)
+	channelID := GetTargetChannelID(message.SenderUserId)
+	until, err := parsePauseEnd(strings.TrimSpace(arguments), time.Now().In(posting.GetLocation(channelID)))
 	if err != nil {
-		duration = 1 * time.Hour
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_PAUSE_USAGE))
+		return err
 	}
 
 	//
-	err = posting.RequestPause(duration)
+	err = posting.RequestPause(channelID, until, message.SenderUserId)
 	if err != nil {
 		reply := fmt.Sprintf(l.GetString(l.COMMANDS_PAUSE_UNSUCCESSFUL), err)
 		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, reply)
@@ -47,16 +44,56 @@ func (PauseCommandHandler) Handle(arguments string, message, replyToMessage *cli
 	}
 
 	//
-	var whoPaused string
-	user, err := api.GetUserByID(message.SenderUserId)
+	log.Info(fmt.Sprintf("%s paused posting", getUserName(message.SenderUserId)))
+	return StatusCommandHandler{}.Handle("", message, replyToMessage)
+
+}
+
+// parsePauseEnd returns the time at which a pause should end, given the /pause arguments.
+// No arguments mean the pause lasts until resumed, and the zero time is returned.
+func parsePauseEnd(arguments string, now time.Time) (time.Time, error) {
+
+	//
+	if arguments == "" {
+		return time.Time{}, nil
+	}
+
+	//
+	if strings.HasPrefix(strings.ToLower(arguments), pauseUntilKeyword) {
+		return parseScheduleTime(strings.TrimSpace(arguments[len(pauseUntilKeyword):]), now)
+	}
+
+	//
+	duration, err := parsePauseDuration(arguments)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return now.Add(duration), nil
+
+}
+
+// parsePauseDuration parses durations such as 30m, 2h or 2d.
+// Numbers without a unit are hours.
+func parsePauseDuration(text string) (time.Duration, error) {
+
+	// Days are not supported by time.ParseDuration
+	duration, err := time.ParseDuration(text)
+	if hours, atoiErr := strconv.Atoi(text); atoiErr == nil {
+		duration, err = time.Duration(hours)*time.Hour, nil
+	} else if days, atoiErr := strconv.Atoi(strings.TrimSuffix(text, "d")); atoiErr == nil && strings.HasSuffix(text, "d") {
+		duration, err = time.Duration(days)*24*time.Hour, nil
+	}
+
 	if err != nil {
-		whoPaused = telegram.GetNameFromUser(user)
-	} else {
-		whoPaused = strconv.Itoa(int(message.SenderUserId))
+		return 0, err
 	}
+
 	//
+	if duration <= 0 {
+		return 0, fmt.Errorf("non positive pause duration %s", text)
+	}
 
-	log.Info(fmt.Sprintf("%s paused posting", whoPaused))
-	return StatusCommandHandler{}.Handle("", message, replyToMessage)
+	return duration, nil
 
 }
@@ -2,8 +2,9 @@ package commands
 
 import (
 	"github.com/shitpostingio/autopostingbot/api"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
 	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/repository"
 	"github.com/zelenin/go-tdlib/client"
 )
 
@@ -11,11 +12,13 @@ import (
 type PeekCommandHandler struct{}
 
 // Handle handles the /peek command.
-// /peek returns the first post in the queue, along with its caption.
+// /peek returns the first post in the queue of the channel the user is working on,
+// along with its caption.
 func (PeekCommandHandler) Handle(_ string, message, _ *client.Message) error {
 
 	//
-	nextPost, err := dbwrapper.GetNextPost()
+	channelID := GetTargetChannelID(message.SenderUserId)
+	nextPost, err := repository.Posts.GetNextPost(channelID, posting.GetQueueSelection(channelID))
 	if err != nil {
 		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_PEEK_NO_POST_FOUND))
 		return err
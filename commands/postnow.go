@@ -3,9 +3,9 @@ package commands
 import (
 	"errors"
 	"github.com/shitpostingio/autopostingbot/api"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
 	l "github.com/shitpostingio/autopostingbot/localization"
 	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/repository"
 	"github.com/zelenin/go-tdlib/client"
 )
 
@@ -30,7 +30,7 @@ func (PostNowCommandHandler) Handle(_ string, message, replyToMessage *client.Me
 	}
 
 	//
-	post, err := dbwrapper.FindPostByUniqueID(fi.Remote.UniqueId)
+	post, err := repository.Posts.FindPostByUniqueID(GetTargetChannelID(message.SenderUserId), fi.Remote.UniqueId)
 	if err != nil {
 		_, _ = api.SendPlainReplyText(replyToMessage.ChatId, replyToMessage.Id, l.GetString(l.DATABASE_UNABLE_TO_FIND_POST))
 		return err
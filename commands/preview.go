@@ -3,8 +3,9 @@ package commands
 import (
 	"errors"
 	"github.com/shitpostingio/autopostingbot/api"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
 	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/repository"
 	"github.com/zelenin/go-tdlib/client"
 )
 
@@ -29,12 +30,20 @@ func (PreviewCommandHandler) Handle(_ string, message, replyToMessage *client.Me
 	}
 
 	//
-	post, err := dbwrapper.FindPostByUniqueID(fi.Remote.UniqueId)
+	post, err := repository.Posts.FindPostByUniqueID(GetTargetChannelID(message.SenderUserId), fi.Remote.UniqueId)
 	if err != nil {
 		_, _ = api.SendPlainReplyText(replyToMessage.ChatId, replyToMessage.Id, l.GetString(l.DATABASE_UNABLE_TO_FIND_POST))
 		return err
 	}
 
+	return sendPostPreview(message.ChatId, message.Id, &post)
+
+}
+
+// sendPostPreview sends the media of a post with its caption, how it will appear if it was to be posted,
+// in reply to a certain replyToMessageID.
+func sendPostPreview(chatID, replyToMessageID int64, post *entities.Post) error {
+
 	//
 	ft, err := api.GetFormattedText(post.Caption)
 	if err != nil {
@@ -45,7 +54,7 @@ func (PreviewCommandHandler) Handle(_ string, message, replyToMessage *client.Me
 	}
 
 	//
-	_, err = api.SendMedia(post.Media.Type, message.ChatId, message.Id, post.Media.FileID, ft.Text, ft.Entities)
+	_, err = api.SendMedia(post.Media.Type, chatID, replyToMessageID, post.Media.FileID, ft.Text, ft.Entities)
 	return err
 
 }
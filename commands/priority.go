@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"github.com/zelenin/go-tdlib/client"
+	"strings"
+)
+
+var (
+	priorities = map[string]int{
+		"high":   entities.PriorityHigh,
+		"normal": entities.PriorityNormal,
+		"low":    entities.PriorityLow,
+	}
+)
+
+// PriorityCommandHandler represents the handler of the /priority command.
+type PriorityCommandHandler struct{}
+
+// Handle handles the /priority command.
+// /priority sets the priority of an enqueued post to high, normal or low.
+// Posts with a higher priority will be posted before the others.
+func (PriorityCommandHandler) Handle(arguments string, message, replyToMessage *client.Message) error {
+
+	//
+	if replyToMessage == nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_REPLY_TO_MEDIA_FILE))
+		return errors.New("reply to message nil")
+	}
+
+	//
+	priorityName := strings.ToLower(strings.TrimSpace(arguments))
+	priority, found := priorities[priorityName]
+	if !found {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_PRIORITY_USAGE))
+		return fmt.Errorf("unknown priority %s", arguments)
+	}
+
+	//
+	fi, err := api.GetMediaFileInfo(replyToMessage)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_REPLY_TO_MEDIA_FILE))
+		return err
+	}
+
+	//
+	post, err := repository.Posts.FindPostByUniqueID(GetTargetChannelID(message.SenderUserId), fi.Remote.UniqueId)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.DATABASE_UNABLE_TO_FIND_POST))
+		return err
+	}
+
+	//
+	if post.PostedAt != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_PRIORITY_ALREADY_POSTED))
+		return errors.New("post already posted")
+	}
+
+	//
+	err = repository.Posts.UpdatePostPriorityByUniqueID(post.ChannelID, fi.Remote.UniqueId, priority)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_PRIORITY_UNSUCCESSFUL))
+		return err
+	}
+
+	//
+	post.Priority = priority
+	position := repository.Posts.GetQueuePosition(&post, posting.GetQueueSelection(post.ChannelID))
+	reply := fmt.Sprintf(l.GetString(l.COMMANDS_PRIORITY_SUCCESSFUL), priorityName, position)
+	_, err = api.SendPlainReplyText(message.ChatId, message.Id, reply)
+	return err
+
+}
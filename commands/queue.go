@@ -0,0 +1,219 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"github.com/shitpostingio/autopostingbot/utility"
+	"github.com/zelenin/go-tdlib/client"
+	"strconv"
+	"strings"
+)
+
+const (
+	// queuePageSize is the number of posts in a page of /queue.
+	queuePageSize = 5
+
+	// queueCaptionLength is the number of characters of each caption shown by /queue.
+	queueCaptionLength = 60
+
+	// queuePageCallback and queuePostCallback prefix the data of the buttons
+	// that show a page of the queue and the preview of a post.
+	queuePageCallback = "queue:page:"
+	queuePostCallback = "queue:post:"
+)
+
+// QueueCommandHandler represents the handler of the /queue command.
+type QueueCommandHandler struct{}
+
+// Handle handles the /queue command.
+// /queue lists the posts enqueued on the channel the user is working on, page by page,
+// with buttons to move between pages and to preview each post.
+// The page to show first can be passed as argument.
+func (QueueCommandHandler) Handle(arguments string, message, _ *client.Message) error {
+
+	//
+	page := 1
+	if strings.TrimSpace(arguments) != "" {
+
+		var err error
+		page, err = strconv.Atoi(strings.TrimSpace(arguments))
+		if err != nil || page < 1 {
+			_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_QUEUE_USAGE))
+			return fmt.Errorf("invalid queue page %s", arguments)
+		}
+
+	}
+
+	//
+	channelID := GetTargetChannelID(message.SenderUserId)
+	text, keyboard, err := getQueuePage(channelID, page)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.DATABASE_UNABLE_TO_FIND_POST))
+		return err
+	}
+
+	//
+	_, err = api.SendKeyboardReplyText(message.ChatId, message.Id, text, keyboard)
+	return err
+
+}
+
+// HandleCallback handles the buttons of the /queue listing:
+// page:channelID:page shows a page of the queue of a channel,
+// post:channelID:uniqueID sends the preview of a post enqueued on a channel.
+func (QueueCommandHandler) HandleCallback(data string, query *client.UpdateNewCallbackQuery) error {
+
+	//
+	fields := strings.Split(data, ":")
+	switch {
+	case len(fields) == 3 && fields[0] == "page":
+
+		channelID, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			break
+		}
+
+		page, err := strconv.Atoi(fields[2])
+		if err != nil {
+			break
+		}
+
+		return showQueuePage(query, channelID, page)
+
+	case len(fields) == 3 && fields[0] == "post":
+
+		channelID, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			break
+		}
+
+		return previewQueuedPost(query, channelID, fields[2])
+
+	}
+
+	//
+	_ = api.AnswerCallbackQuery(query.Id, "", false)
+	return fmt.Errorf("invalid queue callback data %s", data)
+
+}
+
+// showQueuePage replaces the listing the query comes from with another page of the queue.
+func showQueuePage(query *client.UpdateNewCallbackQuery, channelID int64, page int) error {
+
+	//
+	text, keyboard, err := getQueuePage(channelID, page)
+	if err != nil {
+		_ = api.AnswerCallbackQuery(query.Id, l.GetString(l.DATABASE_UNABLE_TO_FIND_POST), true)
+		return err
+	}
+
+	//
+	_, err = api.EditKeyboardText(query.ChatId, query.MessageId, text, keyboard)
+	_ = api.AnswerCallbackQuery(query.Id, "", false)
+	return err
+
+}
+
+// previewQueuedPost sends the preview of a post enqueued on a channel
+// in reply to the listing the query comes from.
+func previewQueuedPost(query *client.UpdateNewCallbackQuery, channelID int64, uniqueID string) error {
+
+	// The post may have been posted or removed since the listing was sent
+	post, err := repository.Posts.FindPostByUniqueID(channelID, uniqueID)
+	if err != nil || post.PostedAt != nil {
+		_ = api.AnswerCallbackQuery(query.Id, l.GetString(l.COMMANDS_QUEUE_POST_NOT_FOUND), true)
+		return err
+	}
+
+	//
+	_ = api.AnswerCallbackQuery(query.Id, "", false)
+	return sendPostPreview(query.ChatId, query.MessageId, &post)
+
+}
+
+// getQueuePage returns the text and the inline keyboard of a page of the queue of a channel.
+// Pages before the first one or past the end of the queue are replaced by the closest one.
+func getQueuePage(channelID int64, page int) (string, [][]*client.InlineKeyboardButton, error) {
+
+	//
+	queue, err := repository.Posts.GetQueue(channelID, posting.GetQueueSelection(channelID))
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(queue) == 0 {
+		return l.GetString(l.COMMANDS_QUEUE_EMPTY), nil, nil
+	}
+
+	//
+	page, pages, first, last := queuePageBounds(len(queue), page)
+
+	//
+	location := posting.GetLocation(channelID)
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_QUEUE_HEADER), len(queue), page, pages))
+
+	var previews []*client.InlineKeyboardButton
+	for i := first; i < last; i++ {
+
+		post := queue[i]
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_QUEUE_ENTRY),
+			i+1, post.Media.Type, getUserName(post.AddedBy), utility.FormatDate(post.AddedAt.In(location)),
+			shortenCaption(post.Caption, queueCaptionLength)))
+
+		previews = append(previews, api.NewCallbackButton(strconv.Itoa(i+1), fmt.Sprintf("%s%d:%s", queuePostCallback, channelID, post.Media.FileUniqueID)))
+
+	}
+
+	//
+	keyboard := [][]*client.InlineKeyboardButton{previews}
+	if pages > 1 {
+
+		var navigation []*client.InlineKeyboardButton
+		if page > 1 {
+			navigation = append(navigation, api.NewCallbackButton("«", fmt.Sprintf("%s%d:%d", queuePageCallback, channelID, page-1)))
+		}
+
+		if page < pages {
+			navigation = append(navigation, api.NewCallbackButton("»", fmt.Sprintf("%s%d:%d", queuePageCallback, channelID, page+1)))
+		}
+
+		keyboard = append(keyboard, navigation)
+
+	}
+
+	return b.String(), keyboard, nil
+
+}
+
+// queuePageBounds returns the page of a queue of the input length to show, given
+// the requested one, along with the number of pages and the range of the positions
+// of the posts in the page, first included and last excluded.
+// Pages before the first one or past the end of the queue are replaced by the closest one.
+func queuePageBounds(length, page int) (shown, pages, first, last int) {
+
+	//
+	pages = (length + queuePageSize - 1) / queuePageSize
+	shown = page
+	if shown > pages {
+		shown = pages
+	}
+
+	if shown < 1 {
+		shown = 1
+	}
+
+	//
+	first = (shown - 1) * queuePageSize
+	last = first + queuePageSize
+	if last > length {
+		last = length
+	}
+
+	return
+
+}
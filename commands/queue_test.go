@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"testing"
+)
+
+func TestQueuePageBounds(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		length    int
+		page      int
+		wantShown int
+		wantPages int
+		wantFirst int
+		wantLast  int
+	}{
+		{"first page", 12, 1, 1, 3, 0, 5},
+		{"middle page", 12, 2, 2, 3, 5, 10},
+		{"partial last page", 12, 3, 3, 3, 10, 12},
+		{"full last page", 10, 2, 2, 2, 5, 10},
+		{"past the end", 12, 7, 3, 3, 10, 12},
+		{"zero", 12, 0, 1, 3, 0, 5},
+		{"negative", 12, -4, 1, 3, 0, 5},
+		{"single page", 3, 1, 1, 1, 0, 3},
+		{"empty queue", 0, 2, 1, 0, 0, 0},
+	}
+
+	for _, test := range tests {
+
+		shown, pages, first, last := queuePageBounds(test.length, test.page)
+		if shown != test.wantShown || pages != test.wantPages || first != test.wantFirst || last != test.wantLast {
+			t.Errorf("%s: got page %d of %d with posts [%d, %d), want page %d of %d with posts [%d, %d)",
+				test.name, shown, pages, first, last, test.wantShown, test.wantPages, test.wantFirst, test.wantLast)
+		}
+
+	}
+
+}
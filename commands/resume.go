@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting"
+	log "github.com/sirupsen/logrus"
+	"github.com/zelenin/go-tdlib/client"
+)
+
+// ResumeCommandHandler represents the handler of the /resume command.
+type ResumeCommandHandler struct{}
+
+// Handle handles the /resume command.
+// /resume cancels the active pause on the channel the user is working on.
+func (ResumeCommandHandler) Handle(_ string, message, replyToMessage *client.Message) error {
+
+	//
+	err := posting.RequestResume(GetTargetChannelID(message.SenderUserId))
+	if err != nil {
+		reply := fmt.Sprintf(l.GetString(l.COMMANDS_RESUME_UNSUCCESSFUL), err)
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, reply)
+		return err
+	}
+
+	//
+	log.Info(fmt.Sprintf("%s resumed posting", getUserName(message.SenderUserId)))
+	return StatusCommandHandler{}.Handle("", message, replyToMessage)
+
+}
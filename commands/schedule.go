@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"github.com/shitpostingio/autopostingbot/utility"
+	"github.com/zelenin/go-tdlib/client"
+	"strings"
+	"time"
+)
+
+const (
+	scheduleCancelArgument = "cancel"
+)
+
+// ScheduleCommandHandler represents the handler of the /schedule command.
+type ScheduleCommandHandler struct{}
+
+// Handle handles the /schedule command.
+// /schedule pins an enqueued post to a specific date and time, in one of the
+// 2006-01-02 15:04, 02/01/2006 15:04, 02/01 15:04 or 15:04 formats.
+// The post will be posted at that time, regardless of the posting rate.
+// By using /schedule cancel, the post will be put back in the queue.
+func (ScheduleCommandHandler) Handle(arguments string, message, replyToMessage *client.Message) error {
+
+	//
+	if replyToMessage == nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_REPLY_TO_MEDIA_FILE))
+		return errors.New("reply to message nil")
+	}
+
+	//
+	fi, err := api.GetMediaFileInfo(replyToMessage)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_REPLY_TO_MEDIA_FILE))
+		return err
+	}
+
+	//
+	post, err := repository.Posts.FindPostByUniqueID(GetTargetChannelID(message.SenderUserId), fi.Remote.UniqueId)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.DATABASE_UNABLE_TO_FIND_POST))
+		return err
+	}
+
+	//
+	var pinTime *time.Time
+	if !strings.EqualFold(arguments, scheduleCancelArgument) {
+
+		parsedTime, err := parseScheduleTime(arguments, time.Now().In(posting.GetLocation(post.ChannelID)))
+		if err != nil {
+			_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_SCHEDULE_USAGE))
+			return err
+		}
+
+		pinTime = &parsedTime
+
+	}
+
+	//
+	err = posting.RequestPin(&post, pinTime)
+	if err != nil {
+		reply := fmt.Sprintf(l.GetString(l.COMMANDS_SCHEDULE_UNSUCCESSFUL), err)
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, reply)
+		return err
+	}
+
+	//
+	reply := l.GetString(l.COMMANDS_SCHEDULE_CANCELLED)
+	if pinTime != nil {
+		reply = fmt.Sprintf(l.GetString(l.COMMANDS_SCHEDULE_SUCCESSFUL), utility.FormatDate(*pinTime))
+	}
+
+	_, err = api.SendPlainReplyText(message.ChatId, message.Id, reply)
+	return err
+
+}
+
+// parseScheduleTime parses the time a post needs to be scheduled for,
+// in the location of the input current time.
+// Times without a date refer to their next occurrence.
+func parseScheduleTime(text string, now time.Time) (time.Time, error) {
+
+	// Full dates
+	for _, layout := range []string{"2006-01-02 15:04", "02/01/2006 15:04"} {
+
+		t, err := time.ParseInLocation(layout, text, now.Location())
+		if err == nil {
+			return t, nil
+		}
+
+	}
+
+	// Dates without the year refer to the current year or the next one
+	t, err := time.ParseInLocation("02/01 15:04", text, now.Location())
+	if err == nil {
+
+		t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(1, 0, 0)
+		}
+
+		return t, nil
+
+	}
+
+	// Times refer to today or tomorrow
+	t, err = time.ParseInLocation("15:04", text, now.Location())
+	if err == nil {
+
+		t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+
+		return t, nil
+
+	}
+
+	return time.Time{}, fmt.Errorf("unable to parse schedule time %s", text)
+
+}
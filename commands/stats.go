@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	"github.com/shitpostingio/autopostingbot/documentstore/statistics"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"github.com/zelenin/go-tdlib/client"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultStatsDays     = 7
+	maxStatsContributors = 10
+
+	// allTimeStats is the period covering the whole history.
+	allTimeStats = "all"
+)
+
+var (
+	// statsPeriods maps the named periods /stats accepts to their length in days.
+	statsPeriods = map[string]int{
+		"day":   1,
+		"week":  7,
+		"month": 30,
+		"year":  365,
+	}
+)
+
+// StatsCommandHandler represents the handler of the /stats command.
+type StatsCommandHandler struct{}
+
+// Handle handles the /stats command.
+// /stats reports the activity on the channel the user is working on:
+// posts per day, average wait in the queue, media type mix, duplicate rate
+// and the adds, posts, deletions and duplicates of the most active contributors.
+// The period can be passed as argument: day, week, month, year, all or a number of days.
+func (StatsCommandHandler) Handle(arguments string, message, _ *client.Message) error {
+
+	//
+	days, err := parseStatsPeriod(arguments)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_STATS_USAGE))
+		return err
+	}
+
+	//
+	until := time.Now()
+	var since time.Time
+	header := l.GetString(l.COMMANDS_STATS_HEADER_ALL_TIME)
+	if days > 0 {
+		since = until.AddDate(0, 0, -days)
+		header = fmt.Sprintf(l.GetString(l.COMMANDS_STATS_HEADER), days)
+	}
+
+	channelID := GetTargetChannelID(message.SenderUserId)
+	report, err := repository.Statistics.GetStatistics(channelID, since, until)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_STATS_FAILURE))
+		return err
+	}
+
+	//
+	if report.Added == 0 && report.Posted == 0 && report.Deleted == 0 && report.Duplicates == 0 {
+		_, err = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_STATS_NO_ACTIVITY))
+		return err
+	}
+
+	//
+	b := strings.Builder{}
+	b.WriteString(header)
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_STATS_POSTS), report.Posted, report.PostsPerDay(), report.Added))
+
+	if report.Posted > 0 {
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_STATS_QUEUE_WAIT), report.AverageQueueWait.Truncate(time.Minute)))
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_STATS_MEDIA_TYPES), formatMediaTypes(&report)))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_STATS_DUPLICATES),
+		report.Duplicates, report.Added+report.Duplicates, report.DuplicateRate()*100))
+
+	//
+	b.WriteString("\n\n")
+	b.WriteString(l.GetString(l.COMMANDS_STATS_CONTRIBUTORS_HEADER))
+	for i, contributor := range report.Contributors {
+
+		if i == maxStatsContributors {
+			break
+		}
+
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_STATS_CONTRIBUTOR_ENTRY),
+			i+1, getUserName(contributor.UserID), contributor.Added, contributor.Posted, contributor.Deleted, contributor.Duplicates))
+
+	}
+
+	//
+	_, err = api.SendPlainReplyText(message.ChatId, message.Id, b.String())
+	return err
+
+}
+
+// parseStatsPeriod returns the number of days of the period passed to /stats,
+// or zero if it covers the whole history.
+func parseStatsPeriod(arguments string) (int, error) {
+
+	//
+	period := strings.ToLower(strings.TrimSpace(arguments))
+	switch period {
+	case "":
+		return defaultStatsDays, nil
+	case allTimeStats:
+		return 0, nil
+	}
+
+	if days, found := statsPeriods[period]; found {
+		return days, nil
+	}
+
+	//
+	days, err := strconv.Atoi(period)
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("invalid statistics period %s", arguments)
+	}
+
+	return days, nil
+
+}
+
+// formatMediaTypes returns the media types of the posts sent in the period
+// with their share, the most frequent first.
+func formatMediaTypes(report *statistics.Report) string {
+
+	//
+	types := make([]string, 0, len(report.MediaTypes))
+	for mediaType := range report.MediaTypes {
+		types = append(types, mediaType)
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+
+		a, b := report.MediaTypes[types[i]], report.MediaTypes[types[j]]
+		if a != b {
+			return a > b
+		}
+
+		return types[i] < types[j]
+
+	})
+
+	//
+	shares := make([]string, len(types))
+	for i, mediaType := range types {
+		shares[i] = fmt.Sprintf("%s %.0f%%", mediaType, float64(report.MediaTypes[mediaType])*100/float64(report.Posted))
+	}
+
+	return strings.Join(shares, ", ")
+
+}
@@ -3,9 +3,12 @@ package commands
 import (
 	"fmt"
 	"github.com/shitpostingio/autopostingbot/api"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
 	l "github.com/shitpostingio/autopostingbot/localization"
 	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"github.com/shitpostingio/autopostingbot/telegram"
+	"github.com/shitpostingio/autopostingbot/utility"
 	"github.com/zelenin/go-tdlib/client"
 	"time"
 )
@@ -15,13 +18,19 @@ type StatusCommandHandler struct{}
 
 // Handle handles the /status command.
 // /status returns information about the posts enqueued, the posting rate
-// and the time until the next post.
+// and the time until the next post on the channel the user is working on.
 func (StatusCommandHandler) Handle(_ string, message, _ *client.Message) error {
 
 	//
-	nextPost := posting.GetNextPostTime()
-	queueLength := dbwrapper.GetQueueLength()
-	postingRate := posting.GetPostingRate().String()
+	channelID := GetTargetChannelID(message.SenderUserId)
+	nextPost := posting.GetNextPostTime(channelID)
+	queueLength := repository.Posts.GetQueueLength(channelID, posting.GetQueueSelection(channelID))
+	if queueLength == storage.UnknownQueueLength {
+		_, err := api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_STATUS_UNABLE_TO_COUNT_POSTS))
+		return err
+	}
+
+	postingRate := posting.GetPostingRate(channelID).String()
 	minutesUntilNextPost := time.Until(nextPost).Truncate(time.Minute)
 
 	//
@@ -31,8 +40,54 @@ func (StatusCommandHandler) Handle(_ string, message, _ *client.Message) error {
 		minutesUntilNextPost,
 		nextPost.Format("15:04"))
 
+	// Let the users know who paused the posting and until when
+	pause, isPaused := posting.GetPause(channelID)
+	if isPaused && pause.Until.IsZero() {
+		text = fmt.Sprintf("%s\n\n%s",
+			fmt.Sprintf(l.GetString(l.COMMANDS_STATUS_POSTS_ENQUEUED_PAUSED), queueLength, postingRate),
+			fmt.Sprintf(l.GetString(l.COMMANDS_STATUS_PAUSED_INDEFINITELY), getPauserName(pause)))
+	} else if isPaused {
+		text = fmt.Sprintf("%s\n\n%s", text, fmt.Sprintf(l.GetString(l.COMMANDS_STATUS_PAUSED_UNTIL), getPauserName(pause), utility.FormatDate(pause.Until)))
+	}
+
+	// Let the users know which channel they're looking at
+	if len(posting.GetChannels()) > 1 {
+		channel, _ := posting.GetChannel(channelID)
+		text = fmt.Sprintf("%s\n\n%s", fmt.Sprintf(l.GetString(l.COMMANDS_STATUS_CHANNEL), channel.Name), text)
+	}
+
+	// Let the users know if posting is on hold because of the posting windows
+	windowOpening := posting.GetNextPostingWindowOpening(channelID)
+	if windowOpening.After(time.Now()) {
+		text = fmt.Sprintf("%s\n%s", text, fmt.Sprintf(l.GetString(l.COMMANDS_STATUS_OUTSIDE_POSTING_WINDOW), windowOpening.Format("15:04")))
+	}
+
+	// Pinned posts will be posted regardless of the queue
+	pinnedPosts, err := repository.Posts.GetScheduledPosts(channelID)
+	if err == nil && len(pinnedPosts) > 0 {
+
+		text = fmt.Sprintf("%s\n\n%s", text, l.GetString(l.COMMANDS_STATUS_PINNED_POSTS))
+		for _, post := range pinnedPosts {
+			entry := fmt.Sprintf(l.GetString(l.COMMANDS_STATUS_PINNED_POST_ENTRY), utility.FormatDate(*post.ScheduledAt), post.Media.Type)
+			text = fmt.Sprintf("%s\n%s", text, entry)
+		}
+
+	}
+
 	//
-	_, err := api.SendPlainText(message.ChatId, text)
+	_, err = api.SendPlainText(message.ChatId, text)
 	return err
 
 }
+
+// getPauserName returns the name of the user who paused the posting,
+// or the one of the bot if it paused the posting because the channel can't be posted on.
+func getPauserName(pause posting.PauseState) string {
+
+	if pause.PausedBy == 0 {
+		return telegram.GetNameFromUser(repository.Me)
+	}
+
+	return getUserName(pause.PausedBy)
+
+}
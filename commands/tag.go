@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"github.com/zelenin/go-tdlib/client"
+	"strings"
+)
+
+// TagCommandHandler represents the handler of the /tag command.
+type TagCommandHandler struct{}
+
+// Handle handles the /tag command.
+// /tag adds an enqueued post to the themed queues with the input tags.
+// Tagged posts are held back until a campaign drawing from one of their queues runs.
+func (TagCommandHandler) Handle(arguments string, message, replyToMessage *client.Message) error {
+
+	//
+	tags := parseTags(arguments)
+	if len(tags) == 0 {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_TAG_USAGE))
+		return errors.New("no tags")
+	}
+
+	//
+	post, err := findEnqueuedPost(message, replyToMessage)
+	if err != nil {
+		return err
+	}
+
+	//
+	err = repository.Posts.AddPostTagsByUniqueID(post.ChannelID, post.Media.FileUniqueID, tags)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_TAG_UNSUCCESSFUL))
+		return err
+	}
+
+	//
+	reply := fmt.Sprintf(l.GetString(l.COMMANDS_TAG_SUCCESSFUL), formatTags(tags))
+	_, err = api.SendPlainReplyText(message.ChatId, message.Id, reply)
+	return err
+
+}
+
+// UntagCommandHandler represents the handler of the /untag command.
+type UntagCommandHandler struct{}
+
+// Handle handles the /untag command.
+// /untag removes an enqueued post from the themed queues with the input tags.
+// By using /untag without any additional argument, one can remove it from every themed queue.
+func (UntagCommandHandler) Handle(arguments string, message, replyToMessage *client.Message) error {
+
+	//
+	post, err := findEnqueuedPost(message, replyToMessage)
+	if err != nil {
+		return err
+	}
+
+	//
+	err = repository.Posts.RemovePostTagsByUniqueID(post.ChannelID, post.Media.FileUniqueID, parseTags(arguments))
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_UNTAG_UNSUCCESSFUL))
+		return err
+	}
+
+	//
+	_, err = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_UNTAG_SUCCESSFUL))
+	return err
+
+}
+
+// findEnqueuedPost retrieves the post of the media the message replies to,
+// replying with the reason if it's not found or it has already been posted.
+func findEnqueuedPost(message, replyToMessage *client.Message) (post entities.Post, err error) {
+
+	//
+	if replyToMessage == nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_REPLY_TO_MEDIA_FILE))
+		return post, errors.New("reply to message nil")
+	}
+
+	//
+	fi, err := api.GetMediaFileInfo(replyToMessage)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_REPLY_TO_MEDIA_FILE))
+		return post, err
+	}
+
+	//
+	post, err = repository.Posts.FindPostByUniqueID(GetTargetChannelID(message.SenderUserId), fi.Remote.UniqueId)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.DATABASE_UNABLE_TO_FIND_POST))
+		return post, err
+	}
+
+	//
+	if post.PostedAt != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_TAG_ALREADY_POSTED))
+		return post, errors.New("post already posted")
+	}
+
+	return post, nil
+
+}
+
+// parseTags returns the normalized tags in the command arguments,
+// separated by spaces or commas.
+func parseTags(arguments string) (tags []string) {
+
+	fields := strings.FieldsFunc(arguments, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n'
+	})
+
+	for _, field := range fields {
+		if tag := entities.NormalizeTag(field); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return
+
+}
+
+// formatTags formats tags as hashtags.
+func formatTags(tags []string) string {
+
+	hashtags := make([]string, len(tags))
+	for i, tag := range tags {
+		hashtags[i] = "#" + tag
+	}
+
+	return strings.Join(hashtags, " ")
+
+}
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"github.com/shitpostingio/autopostingbot/api"
 	"github.com/shitpostingio/autopostingbot/caption"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
 	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/repository"
 	"github.com/zelenin/go-tdlib/client"
 	"strings"
 )
@@ -39,7 +39,7 @@ func (ThanksCommandHandler) Handle(arguments string, message, replyToMessage *cl
 	}
 
 	//
-	err = dbwrapper.UpdatePostCaptionByUniqueID(fi.Remote.UniqueId, newCaption)
+	err = repository.Posts.UpdatePostCaptionByUniqueID(GetTargetChannelID(message.SenderUserId), fi.Remote.UniqueId, newCaption, message.SenderUserId)
 	_ = PreviewCommandHandler{}.Handle("", message, replyToMessage)
 	return err
 
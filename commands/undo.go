@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"errors"
+	"github.com/shitpostingio/autopostingbot/api"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"github.com/zelenin/go-tdlib/client"
+)
+
+// UndoCommandHandler represents the handler of the /undo command.
+type UndoCommandHandler struct{}
+
+// Handle handles the /undo command.
+// /undo restores the last post removed by the user, with /delete or /failed discard,
+// as long as it hasn't been purged yet.
+func (UndoCommandHandler) Handle(_ string, message, _ *client.Message) error {
+
+	//
+	post, err := repository.Posts.RestoreLastRemovedPost(message.SenderUserId)
+	if errors.Is(err, storage.ErrNotFound) {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_UNDO_NOTHING_TO_UNDO))
+		return err
+	}
+
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_UNDO_FAILURE))
+		return err
+	}
+
+	// Show which post was restored
+	_, err = api.SendMedia(post.Media.Type, message.ChatId, message.Id, post.Media.FileID, l.GetString(l.COMMANDS_UNDO_SUCCESSFUL), nil)
+
+	// The queue may have been empty
+	if repository.Posts.GetQueueLength(post.ChannelID, posting.GetQueueSelection(post.ChannelID)) == 1 {
+		posting.ForcePostScheduling(post.ChannelID)
+	}
+
+	return err
+
+}
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/utility"
+	"github.com/zelenin/go-tdlib/client"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultUpcomingPosts = 5
+	maxUpcomingPosts     = 20
+)
+
+// UpcomingCommandHandler represents the handler of the /upcoming command.
+type UpcomingCommandHandler struct{}
+
+// Handle handles the /upcoming command.
+// /upcoming lists the next posts on the channel the user is working on,
+// with their estimated posting time. The number of posts can be passed as argument.
+func (UpcomingCommandHandler) Handle(arguments string, message, _ *client.Message) error {
+
+	//
+	limit := defaultUpcomingPosts
+	if strings.TrimSpace(arguments) != "" {
+
+		var err error
+		limit, err = strconv.Atoi(strings.TrimSpace(arguments))
+		if err != nil || limit < 1 || limit > maxUpcomingPosts {
+			_, _ = api.SendPlainReplyText(message.ChatId, message.Id, fmt.Sprintf(l.GetString(l.COMMANDS_UPCOMING_USAGE), maxUpcomingPosts))
+			return fmt.Errorf("invalid number of upcoming posts %s", arguments)
+		}
+
+	}
+
+	//
+	channelID := GetTargetChannelID(message.SenderUserId)
+	timeline, err := posting.Forecast(channelID, limit)
+	if err != nil {
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.DATABASE_UNABLE_TO_FIND_POST))
+		return err
+	}
+
+	//
+	if len(timeline) == 0 {
+		_, err = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.COMMANDS_UPCOMING_NO_POSTS))
+		return err
+	}
+
+	//
+	location := posting.GetLocation(channelID)
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf(l.GetString(l.COMMANDS_UPCOMING_HEADER), len(timeline)))
+
+	for i, slot := range timeline {
+
+		entryKey := l.COMMANDS_UPCOMING_ENTRY
+		if slot.Pinned {
+			entryKey = l.COMMANDS_UPCOMING_PINNED_ENTRY
+		}
+
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf(l.GetString(entryKey),
+			i+1, utility.FormatDate(slot.Time.In(location)), slot.Post.Media.Type, getUserName(slot.Post.AddedBy)))
+
+	}
+
+	//
+	_, err = api.SendPlainReplyText(message.ChatId, message.Id, b.String())
+	return err
+
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/config/structs"
+)
+
+// checkChannels checks that at least one channel is configured
+// and that channels can be told apart.
+func checkChannels(config structs.Config) error {
+
+	//
+	channels := config.Autoposting.GetChannels()
+	if len(channels) == 0 {
+		return errors.New("no channel configured")
+	}
+
+	//
+	ids := make(map[int64]bool, len(channels))
+	names := make(map[string]bool, len(channels))
+	for _, channel := range channels {
+
+		if channel.ChannelID == 0 {
+			return fmt.Errorf("channel %s has no channel id", channel.Name)
+		}
+
+		if channel.Edition == "" {
+			return fmt.Errorf("channel %d has no edition", channel.ChannelID)
+		}
+
+		if ids[channel.ChannelID] {
+			return fmt.Errorf("channel %d configured more than once", channel.ChannelID)
+		}
+
+		if names[channel.Name] {
+			return fmt.Errorf("channel name %s used more than once", channel.Name)
+		}
+
+		ids[channel.ChannelID] = true
+		names[channel.Name] = true
+
+	}
+
+	return nil
+
+}
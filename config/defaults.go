@@ -5,16 +5,28 @@ import "github.com/spf13/viper"
 const (
 
 	// Autoposting
-	defaultAutopostingFileSizeThreshold   = 20 << 10
-	defaultAutopostingPostAlertThreshold  = 10
-	defaultAutopostingMediaApproximation  = 0.08
-	defaultAutopostingSimilarityThreshold = 6
+	defaultAutopostingFileSizeThreshold    = 20 << 10
+	defaultAutopostingPostAlertThreshold   = 10
+	defaultAutopostingMediaApproximation   = 0.08
+	defaultAutopostingSimilarityThreshold  = 6
+	defaultAutopostingTimeZone             = "Local"
+	defaultAutopostingMaxPostingAttempts   = 3
+	defaultAutopostingRetryBackoff         = "1m"
+	defaultAutopostingMaxRetryBackoff      = "30m"
+	defaultAutopostingAlertCooldown        = "6h"
+	defaultAutopostingRemovedPostRetention = "720h"
 
 	// DocumentStore
-	defaultDocumentStoreHosts             = "localhost:27017"
-	defaultDocumentStoreAuthMechanism     = "SCRAM-SHA-1"
-	defaultDocumentStoreUseAuthentication = false
-	defaultDocumentStoreUseReplicaSet     = false
+	defaultDocumentStoreBackend             = "mongodb"
+	defaultDocumentStoreHosts               = "localhost:27017"
+	defaultDocumentStoreAuthMechanism       = "SCRAM-SHA-1"
+	defaultDocumentStoreUseAuthentication   = false
+	defaultDocumentStoreUseReplicaSet       = false
+	defaultDocumentStoreSQLitePath          = "autopostingbot.db"
+	defaultDocumentStoreConnectionTimeout   = "2m"
+	defaultDocumentStoreRetryAttempts       = 3
+	defaultDocumentStoreRetryBackoff        = "500ms"
+	defaultDocumentStoreHealthCheckInterval = "30s"
 
 	// Tdlib
 	defaultTdlibUseTestDc              = false
@@ -39,12 +51,24 @@ func setDefaultValuesForOptionalFields() {
 	viper.SetDefault("autoposting.postalertthreshold", defaultAutopostingPostAlertThreshold)
 	viper.SetDefault("autoposting.mediaapproximation", defaultAutopostingMediaApproximation)
 	viper.SetDefault("autoposting.similaritythreshold", defaultAutopostingSimilarityThreshold)
+	viper.SetDefault("autoposting.timezone", defaultAutopostingTimeZone)
+	viper.SetDefault("autoposting.maxpostingattempts", defaultAutopostingMaxPostingAttempts)
+	viper.SetDefault("autoposting.retrybackoff", defaultAutopostingRetryBackoff)
+	viper.SetDefault("autoposting.maxretrybackoff", defaultAutopostingMaxRetryBackoff)
+	viper.SetDefault("autoposting.alertcooldown", defaultAutopostingAlertCooldown)
+	viper.SetDefault("autoposting.removedpostretention", defaultAutopostingRemovedPostRetention)
 
 	// DocumentStore
+	viper.SetDefault("documentstore.backend", defaultDocumentStoreBackend)
 	viper.SetDefault("documentstore.hosts", []string{defaultDocumentStoreHosts})
 	viper.SetDefault("documentstore.useauthentication", defaultDocumentStoreUseAuthentication)
 	viper.SetDefault("documentstore.usereplicaset", defaultDocumentStoreUseReplicaSet)
 	viper.SetDefault("documentstore.authmechanism", defaultDocumentStoreAuthMechanism)
+	viper.SetDefault("documentstore.sqlitepath", defaultDocumentStoreSQLitePath)
+	viper.SetDefault("documentstore.connectiontimeout", defaultDocumentStoreConnectionTimeout)
+	viper.SetDefault("documentstore.retryattempts", defaultDocumentStoreRetryAttempts)
+	viper.SetDefault("documentstore.retrybackoff", defaultDocumentStoreRetryBackoff)
+	viper.SetDefault("documentstore.healthcheckinterval", defaultDocumentStoreHealthCheckInterval)
 
 	// Tdlib
 	viper.SetDefault("tdlib.usetestdc", defaultTdlibUseTestDc)
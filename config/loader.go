@@ -30,6 +30,12 @@ func Load(path string) (cfg structs.Config, err error) {
 		return cfg, err
 	}
 
+	//
+	err = checkChannels(cfg)
+	if err != nil {
+		return cfg, err
+	}
+
 	//
 	err = viper.WriteConfig()
 	return
@@ -1,5 +1,7 @@
 package structs
 
+import "time"
+
 // AutopostingConfiguration represents the configuration of the autoposting bot.
 type AutopostingConfiguration struct {
 
@@ -7,7 +9,8 @@ type AutopostingConfiguration struct {
 	BotToken string
 
 	// ChannelID is the id of the channel on which we will send posts.
-	ChannelID int64
+	// It is only used if no channel is specified in Channels.
+	ChannelID int64 `type:"optional"`
 
 	// FileSizeThreshold represents the maximum file size we can perform
 	// fingerprint requests on.
@@ -20,6 +23,18 @@ type AutopostingConfiguration struct {
 	// will be notified of low posts enqueued.
 	PostAlertThreshold int `type:"optional"`
 
+	// CriticalPostAlertThreshold represents the queue length at or below which
+	// the low posts alerts are escalated. By default, alerts escalate when the queue is empty.
+	CriticalPostAlertThreshold int `type:"optional"`
+
+	// AlertCooldown represents the time to wait before reminding the admins
+	// of a low number of posts enqueued, if the queue didn't drop further.
+	AlertCooldown time.Duration `type:"optional"`
+
+	// AlertChatID is the id of the staff group chat the alerts are sent to.
+	// If zero, alerts are sent privately to every authorized user.
+	AlertChatID int64 `type:"optional"`
+
 	// MediaApproximation represents the approximation the similarity search
 	// in the database will use.
 	MediaApproximation float64 `type:"optional"`
@@ -29,5 +44,84 @@ type AutopostingConfiguration struct {
 	SimilarityThreshold int `type:"optional"`
 
 	// Edition represents the edition that will be run.
-	Edition string
+	// It is only used if no channel is specified in Channels.
+	Edition string `type:"optional"`
+
+	// Channels represents the channels the bot posts on, each with its own
+	// edition and queue. The first one is the default channel.
+	Channels []ChannelConfiguration `type:"optional"`
+
+	// TimeZone is the IANA time zone name used to interpret the posting windows.
+	TimeZone string `type:"optional"`
+
+	// PostingWindows represents the daily time ranges in which posting is allowed.
+	// If no window is specified, posting will be allowed at any time.
+	PostingWindows []PostingWindowConfiguration `type:"optional"`
+
+	// MaxPostingAttempts represents the number of attempts at posting a media
+	// before marking it as failed.
+	MaxPostingAttempts int `type:"optional"`
+
+	// RetryBackoff represents the time to wait before retrying a failed post.
+	// It is doubled at every failed attempt.
+	RetryBackoff time.Duration `type:"optional"`
+
+	// MaxRetryBackoff represents the maximum time to wait before retrying a failed post.
+	MaxRetryBackoff time.Duration `type:"optional"`
+
+	// RemovedPostRetention represents how long removed posts are kept,
+	// and their removal can be undone, before being purged.
+	// If zero, removed posts are never purged.
+	RemovedPostRetention time.Duration `type:"optional"`
+
+	// RemovedPostsAreDuplicates tells whether media matching a removed post
+	// are reported as duplicates instead of being added again.
+	RemovedPostsAreDuplicates bool `type:"optional"`
+}
+
+// GetChannels returns the configuration of the channels the bot posts on.
+// If the channel list is empty, the channel described by ChannelID and Edition
+// will be used. Optional channel fields fall back on the autoposting ones.
+func (c *AutopostingConfiguration) GetChannels() []ChannelConfiguration {
+
+	//
+	channels := c.Channels
+	if len(channels) == 0 && c.ChannelID != 0 {
+		channels = []ChannelConfiguration{{ChannelID: c.ChannelID, Edition: c.Edition}}
+	}
+
+	//
+	resolved := make([]ChannelConfiguration, len(channels))
+	for i, channel := range channels {
+
+		if channel.Name == "" {
+			channel.Name = channel.Edition
+		}
+
+		if channel.PostAlertThreshold == 0 {
+			channel.PostAlertThreshold = c.PostAlertThreshold
+		}
+
+		if channel.CriticalPostAlertThreshold == 0 {
+			channel.CriticalPostAlertThreshold = c.CriticalPostAlertThreshold
+		}
+
+		if channel.AlertChatID == 0 {
+			channel.AlertChatID = c.AlertChatID
+		}
+
+		if channel.TimeZone == "" {
+			channel.TimeZone = c.TimeZone
+		}
+
+		if len(channel.PostingWindows) == 0 {
+			channel.PostingWindows = c.PostingWindows
+		}
+
+		resolved[i] = channel
+
+	}
+
+	return resolved
+
 }
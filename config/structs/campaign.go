@@ -0,0 +1,29 @@
+package structs
+
+// CampaignConfiguration represents a time-bounded campaign, during which
+// the posts are drawn from a themed queue, such as the one for holidays.
+type CampaignConfiguration struct {
+
+	// Name is the name of the campaign.
+	Name string
+
+	// Tag is the tag of the posts in the themed queue.
+	// If empty, the name will be used.
+	Tag string `type:"optional"`
+
+	// Channels contains the names of the channels the campaign runs on.
+	// If empty, the campaign runs on every channel.
+	Channels []string `type:"optional"`
+
+	// Start is the day the campaign starts, in the 2006-01-02 format,
+	// or the time it starts at, in the 2006-01-02 15:04 format.
+	Start string
+
+	// End is the last day of the campaign, in the 2006-01-02 format,
+	// or the time it ends at, in the 2006-01-02 15:04 format.
+	End string
+
+	// Exclusive is true if only the posts in the themed queue are posted
+	// during the campaign. Otherwise, they are posted before the others.
+	Exclusive bool `type:"optional"`
+}
@@ -0,0 +1,37 @@
+package structs
+
+// ChannelConfiguration represents the configuration of a channel the bot posts on.
+type ChannelConfiguration struct {
+
+	// Name is the name used to refer to the channel in the commands.
+	// If empty, the edition name will be used.
+	Name string `type:"optional"`
+
+	// ChannelID is the id of the channel on which we will send posts.
+	ChannelID int64
+
+	// Edition represents the edition that will be run on the channel.
+	Edition string
+
+	// PostAlertThreshold represents the threshold below which the admins
+	// will be notified of low posts enqueued.
+	// If zero, the autoposting one will be used.
+	PostAlertThreshold int `type:"optional"`
+
+	// CriticalPostAlertThreshold represents the queue length at or below which
+	// the low posts alerts are escalated.
+	// If zero, the autoposting one will be used.
+	CriticalPostAlertThreshold int `type:"optional"`
+
+	// AlertChatID is the id of the staff group chat the alerts are sent to.
+	// If zero, the autoposting one will be used.
+	AlertChatID int64 `type:"optional"`
+
+	// TimeZone is the IANA time zone name used to interpret the posting windows.
+	// If empty, the autoposting one will be used.
+	TimeZone string `type:"optional"`
+
+	// PostingWindows represents the daily time ranges in which posting is allowed.
+	// If no window is specified, the autoposting ones will be used.
+	PostingWindows []PostingWindowConfiguration `type:"optional"`
+}
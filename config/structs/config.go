@@ -17,4 +17,10 @@ type Config struct {
 
 	// Localization contains localization configuration values.
 	Localization LocalizationConfiguration
+
+	// Editions contains the editions defined in the configuration file.
+	Editions []EditionConfiguration `type:"optional"`
+
+	// Campaigns contains the campaigns defined in the configuration file.
+	Campaigns []CampaignConfiguration `type:"optional"`
 }
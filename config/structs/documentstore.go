@@ -1,11 +1,17 @@
 package structs
 
-import "go.mongodb.org/mongo-driver/mongo/options"
+import (
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
 
 // DocumentStoreConfiguration represents MongoDB configuration values.
 type DocumentStoreConfiguration struct {
-	UseAuthentication bool `type:"optional"`
-	UseReplicaSet     bool `type:"optional"`
+	Backend           string  `type:"optional"`
+	MemoryUsers       []int32 `type:"optional"`
+	SQLitePath        string  `type:"optional"`
+	UseAuthentication bool    `type:"optional"`
+	UseReplicaSet     bool    `type:"optional"`
 	DatabaseName      string
 	AuthMechanism     string   `type:"optional"`
 	Username          string   `type:"optional"`
@@ -13,6 +19,23 @@ type DocumentStoreConfiguration struct {
 	AuthSource        string   `type:"optional"`
 	ReplicaSetName    string   `type:"optional"`
 	Hosts             []string `type:"optional"`
+
+	// ConnectionTimeout is how long the connection to MongoDB
+	// is retried at startup before giving up.
+	ConnectionTimeout time.Duration `type:"optional"`
+
+	// RetryAttempts is the number of attempts made for
+	// the operations failing with a transient error.
+	RetryAttempts int `type:"optional"`
+
+	// RetryBackoff is the time to wait before the first retry,
+	// doubled after each one.
+	RetryBackoff time.Duration `type:"optional"`
+
+	// HealthCheckInterval is the time between two checks
+	// of the availability of the storage backend.
+	// Zero disables the checks.
+	HealthCheckInterval time.Duration `type:"optional"`
 }
 
 // MongoDBConnectionOptions gets the connection options from the DocumentStoreConfiguration.
@@ -0,0 +1,53 @@
+package structs
+
+import "time"
+
+// EditionConfiguration represents a posting strategy defined in the configuration file.
+type EditionConfiguration struct {
+
+	// Name is the name of the edition, to be used in the autoposting configuration.
+	Name string
+
+	// Signature is the text appended to the caption of every post.
+	// If empty, @Name will be used.
+	Signature string `type:"optional"`
+
+	// RateCurve maps queue lengths to posting rates.
+	RateCurve []RateBreakpointConfiguration `type:"optional"`
+
+	// MinInterval is the minimum amount of time between two posts.
+	MinInterval time.Duration `type:"optional"`
+
+	// MaxInterval is the maximum amount of time between two posts.
+	MaxInterval time.Duration `type:"optional"`
+
+	// MinJitter is the lower bound of the random amount of time
+	// added to the interval between two posts. It can be negative.
+	MinJitter time.Duration `type:"optional"`
+
+	// MaxJitter is the upper bound of the random amount of time
+	// added to the interval between two posts.
+	MaxJitter time.Duration `type:"optional"`
+
+	// QueueSelection is the policy used to pick the next post from the queue:
+	// fifo (the default) or roundrobin, interleaving the posts of the contributors.
+	QueueSelection string `type:"optional"`
+
+	// MaxConsecutive maps media types (photo, video, animation)
+	// to the maximum number of consecutive posts of that type.
+	MaxConsecutive map[string]int `type:"optional"`
+
+	// MediaMix maps media types to their target share of the recent posts.
+	// The ratios are relative to their sum.
+	MediaMix map[string]float64 `type:"optional"`
+}
+
+// RateBreakpointConfiguration represents a point of an edition rate curve.
+type RateBreakpointConfiguration struct {
+
+	// QueueLength is the minimum queue length for the breakpoint to apply.
+	QueueLength int
+
+	// PostsPerHour is the number of posts per hour while the breakpoint applies.
+	PostsPerHour float64
+}
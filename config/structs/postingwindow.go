@@ -0,0 +1,12 @@
+package structs
+
+// PostingWindowConfiguration represents a daily time range in which posting is allowed.
+type PostingWindowConfiguration struct {
+
+	// Start is the time at which the window opens, in the 15:04 format.
+	Start string
+
+	// End is the time at which the window closes, in the 15:04 format.
+	// If End comes before Start, the window spans across midnight.
+	End string
+}
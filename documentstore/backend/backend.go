@@ -0,0 +1,111 @@
+package backend
+
+import (
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	"github.com/shitpostingio/autopostingbot/documentstore"
+	"github.com/shitpostingio/autopostingbot/documentstore/memorystore"
+	"github.com/shitpostingio/autopostingbot/documentstore/sqlitestore"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	"github.com/shitpostingio/autopostingbot/repository"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// mongoDBBackend is the storage backend backed by MongoDB.
+	mongoDBBackend = "mongodb"
+
+	// memoryBackend is the in-memory storage backend, that persists nothing.
+	memoryBackend = "memory"
+
+	// sqliteBackend is the storage backend backed by an embedded SQLite database.
+	sqliteBackend = "sqlite"
+)
+
+var (
+	// sqliteStore is the open SQLite database, if the sqlite backend is in use.
+	sqliteStore *sqlitestore.Store
+)
+
+// Open opens the storage backend set in the configuration
+// and makes its stores available through the repository.
+func Open(cfg *structs.Config) {
+
+	switch cfg.DocumentStore.Backend {
+	case mongoDBBackend:
+
+		documentstore.Connect(&cfg.DocumentStore, cfg.Autoposting.MediaApproximation, cfg.Autoposting.SimilarityThreshold, cfg.Autoposting.GetChannels()[0].ChannelID)
+		repository.Posts = documentstore.NewPostStore()
+		repository.Users = documentstore.NewUserStore()
+		repository.Scheduler = documentstore.NewSchedulerStore()
+		repository.Statistics = documentstore.NewStatisticsStore()
+
+	case memoryBackend:
+
+		store := memorystore.New(cfg.Autoposting.MediaApproximation, cfg.Autoposting.SimilarityThreshold)
+		authorizeUsers(store, cfg.DocumentStore.MemoryUsers)
+		repository.Posts = store
+		repository.Users = store
+		repository.Scheduler = store
+		repository.Statistics = store
+		log.Warn("Using the memory storage backend: posts, users and the posting state will be lost on exit")
+
+	case sqliteBackend:
+
+		store, err := sqlitestore.Open(cfg.DocumentStore.SQLitePath, cfg.Autoposting.MediaApproximation, cfg.Autoposting.SimilarityThreshold)
+		if err != nil {
+			log.Fatal("Unable to open the SQLite database: ", err)
+		}
+
+		authorizeUsers(store, cfg.DocumentStore.MemoryUsers)
+		sqliteStore = store
+		repository.Posts = store
+		repository.Users = store
+		repository.Scheduler = store
+		repository.Statistics = store
+
+	default:
+		log.Fatal("Unknown storage backend ", cfg.DocumentStore.Backend)
+	}
+
+}
+
+// authorizeUsers adds the input users to the authorized admins, if they're not already.
+func authorizeUsers(store storage.UserStore, userIDs []int32) {
+
+	for _, userID := range userIDs {
+
+		if store.UserIsAuthorized(userID) {
+			continue
+		}
+
+		err := store.AddUser(userID)
+		if err != nil {
+			log.Fatal("Unable to authorize user ", userID, ": ", err)
+		}
+
+	}
+
+}
+
+// Ping checks that the storage backend can be reached.
+// The memory backend is always available.
+func Ping() error {
+
+	if sqliteStore != nil {
+		return sqliteStore.Ping()
+	}
+
+	return documentstore.Ping()
+
+}
+
+// Close closes the storage backend.
+func Close() error {
+
+	if sqliteStore != nil {
+		return sqliteStore.Close()
+	}
+
+	return documentstore.Disconnect()
+
+}
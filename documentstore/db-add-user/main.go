@@ -37,7 +37,7 @@ func main() {
 	repository.Config = &cfg
 
 	// Connect to the database
-	documentstore.Connect(&cfg.DocumentStore, cfg.Autoposting.MediaApproximation, cfg.Autoposting.SimilarityThreshold)
+	documentstore.Connect(&cfg.DocumentStore, cfg.Autoposting.MediaApproximation, cfg.Autoposting.SimilarityThreshold, cfg.Autoposting.GetChannels()[0].ChannelID)
 
 	//
 	err = documentstore.AddUser(int32(userID), documentstore.UserCollection)
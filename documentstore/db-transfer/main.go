@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"github.com/shitpostingio/autopostingbot/config"
+	"github.com/shitpostingio/autopostingbot/documentstore/backend"
+	"github.com/shitpostingio/autopostingbot/documentstore/transfer"
+	"github.com/shitpostingio/autopostingbot/repository"
+	log "github.com/sirupsen/logrus"
+	"os"
+)
+
+var (
+	// config file path, if not specified it will read ./config.toml
+	configFilePath string
+
+	// export file path, if not specified it will use stdout or stdin
+	filePath string
+
+	// channel whose posts are exported, if zero all posts are exported
+	channelID int64
+
+	// channel the imported posts are moved to, if zero they keep their channel
+	targetChannelID int64
+
+	// whether imported posts replace the ones with the same media
+	replace bool
+)
+
+func main() {
+
+	// Load parameters from CLI
+	flag.StringVar(&configFilePath, "config", "./config.toml", "configuration file path")
+	flag.StringVar(&filePath, "file", "", "NDJSON file to export to or import from, stdout or stdin if empty")
+	flag.Int64Var(&channelID, "channel", 0, "export only the posts of this channel")
+	flag.Int64Var(&targetChannelID, "targetchannel", 0, "move the imported posts to this channel")
+	flag.BoolVar(&replace, "replace", false, "replace the posts with the same media instead of skipping them")
+	flag.Usage = func() {
+		log.Println("Usage: db-transfer [flags] export|import")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	//
+	if flag.NArg() != 1 || (flag.Arg(0) != "export" && flag.Arg(0) != "import") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load configuration file
+	cfg, err := config.Load(configFilePath)
+	if err != nil {
+		log.Fatal("Error while loading configuration: ", err)
+	}
+
+	repository.Config = &cfg
+
+	// Open the database
+	backend.Open(&cfg)
+
+	//
+	var summary transfer.Summary
+	if flag.Arg(0) == "export" {
+		summary, err = export()
+	} else {
+		summary, err = importFile()
+	}
+
+	closeErr := backend.Close()
+	if err != nil {
+		log.Fatal("Unable to ", flag.Arg(0), ": ", err)
+	}
+
+	if closeErr != nil {
+		log.Error("Unable to close the database: ", closeErr)
+	}
+
+	log.Printf("%s completed: %d posts, %d users, %d posts and %d users skipped",
+		flag.Arg(0), summary.Posts, summary.Users, summary.SkippedPosts, summary.SkippedUsers)
+
+}
+
+// export writes the posts and the users to the export file.
+func export() (transfer.Summary, error) {
+
+	//
+	out := os.Stdout
+	if filePath != "" {
+
+		file, err := os.Create(filePath)
+		if err != nil {
+			return transfer.Summary{}, err
+		}
+
+		defer file.Close()
+		out = file
+
+	}
+
+	//
+	summary, err := transfer.Export(out, repository.Posts, repository.Users, channelID)
+	if err != nil || filePath == "" {
+		return summary, err
+	}
+
+	return summary, out.Sync()
+
+}
+
+// importFile reads the posts and the users from the export file.
+func importFile() (transfer.Summary, error) {
+
+	//
+	in := os.Stdin
+	if filePath != "" {
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return transfer.Summary{}, err
+		}
+
+		defer file.Close()
+		in = file
+
+	}
+
+	//
+	return transfer.Import(in, repository.Posts, repository.Users, transfer.ImportOptions{
+		Replace:   replace,
+		ChannelID: targetChannelID,
+	})
+
+}
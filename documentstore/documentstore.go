@@ -13,18 +13,23 @@ import (
 const (
 
 	//
-	opDeadline = 10 * time.Second
+	opDeadline   = 10 * time.Second
+	pingDeadline = 5 * time.Second
 
 	//
-	postCollectionName = "posts"
-	userCollectionName = "users"
+	postCollectionName        = "posts"
+	userCollectionName        = "users"
+	schedulerCollectionName   = "scheduler"
+	duplicateCollectionName   = "duplicates"
+	reservationCollectionName = "reservations"
 )
 
 var (
 
 	//
-	dsCtx    context.Context
-	database *mongo.Database
+	dsCtx       context.Context
+	mongoClient *mongo.Client
+	database    *mongo.Database
 
 	// PostCollection is the MongoDB post collection.
 	PostCollection *mongo.Collection
@@ -32,6 +37,15 @@ var (
 	// UserCollection is the MongoDB user collection.
 	UserCollection *mongo.Collection
 
+	// SchedulerCollection is the MongoDB scheduler state collection.
+	SchedulerCollection *mongo.Collection
+
+	// DuplicateCollection is the MongoDB collection of the duplicate submissions.
+	DuplicateCollection *mongo.Collection
+
+	// ReservationCollection is the MongoDB collection of the reserved media features.
+	ReservationCollection *mongo.Collection
+
 	// MediaApproximation is the approximation factor for similarity search in the database.
 	MediaApproximation float64
 
@@ -39,12 +53,17 @@ var (
 	SimilarityThreshold int
 )
 
-// Connect connects to the document store.
-func Connect(cfg *structs.DocumentStoreConfiguration, mediaApproximation float64, similarityThreshold int) {
+// Connect connects to the document store and applies the migrations it's missing.
+// The connection is retried with an exponential backoff until the connection timeout
+// in the configuration expires. Documents created before the support for multiple channels
+// will be assigned to the default channel.
+func Connect(cfg *structs.DocumentStoreConfiguration, mediaApproximation float64, similarityThreshold int, defaultChannelID int64) {
 
 	//
 	MediaApproximation = mediaApproximation
 	SimilarityThreshold = similarityThreshold
+	retryAttempts = cfg.RetryAttempts
+	retryBackoff = cfg.RetryBackoff
 
 	//
 	client, err := mongo.Connect(context.Background(), cfg.MongoDBConnectionOptions())
@@ -52,11 +71,24 @@ func Connect(cfg *structs.DocumentStoreConfiguration, mediaApproximation float64
 		log.Fatal("Unable to connect to document store:", err)
 	}
 
-	pingCtx, cancelPingCtx := context.WithTimeout(context.Background(), 1*time.Second)
-	defer cancelPingCtx()
-	err = client.Ping(pingCtx, readpref.Primary())
-	if err != nil {
-		log.Fatal("Unable to ping document store:", err)
+	//
+	mongoClient = client
+	deadline := time.Now().Add(cfg.ConnectionTimeout)
+	for attempt := 1; ; attempt++ {
+
+		err = Ping()
+		if err == nil {
+			break
+		}
+
+		backoff := connectionBackoff(attempt)
+		if time.Now().Add(backoff).After(deadline) {
+			log.Fatal("Unable to ping document store:", err)
+		}
+
+		log.Println("Unable to ping document store, retrying in", backoff, ":", err)
+		time.Sleep(backoff)
+
 	}
 
 	//
@@ -66,5 +98,47 @@ func Connect(cfg *structs.DocumentStoreConfiguration, mediaApproximation float64
 	database = client.Database(cfg.DatabaseName)
 	PostCollection = database.Collection(postCollectionName)
 	UserCollection = database.Collection(userCollectionName)
+	SchedulerCollection = database.Collection(schedulerCollectionName)
+	DuplicateCollection = database.Collection(duplicateCollectionName)
+	ReservationCollection = database.Collection(reservationCollectionName)
+
+	//
+	err = migrate(database, defaultChannelID)
+	if err != nil {
+		log.Fatal("Unable to migrate the document store: ", err)
+	}
+
+}
+
+// Ping checks that the document store can be reached.
+func Ping() error {
+
+	//
+	if mongoClient == nil {
+		return nil
+	}
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), pingDeadline)
+	defer cancelCtx()
+
+	return mongoClient.Ping(ctx, readpref.Primary())
+
+}
+
+// Disconnect disconnects from the document store,
+// waiting for the in-flight operations to complete.
+func Disconnect() error {
+
+	//
+	if mongoClient == nil {
+		return nil
+	}
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	return mongoClient.Disconnect(ctx)
 
 }
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Duplicate represents the submission of a media similar to a stored one,
+// that was therefore not added.
+type Duplicate struct {
+
+	// ID is MongoDB's object ID.
+	ID primitive.ObjectID `bson:"_id,omitempty"`
+
+	// SubmittedBy is the Telegram user ID of the person that submitted the media.
+	SubmittedBy int32
+
+	// ChannelID is the id of the channel the media would have been sent to.
+	ChannelID int64
+
+	// MatchedPostID is the ID of the stored post the media matched.
+	MatchedPostID primitive.ObjectID
+
+	// SubmittedAt is the timestamp of the submission.
+	SubmittedAt time.Time
+}
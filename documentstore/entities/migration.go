@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Migration represents a schema migration applied to the document store.
+type Migration struct {
+
+	// ID is MongoDB's object ID.
+	ID primitive.ObjectID `bson:"_id,omitempty"`
+
+	// Version is the version the document store was migrated to.
+	Version int
+
+	// Description describes what the migration did.
+	Description string
+
+	// AppliedAt is the timestamp of the migration.
+	AppliedAt time.Time
+}
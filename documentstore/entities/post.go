@@ -2,9 +2,25 @@ package entities
 
 import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
 	"time"
 )
 
+const (
+
+	// PriorityLow is the priority of posts that can wait for the others.
+	PriorityLow = -1
+
+	// PriorityNormal is the default priority of posts.
+	PriorityNormal = 0
+
+	// PriorityHigh is the priority of posts that jump the queue.
+	PriorityHigh = 1
+
+	// MaxCaptionRevisions is the number of previous captions kept for each post.
+	MaxCaptionRevisions = 20
+)
+
 // Post represents a post in the document store.
 type Post struct {
 
@@ -14,6 +30,9 @@ type Post struct {
 	// AddedBy is the Telegram user ID of the person that added the post.
 	AddedBy int32
 
+	// ChannelID is the id of the channel the post will be sent to.
+	ChannelID int64
+
 	// Media contains information on the media added.
 	Media Media
 
@@ -22,16 +41,40 @@ type Post struct {
 	// be processed correctly.
 	Caption string
 
-	// HasError becomes true if there was an error while trying to
-	// post the media.
+	// CaptionHistory contains the previous captions of the post, oldest first.
+	CaptionHistory []CaptionRevision `bson:",omitempty"`
+
+	// Priority represents the priority of the post in the queue.
+	// Posts with higher priority are posted first.
+	Priority int
+
+	// Tags are the names of the themed queues the post belongs to.
+	// Tagged posts are held back until a campaign drawing from one of their queues runs.
+	Tags []string `bson:",omitempty"`
+
+	// HasError becomes true if the media could not be posted
+	// and no more attempts will be made.
 	HasError bool `bson:",omitempty"`
 
+	// FailedAttempts is the number of failed attempts at posting the media.
+	FailedAttempts int `bson:",omitempty"`
+
+	// LastError is the error returned by the last failed posting attempt.
+	LastError string `bson:",omitempty"`
+
+	// LastAttemptAt is the timestamp of the last failed posting attempt.
+	LastAttemptAt *time.Time `bson:",omitempty"`
+
 	// AddedAt is the timestamp of the addition to the database.
 	AddedAt time.Time
 
 	// UpdatedAT is the timestamp of the last modification.
 	UpdatedAt *time.Time `bson:",omitempty"`
 
+	// ScheduledAt is the time the post has been scheduled for.
+	// Scheduled posts are not part of the queue.
+	ScheduledAt *time.Time `bson:",omitempty"`
+
 	// PostedAt is the timestamp of the post on the channel.
 	PostedAt *time.Time `bson:",omitempty"`
 
@@ -41,4 +84,45 @@ type Post struct {
 
 	// DeletedAt is the timestamp of the deletion from the channel.
 	DeletedAt *time.Time `bson:",omitempty"`
+
+	// RemovedAt is the timestamp of the removal of the post by an admin.
+	// Removed posts are not part of the queue and are purged after a while,
+	// but their features can still be matched until then.
+	RemovedAt *time.Time `bson:",omitempty"`
+
+	// RemovedBy is the Telegram user ID of the person that removed the post.
+	RemovedBy int32 `bson:",omitempty"`
+}
+
+// CaptionRevision represents a previous caption of a post.
+type CaptionRevision struct {
+
+	// Caption is the caption before the edit.
+	Caption string
+
+	// EditedBy is the Telegram user ID of the person that replaced it.
+	// It is zero if the caption was edited on the channel.
+	EditedBy int32
+
+	// EditedAt is the timestamp of the edit.
+	EditedAt time.Time
+}
+
+// HasTag returns true if the post belongs to the themed queue with the input tag.
+func (p *Post) HasTag(tag string) bool {
+
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// NormalizeTag returns the tag in the form it's stored in:
+// lowercase and without the leading hash sign.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
 }
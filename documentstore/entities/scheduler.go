@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// SchedulerState represents the state of the posting scheduler of a channel in the document store.
+type SchedulerState struct {
+
+	// ID is MongoDB's object ID.
+	ID primitive.ObjectID `bson:"_id,omitempty"`
+
+	// ChannelID is the id of the channel the scheduler posts on.
+	ChannelID int64
+
+	// NextPostScheduled is the time at which the next post is scheduled.
+	NextPostScheduled time.Time
+
+	// PreviousPostTime is the time of the last post.
+	PreviousPostTime time.Time
+
+	// PreviousPauseTime is the time of the last pause request.
+	PreviousPauseTime time.Time
+
+	// PostingRate is the last posting rate computed by the edition.
+	PostingRate time.Duration
+
+	// PausedUntil is the time at which an active pause ends.
+	PausedUntil time.Time
+
+	// PausedIndefinitely is true if posting is paused until resumed.
+	PausedIndefinitely bool
+
+	// PausedBy is the id of the user who requested the last pause.
+	PausedBy int32
+
+	// AlertLevel is the level of the last low posts alert.
+	AlertLevel int
+
+	// AlertedAt is the time of the last low posts alert.
+	AlertedAt time.Time
+
+	// AlertedQueueLength is the queue length reported by the last low posts alert.
+	AlertedQueueLength int
+
+	// UpdatedAt is the timestamp of the last save.
+	UpdatedAt time.Time
+}
@@ -14,6 +14,10 @@ type User struct {
 	// TelegramID is the user's telegram ID.
 	TelegramID int32
 
+	// TargetChannelID is the id of the channel the media added by the user
+	// will be posted on. If zero, the default channel will be used.
+	TargetChannelID int64 `bson:",omitempty"`
+
 	// CreatedAt is the timestamp of the addition of the user.
 	CreatedAt time.Time
 }
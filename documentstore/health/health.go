@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+const (
+
+	// failuresBeforeUnavailable is the number of consecutive failed checks
+	// after which the storage backend is considered unavailable.
+	failuresBeforeUnavailable = 2
+)
+
+var (
+
+	//
+	mutex     sync.Mutex
+	available = true
+	failures  int
+)
+
+// Available returns false while the storage backend can't be reached.
+func Available() bool {
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	return available
+
+}
+
+// Run checks the availability of the storage backend every interval,
+// until the context is cancelled. notify is called every time
+// the storage backend becomes unavailable or available again.
+func Run(ctx context.Context, ping func() error, interval time.Duration, notify func(available bool)) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if Check(ping) {
+			notify(Available())
+		}
+
+	}
+
+}
+
+// Check pings the storage backend and updates its availability,
+// returning true if it changed. A single failed ping is tolerated,
+// while one successful ping is enough to make it available again.
+func Check(ping func() error) (changed bool) {
+
+	err := ping()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	//
+	if err == nil {
+
+		failures = 0
+		if !available {
+			available = true
+			log.Info("The storage backend is available again")
+			return true
+		}
+
+		return false
+
+	}
+
+	//
+	failures++
+	log.Warn("Unable to reach the storage backend: ", err)
+	if available && failures >= failuresBeforeUnavailable {
+		available = false
+		log.Error("The storage backend is unavailable after ", failures, " failed checks")
+		return true
+	}
+
+	return false
+
+}
@@ -0,0 +1,42 @@
+package health
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+
+	errUnreachable := errors.New("server selection error")
+	fail := func() error { return errUnreachable }
+	succeed := func() error { return nil }
+
+	tests := []struct {
+		name      string
+		ping      func() error
+		changed   bool
+		available bool
+	}{
+		{"healthy", succeed, false, true},
+		{"first failure is tolerated", fail, false, true},
+		{"second failure", fail, true, false},
+		{"still unreachable", fail, false, false},
+		{"recovery", succeed, true, true},
+		{"failure after recovery is tolerated", fail, false, true},
+		{"success resets the failures", succeed, false, true},
+		{"single failure again", fail, false, true},
+	}
+
+	for _, test := range tests {
+
+		if changed := Check(test.ping); changed != test.changed {
+			t.Errorf("%s: changed: got %t, want %t", test.name, changed, test.changed)
+		}
+
+		if Available() != test.available {
+			t.Errorf("%s: available: got %t, want %t", test.name, Available(), test.available)
+		}
+
+	}
+
+}
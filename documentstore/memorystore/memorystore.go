@@ -0,0 +1,47 @@
+package memorystore
+
+import (
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"sync"
+	"time"
+)
+
+// Store is an in-memory store of posts, users, scheduler states and statistics.
+// It allows running and testing the bot without a document store,
+// but nothing is persisted across restarts.
+type Store struct {
+	mutex      sync.RWMutex
+	posts      []*entities.Post
+	users      []*entities.User
+	states     map[int64]entities.SchedulerState
+	duplicates []entities.Duplicate
+
+	//
+	reservationMutex sync.Mutex
+	reservations     map[reservation]bool
+
+	//
+	mediaApproximation  float64
+	similarityThreshold int
+
+	//
+	now func() time.Time
+}
+
+// reservation identifies a reserved feature bucket of a channel.
+type reservation struct {
+	channelID int64
+	bucket    int64
+}
+
+// New creates an empty Store. mediaApproximation and similarityThreshold
+// are used by the similarity search, as in the document store.
+func New(mediaApproximation float64, similarityThreshold int) *Store {
+	return &Store{
+		states:              make(map[int64]entities.SchedulerState),
+		reservations:        make(map[reservation]bool),
+		mediaApproximation:  mediaApproximation,
+		similarityThreshold: similarityThreshold,
+		now:                 time.Now,
+	}
+}
@@ -0,0 +1,25 @@
+package memorystore
+
+import (
+	"github.com/shitpostingio/autopostingbot/documentstore/storage/storagetest"
+	"testing"
+	"time"
+)
+
+// newTestStore creates a Store whose clock advances by a minute at every reading.
+func newTestStore(*testing.T) storagetest.Store {
+
+	s := New(storagetest.MediaApproximation, storagetest.SimilarityThreshold)
+	now := time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+
+	return s
+
+}
+
+func TestStore(t *testing.T) {
+	storagetest.Run(t, newTestStore)
+}
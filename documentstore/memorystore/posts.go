@@ -0,0 +1,703 @@
+package memorystore
+
+import (
+	"errors"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	fpcompare "github.com/shitpostingio/image-fingerprinting/comparer"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"sort"
+	"time"
+)
+
+// AddPost adds a post to the queue of a channel.
+// As in the document store, a media can be added only once to a channel, storage.ErrDuplicate
+// being returned otherwise, and a removed post with the same media on the channel is replaced.
+func (s *Store) AddPost(addedBy int32, channelID int64, media entities.Media, caption string) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.deleteWhere(func(post *entities.Post) bool {
+		return post.ChannelID == channelID && post.Media.FileUniqueID == media.FileUniqueID && post.RemovedAt != nil
+	})
+
+	if s.findByUniqueID(channelID, media.FileUniqueID) != nil {
+		return storage.ErrDuplicate
+	}
+
+	s.posts = append(s.posts, &entities.Post{
+		ID:        primitive.NewObjectID(),
+		AddedBy:   addedBy,
+		ChannelID: channelID,
+		Media:     media,
+		Caption:   caption,
+		AddedAt:   s.now(),
+	})
+
+	return nil
+
+}
+
+// UpdatePostCaptionByUniqueID updates the caption of a post given its channel and uniqueID,
+// recording the previous one and the user who edited it in the caption history.
+func (s *Store) UpdatePostCaptionByUniqueID(channelID int64, uniqueID, caption string, editedBy int32) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	post := s.findByUniqueID(channelID, uniqueID)
+	if post == nil || post.Caption == caption {
+		return nil
+	}
+
+	now := s.now()
+	post.CaptionHistory = appendRevision(post.CaptionHistory, entities.CaptionRevision{
+		Caption:  post.Caption,
+		EditedBy: editedBy,
+		EditedAt: now,
+	})
+
+	post.Caption = caption
+	post.UpdatedAt = &now
+	return nil
+
+}
+
+// AddPostTagsByUniqueID adds a post to the themed queues with the input tags, given its channel and uniqueID.
+func (s *Store) AddPostTagsByUniqueID(channelID int64, uniqueID string, tags []string) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	post := s.findByUniqueID(channelID, uniqueID)
+	if post == nil {
+		return nil
+	}
+
+	// The slice is replaced, as it may be shared with copies of the post
+	updated := append([]string(nil), post.Tags...)
+	for _, tag := range tags {
+		if !post.HasTag(tag) {
+			updated = append(updated, tag)
+		}
+	}
+
+	post.Tags = updated
+	return nil
+
+}
+
+// RemovePostTagsByUniqueID removes a post from the themed queues with the input tags,
+// given its channel and uniqueID. If no tag is passed, the post is removed from every themed queue.
+func (s *Store) RemovePostTagsByUniqueID(channelID int64, uniqueID string, tags []string) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	post := s.findByUniqueID(channelID, uniqueID)
+	if post == nil {
+		return nil
+	}
+
+	//
+	removed := entities.Post{Tags: tags}
+	var updated []string
+	for _, tag := range post.Tags {
+		if len(tags) > 0 && !removed.HasTag(tag) {
+			updated = append(updated, tag)
+		}
+	}
+
+	post.Tags = updated
+	return nil
+
+}
+
+// ReserveFeatures reserves the features of a media on a channel until release is called,
+// waiting up to storage.ReservationTimeout for the other reservations of similar media.
+func (s *Store) ReserveFeatures(channelID int64, histogram []float64) (release func(), err error) {
+
+	//
+	reserve := func(bucket int64) error {
+
+		s.reservationMutex.Lock()
+		defer s.reservationMutex.Unlock()
+
+		key := reservation{channelID: channelID, bucket: bucket}
+		if s.reservations[key] {
+			return storage.ErrReserved
+		}
+
+		s.reservations[key] = true
+		return nil
+
+	}
+
+	//
+	unreserve := func(bucket int64) {
+		s.reservationMutex.Lock()
+		delete(s.reservations, reservation{channelID: channelID, bucket: bucket})
+		s.reservationMutex.Unlock()
+	}
+
+	return storage.Reserve(storage.FeatureBuckets(histogram), reserve, unreserve)
+
+}
+
+// FindPostByFeatures finds a post of a channel similar to the media with the input features,
+// with the same approximations as the document store.
+// Removed posts are matched only if no other post is similar.
+func (s *Store) FindPostByFeatures(channelID int64, histogram []float64, pHash string) (entities.Post, error) {
+
+	//
+	if histogram == nil {
+		return entities.Post{}, errors.New("FindPostByFeatures: histogram was nil")
+	}
+
+	//
+	if pHash == "" {
+		return entities.Post{}, errors.New("FindPostByFeatures: pHash was empty")
+	}
+
+	//
+	average, sum := entities.GetHistogramAverageAndSum(histogram)
+	minAvg := math.Trunc(average - 1)
+	maxAvg := math.Ceil(average + 1)
+	minSum := math.Trunc(sum - (sum * s.mediaApproximation))
+	maxSum := math.Ceil(sum + (sum * s.mediaApproximation))
+
+	//
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var removed *entities.Post
+	for _, post := range s.posts {
+
+		media := post.Media
+		if post.ChannelID != channelID || media.HistogramAverage < minAvg || media.HistogramAverage > maxAvg ||
+			media.HistogramSum < minSum || media.HistogramSum > maxSum {
+			continue
+		}
+
+		if fpcompare.PhotoSimilarity(pHash, media.PHash) >= s.similarityThreshold {
+			continue
+		}
+
+		// Keep looking for a post that wasn't removed
+		if post.RemovedAt != nil {
+			if removed == nil {
+				removed = post
+			}
+
+			continue
+		}
+
+		return *post, nil
+
+	}
+
+	//
+	if removed != nil {
+		return *removed, nil
+	}
+
+	return entities.Post{}, errors.New("FindPostByFeatures: no match found")
+
+}
+
+// FindPostByUniqueID retrieves a post via its channel and uniqueID, unless it was removed.
+func (s *Store) FindPostByUniqueID(channelID int64, uniqueID string) (entities.Post, error) {
+
+	//
+	if uniqueID == "" {
+		return entities.Post{}, errors.New("uniqueID empty")
+	}
+
+	//
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	post := s.findByUniqueID(channelID, uniqueID)
+	if post == nil {
+		return entities.Post{}, storage.ErrNotFound
+	}
+
+	return *post, nil
+
+}
+
+// RemovePostByUniqueID removes a post via its channel and uniqueID, keeping it until it's purged
+// so that the removal can be undone.
+func (s *Store) RemovePostByUniqueID(channelID int64, uniqueID string, removedBy int32) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if post := s.findByUniqueID(channelID, uniqueID); post != nil {
+		now := s.now()
+		post.RemovedAt = &now
+		post.RemovedBy = removedBy
+	}
+
+	return nil
+
+}
+
+// RestoreLastRemovedPost restores the post removed most recently by a user.
+// storage.ErrNotFound is returned if there's none.
+func (s *Store) RestoreLastRemovedPost(removedBy int32) (entities.Post, error) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	//
+	var last *entities.Post
+	for _, post := range s.posts {
+		if post.RemovedAt != nil && post.RemovedBy == removedBy && (last == nil || post.RemovedAt.After(*last.RemovedAt)) {
+			last = post
+		}
+	}
+
+	if last == nil {
+		return entities.Post{}, storage.ErrNotFound
+	}
+
+	//
+	last.RemovedAt = nil
+	last.RemovedBy = 0
+	return *last, nil
+
+}
+
+// PurgeRemovedPosts deletes the posts removed before the input time,
+// returning how many were deleted.
+func (s *Store) PurgeRemovedPosts(removedBefore time.Time) (int64, error) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	purged := s.deleteWhere(func(post *entities.Post) bool {
+		return post.RemovedAt != nil && post.RemovedAt.Before(removedBefore)
+	})
+
+	return int64(purged), nil
+
+}
+
+// GetNextPost retrieves the next post in the queue of a channel,
+// according to the input selection policy.
+func (s *Store) GetNextPost(channelID int64, policy selection.Policy) (entities.Post, error) {
+
+	queue, _ := s.GetQueue(channelID, policy)
+	if len(queue) == 0 {
+		return entities.Post{}, storage.ErrNotFound
+	}
+
+	return queue[0], nil
+
+}
+
+// GetQueue retrieves the queue of a channel,
+// in the order given by the input selection policy.
+func (s *Store) GetQueue(channelID int64, policy selection.Policy) ([]entities.Post, error) {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return selection.Order(s.queue(channelID, policy), s.history(channelID), policy), nil
+
+}
+
+// GetQueueLength returns the number of the posts enqueued on a channel
+// the input selection policy admits.
+func (s *Store) GetQueueLength(channelID int64, policy selection.Policy) int64 {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return int64(len(s.queue(channelID, policy)))
+
+}
+
+// GetQueuePosition returns the position of a post in the queue of its channel,
+// according to the input selection policy.
+func (s *Store) GetQueuePosition(post *entities.Post, policy selection.Policy) int {
+
+	queue, _ := s.GetQueue(post.ChannelID, policy)
+	for i, enqueued := range queue {
+		if enqueued.ID == post.ID {
+			return i + 1
+		}
+	}
+
+	return -1
+
+}
+
+// SchedulePostByUniqueID schedules a post for a specific time given its channel and uniqueID,
+// removing it from the queue. A nil scheduledAt puts the post back in the queue.
+func (s *Store) SchedulePostByUniqueID(channelID int64, uniqueID string, scheduledAt *time.Time) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	post := s.findByUniqueID(channelID, uniqueID)
+	if post == nil {
+		return nil
+	}
+
+	post.ScheduledAt = nil
+	if scheduledAt != nil {
+		scheduledTime := *scheduledAt
+		post.ScheduledAt = &scheduledTime
+	}
+
+	return nil
+
+}
+
+// GetScheduledPosts retrieves the posts scheduled for a specific time on a channel
+// that have yet to be posted, the closest first.
+func (s *Store) GetScheduledPosts(channelID int64) ([]entities.Post, error) {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	//
+	var posts []entities.Post
+	for _, post := range s.posts {
+		if post.ChannelID == channelID && post.PostedAt == nil && !post.HasError && post.ScheduledAt != nil && post.RemovedAt == nil {
+			posts = append(posts, *post)
+		}
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].ScheduledAt.Before(*posts[j].ScheduledAt)
+	})
+
+	return posts, nil
+
+}
+
+// UpdatePostPriorityByUniqueID updates the priority of a post given its channel and uniqueID.
+func (s *Store) UpdatePostPriorityByUniqueID(channelID int64, uniqueID string, priority int) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if post := s.findByUniqueID(channelID, uniqueID); post != nil {
+		post.Priority = priority
+	}
+
+	return nil
+
+}
+
+// MarkPostAsPosted marks a post as posted.
+func (s *Store) MarkPostAsPosted(post *entities.Post, messageID int) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if stored := s.findByID(post.ID); stored != nil {
+		now := s.now()
+		stored.MessageID = int64(messageID)
+		stored.PostedAt = &now
+	}
+
+	return nil
+
+}
+
+// RecordFailedAttempt records a failed posting attempt.
+// If permanent is true, the post will be marked as failed and removed from the queue.
+func (s *Store) RecordFailedAttempt(post *entities.Post, lastError string, permanent bool) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := s.findByID(post.ID)
+	if stored == nil {
+		return nil
+	}
+
+	now := s.now()
+	stored.LastError = lastError
+	stored.LastAttemptAt = &now
+	stored.FailedAttempts++
+	if permanent {
+		stored.HasError = true
+	}
+
+	return nil
+
+}
+
+// GetFailedPosts retrieves the posts of a channel that could not be posted, oldest first.
+func (s *Store) GetFailedPosts(channelID int64) ([]entities.Post, error) {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	//
+	var posts []entities.Post
+	for _, post := range s.posts {
+		if post.ChannelID == channelID && post.PostedAt == nil && post.HasError && post.RemovedAt == nil {
+			posts = append(posts, *post)
+		}
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].AddedAt.Before(posts[j].AddedAt)
+	})
+
+	return posts, nil
+
+}
+
+// RequeueFailedPostByUniqueID puts a failed post back in the queue,
+// resetting its failed attempts.
+func (s *Store) RequeueFailedPostByUniqueID(channelID int64, uniqueID string) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	post := s.findByUniqueID(channelID, uniqueID)
+	if post == nil || !post.HasError {
+		return storage.ErrNotFound
+	}
+
+	post.HasError = false
+	post.FailedAttempts = 0
+	post.LastError = ""
+	post.LastAttemptAt = nil
+	return nil
+
+}
+
+// MarkPostAsDeletedByMessageID marks a post sent on a channel as deleted.
+func (s *Store) MarkPostAsDeletedByMessageID(channelID, messageID int64) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, post := range s.posts {
+		if post.ChannelID == channelID && post.MessageID == messageID {
+			now := s.now()
+			post.DeletedAt = &now
+			break
+		}
+	}
+
+	return nil
+
+}
+
+// ExportPosts calls export with every stored post, removed ones included, oldest first.
+// Exporting stops at the first error returned by export.
+func (s *Store) ExportPosts(export func(post entities.Post) error) error {
+
+	//
+	s.mutex.RLock()
+	posts := make([]entities.Post, 0, len(s.posts))
+	for _, post := range s.posts {
+		posts = append(posts, *post)
+	}
+
+	s.mutex.RUnlock()
+
+	//
+	for _, post := range posts {
+		err := export(post)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+// ImportPost stores a post as it was exported. If a post with the same media exists on its channel,
+// it is replaced if replace is true, otherwise the post is not imported.
+// It returns true if the post was stored.
+func (s *Store) ImportPost(post entities.Post, replace bool) (bool, error) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Removed posts conflict too, as they hold the media until they're purged
+	sameMedia := func(stored *entities.Post) bool {
+		return stored.ChannelID == post.ChannelID && stored.Media.FileUniqueID == post.Media.FileUniqueID
+	}
+
+	for _, stored := range s.posts {
+		if sameMedia(stored) && !replace {
+			return false, nil
+		}
+	}
+
+	//
+	if post.ID.IsZero() {
+		post.ID = primitive.NewObjectID()
+	}
+
+	s.deleteWhere(sameMedia)
+	s.posts = append(s.posts, &post)
+	return true, nil
+
+}
+
+// ============================================================================
+
+// appendRevision returns the caption history with the input revision,
+// keeping at most entities.MaxCaptionRevisions of them.
+// The slice is replaced, as it may be shared with copies of the post.
+func appendRevision(history []entities.CaptionRevision, revision entities.CaptionRevision) []entities.CaptionRevision {
+
+	updated := append(append([]entities.CaptionRevision(nil), history...), revision)
+	if len(updated) > entities.MaxCaptionRevisions {
+		updated = updated[len(updated)-entities.MaxCaptionRevisions:]
+	}
+
+	return updated
+
+}
+
+// findByUniqueID returns the stored post of a channel with the input uniqueID, if any,
+// unless it was removed. The caller must hold the mutex.
+func (s *Store) findByUniqueID(channelID int64, uniqueID string) *entities.Post {
+
+	for _, post := range s.posts {
+		if post.ChannelID == channelID && post.Media.FileUniqueID == uniqueID && post.RemovedAt == nil {
+			return post
+		}
+	}
+
+	return nil
+
+}
+
+// deleteWhere deletes the stored posts matching the input condition,
+// returning how many were deleted. The caller must hold the mutex.
+func (s *Store) deleteWhere(matches func(post *entities.Post) bool) int {
+
+	kept := s.posts[:0]
+	for _, post := range s.posts {
+		if !matches(post) {
+			kept = append(kept, post)
+		}
+	}
+
+	deleted := len(s.posts) - len(kept)
+	s.posts = kept
+	return deleted
+
+}
+
+// findByID returns the stored post with the input ID, if any.
+// The caller must hold the mutex.
+func (s *Store) findByID(id primitive.ObjectID) *entities.Post {
+
+	for _, post := range s.posts {
+		if post.ID == id {
+			return post
+		}
+	}
+
+	return nil
+
+}
+
+// queue returns the posts in the queue of a channel the input selection policy
+// admits, sorted by priority and addition time. The caller must hold the mutex.
+func (s *Store) queue(channelID int64, policy selection.Policy) []entities.Post {
+
+	//
+	var queue []entities.Post
+	for _, post := range s.posts {
+		if post.ChannelID == channelID && post.PostedAt == nil && !post.HasError && post.ScheduledAt == nil && post.RemovedAt == nil && policy.Admits(post) {
+			queue = append(queue, *post)
+		}
+	}
+
+	//
+	sort.SliceStable(queue, func(i, j int) bool {
+
+		if queue[i].Priority != queue[j].Priority {
+			return queue[i].Priority > queue[j].Priority
+		}
+
+		return queue[i].AddedAt.Before(queue[j].AddedAt)
+
+	})
+
+	return queue
+
+}
+
+// history returns what has already been posted on a channel.
+// The caller must hold the mutex.
+func (s *Store) history(channelID int64) selection.History {
+
+	//
+	var posted []*entities.Post
+	for _, post := range s.posts {
+		if post.ChannelID == channelID && post.PostedAt != nil {
+			posted = append(posted, post)
+		}
+	}
+
+	// Latest first
+	sort.SliceStable(posted, func(i, j int) bool {
+		return posted[i].PostedAt.After(*posted[j].PostedAt)
+	})
+
+	//
+	history := selection.History{LastPosted: make(map[int32]time.Time)}
+	for _, post := range posted {
+
+		if _, found := history.LastPosted[post.AddedBy]; !found {
+			history.LastPosted[post.AddedBy] = *post.PostedAt
+		}
+
+		if len(history.RecentTypes) < selection.HistoryLength {
+			history.RecentTypes = append(history.RecentTypes, post.Media.Type)
+		}
+
+	}
+
+	return history
+
+}
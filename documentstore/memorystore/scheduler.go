@@ -0,0 +1,34 @@
+package memorystore
+
+import (
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+)
+
+// SaveSchedulerState saves the state of the posting scheduler of a channel,
+// replacing the previous one.
+func (s *Store) SaveSchedulerState(state entities.SchedulerState) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state.UpdatedAt = s.now()
+	s.states[state.ChannelID] = state
+	return nil
+
+}
+
+// GetSchedulerState retrieves the last saved state of the posting scheduler of a channel.
+func (s *Store) GetSchedulerState(channelID int64) (entities.SchedulerState, error) {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	state, found := s.states[channelID]
+	if !found {
+		return state, storage.ErrNotFound
+	}
+
+	return state, nil
+
+}
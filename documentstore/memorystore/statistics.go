@@ -0,0 +1,38 @@
+package memorystore
+
+import (
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/statistics"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// RecordDuplicate records the submission of a media similar to a stored one,
+// at the current time.
+func (s *Store) RecordDuplicate(duplicate entities.Duplicate) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	duplicate.ID = primitive.NewObjectID()
+	duplicate.SubmittedAt = s.now()
+	s.duplicates = append(s.duplicates, duplicate)
+	return nil
+
+}
+
+// GetStatistics aggregates the activity on a channel between since, included,
+// and until, excluded. A zero since covers the whole history.
+func (s *Store) GetStatistics(channelID int64, since, until time.Time) (statistics.Report, error) {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	posts := make([]entities.Post, len(s.posts))
+	for i, post := range s.posts {
+		posts[i] = *post
+	}
+
+	return statistics.Aggregate(channelID, posts, s.duplicates, since, until), nil
+
+}
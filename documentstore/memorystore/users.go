@@ -0,0 +1,120 @@
+package memorystore
+
+import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AddUser adds a user to the authorized admins.
+func (s *Store) AddUser(userID int32) error {
+
+	//
+	if userID <= 0 {
+		return fmt.Errorf("AddUser: userID <= 0: %d", userID)
+	}
+
+	//
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.findUser(userID) != nil {
+		return fmt.Errorf("AddUser: user %d already added", userID)
+	}
+
+	s.users = append(s.users, &entities.User{
+		ID:         primitive.NewObjectID(),
+		TelegramID: userID,
+		CreatedAt:  s.now(),
+	})
+
+	return nil
+
+}
+
+// UserIsAuthorized returns true if the user can interact with the bot.
+func (s *Store) UserIsAuthorized(userID int32) bool {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return userID > 0 && s.findUser(userID) != nil
+
+}
+
+// GetUsers retrieves all the authorized users.
+func (s *Store) GetUsers() ([]entities.User, error) {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	users := make([]entities.User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, *user)
+	}
+
+	return users, nil
+
+}
+
+// FindUserByTelegramID retrieves an authorized user via its Telegram ID.
+func (s *Store) FindUserByTelegramID(userID int32) (entities.User, error) {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	user := s.findUser(userID)
+	if user == nil {
+		return entities.User{}, storage.ErrNotFound
+	}
+
+	return *user, nil
+
+}
+
+// UpdateUserTargetChannel updates the channel the media added by a user will be posted on.
+func (s *Store) UpdateUserTargetChannel(userID int32, channelID int64) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	user := s.findUser(userID)
+	if user == nil {
+		return storage.ErrNotFound
+	}
+
+	user.TargetChannelID = channelID
+	return nil
+
+}
+
+// DeleteUser deletes a user.
+func (s *Store) DeleteUser(userID int32) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, user := range s.users {
+		if user.TelegramID == userID {
+			s.users = append(s.users[:i], s.users[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+
+}
+
+// findUser returns the stored user with the input Telegram ID, if any.
+// The caller must hold the mutex.
+func (s *Store) findUser(userID int32) *entities.User {
+
+	for _, user := range s.users {
+		if user.TelegramID == userID {
+			return user
+		}
+	}
+
+	return nil
+
+}
@@ -0,0 +1,557 @@
+package documentstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+
+	//
+	migrationCollectionName = "migrations"
+
+	// indexDeadline is the deadline for the creation of an index,
+	// that may take a while on large collections.
+	indexDeadline = 5 * time.Minute
+
+	// indexNotFoundErrorCode is the code of the errors caused by dropping an index that doesn't exist.
+	indexNotFoundErrorCode = 27
+)
+
+// migration is a versioned change to the document store.
+// Migrations are applied in order and only once, the version reached
+// being recorded in the migration collection.
+type migration struct {
+	version     int
+	description string
+	apply       func(database *mongo.Database, defaultChannelID int64) error
+}
+
+// channelFileUniqueIDIndex makes the file unique IDs unique among the posts of a channel
+// that weren't removed. Removed posts are told apart by their removal time.
+var channelFileUniqueIDIndex = mongo.IndexModel{
+	Keys: bson.D{
+		{Key: "channelid", Value: 1},
+		{Key: "media.fileuniqueid", Value: 1},
+		{Key: "removedat", Value: 1},
+	},
+	Options: options.Index().SetName("channel_fileuniqueid_removedat").SetUnique(true),
+}
+
+// migrations are the changes needed to bring the document store
+// created by any older version of the bot up to date.
+// New migrations must be appended with the next version.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "set the priority of the posts added before priorities existed",
+		apply: func(database *mongo.Database, _ int64) error {
+			// Posts without a priority would be sorted after the low priority ones
+			return backfillField(database.Collection(postCollectionName), "priority", entities.PriorityNormal)
+		},
+	},
+	{
+		version:     2,
+		description: "assign the posts added before the support for multiple channels to the default one",
+		apply: func(database *mongo.Database, defaultChannelID int64) error {
+			return backfillField(database.Collection(postCollectionName), "channelid", defaultChannelID)
+		},
+	},
+	{
+		version:     3,
+		description: "assign the scheduler state saved before the support for multiple channels to the default one",
+		apply: func(database *mongo.Database, defaultChannelID int64) error {
+			return backfillField(database.Collection(schedulerCollectionName), "channelid", defaultChannelID)
+		},
+	},
+	{
+		version:     4,
+		description: "remove the posts with the same media as an older one on their channel",
+		apply: func(database *mongo.Database, _ int64) error {
+			return removeDuplicatePosts(database.Collection(postCollectionName))
+		},
+	},
+	{
+		version:     5,
+		description: "create the post indexes",
+		apply: func(database *mongo.Database, _ int64) error {
+			return createPostIndexes(database.Collection(postCollectionName))
+		},
+	},
+	{
+		version:     6,
+		description: "create the duplicate submission indexes",
+		apply: func(database *mongo.Database, _ int64) error {
+			return createDuplicateIndexes(database.Collection(duplicateCollectionName))
+		},
+	},
+	{
+		version:     7,
+		description: "allow the same media to be added to more than one channel",
+		apply: func(database *mongo.Database, _ int64) error {
+			return replaceFileUniqueIDIndex(database.Collection(postCollectionName), "fileuniqueid")
+		},
+	},
+	{
+		version:     8,
+		description: "allow removed posts to have the same media as a queued one",
+		apply: func(database *mongo.Database, _ int64) error {
+			return replaceFileUniqueIDIndex(database.Collection(postCollectionName), "channel_fileuniqueid")
+		},
+	},
+	{
+		version:     9,
+		description: "prevent the users from being authorized twice",
+		apply: func(database *mongo.Database, _ int64) error {
+			return createUserIndexes(database.Collection(userCollectionName))
+		},
+	},
+	{
+		version:     10,
+		description: "reserve the features of the media being added",
+		apply: func(database *mongo.Database, _ int64) error {
+			return createReservationIndexes(database.Collection(reservationCollectionName))
+		},
+	},
+}
+
+// migrate applies the migrations the document store is missing, in order.
+// Documents created before the support for multiple channels
+// will be assigned to the default channel.
+func migrate(database *mongo.Database, defaultChannelID int64) error {
+
+	//
+	collection := database.Collection(migrationCollectionName)
+	version, err := schemaVersion(collection)
+	if err != nil {
+		return err
+	}
+
+	//
+	for _, m := range migrations {
+
+		if m.version <= version {
+			continue
+		}
+
+		log.Info("Migrating the document store to version ", m.version, ": ", m.description)
+		err = m.apply(database, defaultChannelID)
+		if err != nil {
+			return fmt.Errorf("migration to version %d failed: %v", m.version, err)
+		}
+
+		err = recordMigration(collection, m)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+
+}
+
+// schemaVersion returns the version of the last migration applied to the document store,
+// or zero if none was.
+func schemaVersion(collection *mongo.Collection) (int, error) {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	var last entities.Migration
+	findOptions := options.FindOne().SetSort(bson.M{"version": -1})
+	err := collection.FindOne(ctx, bson.M{}, findOptions).Decode(&last)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		return 0, nil
+	case err != nil:
+		return 0, fmt.Errorf("unable to retrieve the schema version: %v", err)
+	}
+
+	return last.Version, nil
+
+}
+
+// recordMigration records that a migration has been applied.
+func recordMigration(collection *mongo.Collection, m migration) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	_, err := collection.InsertOne(ctx, entities.Migration{
+		Version:     m.version,
+		Description: m.description,
+		AppliedAt:   time.Now(),
+	})
+
+	if err != nil {
+		return fmt.Errorf("unable to record the migration to version %d: %v", m.version, err)
+	}
+
+	return nil
+
+}
+
+// removeDuplicatePosts removes the posts with the same media as another one of their channel,
+// so that the file unique IDs can be indexed as unique within a channel.
+// Of each group of duplicates, the first posted is kept, otherwise the oldest that wasn't removed.
+// Queued duplicates are removed as an admin would, so that the removal can be undone
+// until they're purged, while the other duplicates are deleted.
+func removeDuplicatePosts(collection *mongo.Collection) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), indexDeadline)
+	defer cancelCtx()
+
+	//
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.M{"addedat": 1}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.M{"channelid": "$channelid", "fileuniqueid": "$media.fileuniqueid"}},
+			{Key: "posts", Value: bson.M{"$push": bson.M{"_id": "$_id", "addedby": "$addedby", "postedat": "$postedat", "removedat": "$removedat"}}},
+			{Key: "count", Value: bson.M{"$sum": 1}},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("unable to find the duplicate posts: %v", err)
+	}
+
+	//
+	var groups []struct {
+		ID struct {
+			ChannelID    int64  `bson:"channelid"`
+			FileUniqueID string `bson:"fileuniqueid"`
+		} `bson:"_id"`
+		Posts []struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			AddedBy   int32              `bson:"addedby"`
+			PostedAt  *time.Time         `bson:"postedat"`
+			RemovedAt *time.Time         `bson:"removedat"`
+		}
+	}
+
+	err = cursor.All(ctx, &groups)
+	if err != nil {
+		return fmt.Errorf("unable to decode the duplicate posts: %v", err)
+	}
+
+	//
+	for _, group := range groups {
+
+		// The first posted, otherwise the oldest that wasn't removed
+		kept := -1
+		for i, post := range group.Posts {
+
+			if post.PostedAt != nil {
+				kept = i
+				break
+			}
+
+			if kept == -1 && post.RemovedAt == nil {
+				kept = i
+			}
+
+		}
+
+		if kept == -1 {
+			kept = 0
+		}
+
+		//
+		var deleted []primitive.ObjectID
+		for i, post := range group.Posts {
+
+			if i == kept {
+				continue
+			}
+
+			if post.PostedAt != nil || post.RemovedAt != nil {
+				deleted = append(deleted, post.ID)
+				continue
+			}
+
+			err = removeQueuedDuplicate(collection, post.ID, post.AddedBy, i)
+			if err != nil {
+				return err
+			}
+
+			log.Warn("Removed post ", post.ID.Hex(), " from the queue of channel ", group.ID.ChannelID,
+				": it has the same media as post ", group.Posts[kept].ID.Hex())
+
+		}
+
+		//
+		if len(deleted) == 0 {
+			continue
+		}
+
+		_, err = collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": deleted}})
+		if err != nil {
+			return fmt.Errorf("unable to remove the duplicates of %s on channel %d: %v", group.ID.FileUniqueID, group.ID.ChannelID, err)
+		}
+
+		log.Warn("Removed ", len(deleted), " duplicates of the media with unique ID ", group.ID.FileUniqueID,
+			" on channel ", group.ID.ChannelID)
+
+	}
+
+	return nil
+
+}
+
+// removeQueuedDuplicate removes a queued post on behalf of the user who added it.
+// The removal times of the duplicates of a media are a millisecond apart,
+// as they tell the removed posts apart in the file unique ID index.
+func removeQueuedDuplicate(collection *mongo.Collection, id primitive.ObjectID, addedBy int32, index int) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.D{
+				{Key: "removedat", Value: time.Now().Add(time.Duration(index) * time.Millisecond)},
+				{Key: "removedby", Value: addedBy},
+			},
+		},
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update, options.Update())
+	if err != nil {
+		return fmt.Errorf("unable to remove the queued post %s: %v", id.Hex(), err)
+	}
+
+	return nil
+
+}
+
+// createPostIndexes creates the indexes used by the post queries:
+// the file unique IDs, unique within a channel, the queue ordering and the histogram features.
+func createPostIndexes(collection *mongo.Collection) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), indexDeadline)
+	defer cancelCtx()
+
+	//
+	models := []mongo.IndexModel{
+		channelFileUniqueIDIndex,
+		{
+			Keys: bson.D{
+				{Key: "channelid", Value: 1},
+				{Key: "priority", Value: -1},
+				{Key: "addedat", Value: 1},
+			},
+			Options: options.Index().SetName("queue"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "media.histogramaverage", Value: 1},
+				{Key: "media.histogramsum", Value: 1},
+			},
+			Options: options.Index().SetName("histogram"),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		return fmt.Errorf("unable to create the post indexes: %v", err)
+	}
+
+	return nil
+
+}
+
+// replaceFileUniqueIDIndex replaces the index on the file unique IDs
+// created by older versions of the bot with the current one.
+func replaceFileUniqueIDIndex(collection *mongo.Collection, previous string) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), indexDeadline)
+	defer cancelCtx()
+
+	// The index was never created if the document store is more recent
+	_, err := collection.Indexes().DropOne(ctx, previous)
+	var ce mongo.CommandError
+	if err != nil && !(errors.As(err, &ce) && ce.Code == indexNotFoundErrorCode) {
+		return fmt.Errorf("unable to drop the %s index: %v", previous, err)
+	}
+
+	//
+	_, err = collection.Indexes().CreateOne(ctx, channelFileUniqueIDIndex)
+	if err != nil {
+		return fmt.Errorf("unable to create the %s index: %v", *channelFileUniqueIDIndex.Options.Name, err)
+	}
+
+	return nil
+
+}
+
+// createDuplicateIndexes creates the index used by the statistics
+// on the duplicate submissions of a channel.
+func createDuplicateIndexes(collection *mongo.Collection) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), indexDeadline)
+	defer cancelCtx()
+
+	//
+	model := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "channelid", Value: 1},
+			{Key: "submittedat", Value: 1},
+		},
+		Options: options.Index().SetName("submissions"),
+	}
+
+	_, err := collection.Indexes().CreateOne(ctx, model)
+	if err != nil {
+		return fmt.Errorf("unable to create the duplicate indexes: %v", err)
+	}
+
+	return nil
+
+}
+
+// createReservationIndexes creates the index allowing a feature bucket of a channel
+// to be reserved only once and the one deleting the expired reservations.
+func createReservationIndexes(collection *mongo.Collection) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), indexDeadline)
+	defer cancelCtx()
+
+	//
+	models := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "channelid", Value: 1},
+				{Key: "bucket", Value: 1},
+			},
+			Options: options.Index().SetName("channel_bucket").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresat", Value: 1}},
+			Options: options.Index().SetName("expiresat").SetExpireAfterSeconds(0),
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		return fmt.Errorf("unable to create the reservation indexes: %v", err)
+	}
+
+	return nil
+
+}
+
+// createUserIndexes creates the index making the Telegram IDs of the users unique,
+// keeping the oldest of the users that were authorized more than once.
+func createUserIndexes(collection *mongo.Collection) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), indexDeadline)
+	defer cancelCtx()
+
+	//
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$telegramid"},
+			{Key: "ids", Value: bson.M{"$push": "$_id"}},
+		}}},
+		{{Key: "$match", Value: bson.M{"ids.1": bson.M{"$exists": true}}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("unable to find the duplicate users: %v", err)
+	}
+
+	var groups []struct {
+		TelegramID int32                `bson:"_id"`
+		IDs        []primitive.ObjectID `bson:"ids"`
+	}
+
+	err = cursor.All(ctx, &groups)
+	if err != nil {
+		return fmt.Errorf("unable to decode the duplicate users: %v", err)
+	}
+
+	//
+	for _, group := range groups {
+
+		_, err = collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": group.IDs[1:]}})
+		if err != nil {
+			return fmt.Errorf("unable to remove the duplicates of user %d: %v", group.TelegramID, err)
+		}
+
+		log.Warn("Removed ", len(group.IDs)-1, " duplicates of user ", group.TelegramID)
+
+	}
+
+	//
+	model := mongo.IndexModel{
+		Keys:    bson.D{{Key: "telegramid", Value: 1}},
+		Options: options.Index().SetName("telegramid").SetUnique(true),
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, model)
+	if err != nil {
+		return fmt.Errorf("unable to create the user indexes: %v", err)
+	}
+
+	return nil
+
+}
+
+// ============================================================================
+
+// backfillField sets a field to the input value in all the documents
+// of a collection where the field is missing.
+func backfillField(collection *mongo.Collection, field string, value interface{}) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	filter := bson.M{field: bson.M{"$exists": false}}
+	update := bson.D{
+		{
+			Key:   "$set",
+			Value: bson.D{{Key: field, Value: value}},
+		},
+	}
+
+	//
+	res, err := collection.UpdateMany(ctx, filter, update, options.Update())
+	if err != nil {
+		return fmt.Errorf("unable to backfill the %s field in %s: %v", field, collection.Name(), err)
+	}
+
+	if res.ModifiedCount > 0 {
+		log.Info("Backfilled the ", field, " field of ", res.ModifiedCount, " documents in ", collection.Name())
+	}
+
+	return nil
+
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
 	fpcompare "github.com/shitpostingio/image-fingerprinting/comparer"
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,24 +17,54 @@ import (
 	"time"
 )
 
-// AddPost adds a post to the database.
-func AddPost(addedBy int32, media entities.Media, caption string, collection *mongo.Collection) error {
+const (
+
+	// captionUpdateAttempts is the number of times a caption update is attempted
+	// when the caption is changed concurrently.
+	captionUpdateAttempts = 3
+
+	// duplicateKeyErrorCode is the code of the errors caused by the violation of a unique index.
+	duplicateKeyErrorCode = 11000
+)
+
+var (
+
+	// queueSorting sorts the posts in queue order:
+	// highest priority first, then oldest first.
+	queueSorting = bson.D{
+		{Key: "priority", Value: -1},
+		{Key: "addedat", Value: 1},
+	}
+)
+
+// AddPost adds a post to the queue of a channel.
+// A removed post with the same media on the channel is replaced, while adding
+// another post with the same media to the channel fails with a duplicate key error.
+func AddPost(addedBy int32, channelID int64, media entities.Media, caption string, collection *mongo.Collection) error {
 
 	//
 	post := entities.Post{
-		AddedBy: addedBy,
-		Media:   media,
-		Caption: caption,
-		AddedAt: time.Now(),
+		AddedBy:   addedBy,
+		ChannelID: channelID,
+		Media:     media,
+		Caption:   caption,
+		AddedAt:   time.Now(),
 	}
 
 	//
 	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
 	defer cancelCtx()
 
-	//
-	_, err := collection.InsertOne(ctx, post)
+	// A removed post with the same media is replaced
+	removed := bson.M{"channelid": channelID, "media.fileuniqueid": media.FileUniqueID, "removedat": bson.M{"$ne": nil}}
+	_, err := collection.DeleteMany(ctx, removed, options.Delete())
 	if err != nil {
+		return fmt.Errorf("AddPost: %v", err)
+	}
+
+	// Duplicate key errors are left as they are for the callers to recognize them
+	_, err = collection.InsertOne(ctx, post)
+	if err != nil && !isDuplicateKeyError(err) {
 		err = fmt.Errorf("AddPost: %v", err)
 	}
 
@@ -40,32 +72,162 @@ func AddPost(addedBy int32, media entities.Media, caption string, collection *mo
 
 }
 
-// UpdatePostCaptionByUniqueID updates the caption of a post given its uniqueID.
-func UpdatePostCaptionByUniqueID(uniqueID, caption string, collection *mongo.Collection) error {
+// isDuplicateKeyError returns true if a write failed
+// because it violated a unique index.
+func isDuplicateKeyError(err error) bool {
+
+	var writeException mongo.WriteException
+	if !errors.As(err, &writeException) {
+		return false
+	}
+
+	for _, writeError := range writeException.WriteErrors {
+		if writeError.Code == duplicateKeyErrorCode {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// UpdatePostCaptionByUniqueID updates the caption of a post given its channel and uniqueID,
+// recording the previous one and the user who edited it in the caption history.
+// The caption is only replaced if it wasn't changed since it was read,
+// so that concurrent edits don't lose revisions.
+func UpdatePostCaptionByUniqueID(channelID int64, uniqueID, caption string, editedBy int32, collection *mongo.Collection) error {
+
+	for attempt := 0; attempt < captionUpdateAttempts; attempt++ {
+
+		//
+		post, err := FindPostByUniqueID(channelID, uniqueID, collection)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Nothing to record
+		if post.Caption == caption {
+			return nil
+		}
+
+		//
+		ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+		now := time.Now()
+		revision := entities.CaptionRevision{
+			Caption:  post.Caption,
+			EditedBy: editedBy,
+			EditedAt: now,
+		}
+
+		//
+		filter := bson.M{"_id": post.ID, "caption": post.Caption}
+		update := bson.D{
+			{
+				Key: "$set",
+				Value: bson.D{
+					{Key: "caption", Value: caption},
+					{Key: "updatedat", Value: now},
+				},
+			},
+			{
+				Key: "$push",
+				Value: bson.M{
+					"captionhistory": bson.D{
+						{Key: "$each", Value: []entities.CaptionRevision{revision}},
+						{Key: "$slice", Value: -entities.MaxCaptionRevisions},
+					},
+				},
+			},
+		}
+
+		//
+		res, err := collection.UpdateOne(ctx, filter, update, options.Update())
+		cancelCtx()
+		if err != nil {
+			return err
+		}
+
+		if res.MatchedCount > 0 {
+			return nil
+		}
+
+	}
+
+	return fmt.Errorf("UpdatePostCaptionByUniqueID: the caption of %s kept changing", uniqueID)
+
+}
+
+// AddPostTagsByUniqueID adds a post to the themed queues with the input tags, given its channel and uniqueID.
+func AddPostTagsByUniqueID(channelID int64, uniqueID string, tags []string, collection *mongo.Collection) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
 
 	//
 	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
 	defer cancelCtx()
 
 	//
-	filter := bson.M{"media.fileuniqueid": uniqueID}
+	filter := uniqueIDFilter(channelID, uniqueID)
 	update := bson.D{
 		{
-			Key: "$set",
-			Value: bson.D{
-				{Key: "caption", Value: caption},
-			},
+			Key:   "$addToSet",
+			Value: bson.D{{Key: "tags", Value: bson.D{{Key: "$each", Value: tags}}}},
+		},
+	}
+
+	//
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update())
+	return err
+
+}
+
+// RemovePostTagsByUniqueID removes a post from the themed queues with the input tags,
+// given its channel and uniqueID. If no tag is passed, the post is removed from every themed queue.
+func RemovePostTagsByUniqueID(channelID int64, uniqueID string, tags []string, collection *mongo.Collection) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	update := bson.D{
+		{
+			Key:   "$pullAll",
+			Value: bson.D{{Key: "tags", Value: tags}},
 		},
 	}
 
+	if len(tags) == 0 {
+		update = bson.D{
+			{
+				Key:   "$unset",
+				Value: bson.D{{Key: "tags", Value: ""}},
+			},
+		}
+	}
+
 	//
+	filter := uniqueIDFilter(channelID, uniqueID)
 	_, err := collection.UpdateOne(ctx, filter, update, options.Update())
 	return err
 
 }
 
-// FindPostByFeatures finds a post by its features.
-func FindPostByFeatures(histogram []float64, pHash string, approximation float64, similarityThreshold int, collection *mongo.Collection) (post entities.Post, err error) {
+// FindPostByFeatures finds a post of a channel by its features.
+// Removed posts are matched only if no other post is similar.
+func FindPostByFeatures(channelID int64, histogram []float64, pHash string, approximation float64, similarityThreshold int, collection *mongo.Collection) (post entities.Post, err error) {
 
 	//
 	if histogram == nil {
@@ -88,6 +250,10 @@ func FindPostByFeatures(histogram []float64, pHash string, approximation float64
 
 	//
 	filter := bson.D{
+		{
+			Key:   "channelid",
+			Value: channelID,
+		},
 		{
 			Key: "media.histogramaverage",
 			Value: bson.D{
@@ -126,8 +292,8 @@ func FindPostByFeatures(histogram []float64, pHash string, approximation float64
 
 }
 
-// FindPostByUniqueID retrieves a post via its uniqueID.
-func FindPostByUniqueID(uniqueID string, collection *mongo.Collection) (post entities.Post, err error) {
+// FindPostByUniqueID retrieves a post via its channel and uniqueID, unless it was removed.
+func FindPostByUniqueID(channelID int64, uniqueID string, collection *mongo.Collection) (post entities.Post, err error) {
 
 	//
 	if uniqueID == "" {
@@ -139,7 +305,7 @@ func FindPostByUniqueID(uniqueID string, collection *mongo.Collection) (post ent
 	defer cancelCtx()
 
 	//
-	filter := bson.M{"media.fileuniqueid": uniqueID}
+	filter := uniqueIDFilter(channelID, uniqueID)
 
 	//
 	result := collection.FindOne(ctx, filter, options.FindOne())
@@ -153,8 +319,9 @@ func FindPostByUniqueID(uniqueID string, collection *mongo.Collection) (post ent
 
 }
 
-// DeletePostByUniqueID deletes a post entity via its uniqueID.
-func DeletePostByUniqueID(uniqueID string, collection *mongo.Collection) error {
+// RemovePostByUniqueID removes a post via its channel and uniqueID, keeping it until it's purged
+// so that the removal can be undone.
+func RemovePostByUniqueID(channelID int64, uniqueID string, removedBy int32, collection *mongo.Collection) error {
 
 	//
 	if uniqueID == "" {
@@ -166,48 +333,216 @@ func DeletePostByUniqueID(uniqueID string, collection *mongo.Collection) error {
 	defer cancelCtx()
 
 	//
-	filter := bson.M{"media.fileuniqueid": uniqueID}
+	filter := uniqueIDFilter(channelID, uniqueID)
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.D{
+				{Key: "removedat", Value: time.Now()},
+				{Key: "removedby", Value: removedBy},
+			},
+		},
+	}
 
 	//
-	_, err := collection.DeleteOne(ctx, filter, options.Delete())
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update())
 	return err
 
 }
 
-// GetNextPost retrieves the oldest post in the queue (not yet posted).
-func GetNextPost(collection *mongo.Collection) (post entities.Post, err error) {
+// RestoreLastRemovedPost restores the post removed most recently by a user.
+func RestoreLastRemovedPost(removedBy int32, collection *mongo.Collection) (post entities.Post, err error) {
 
 	//
 	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
 	defer cancelCtx()
 
 	//
-	filter := bson.D{
+	filter := bson.M{"removedby": removedBy, "removedat": bson.M{"$ne": nil}}
+	update := bson.D{
 		{
-			Key:   "messageid",
-			Value: 0,
+			Key: "$unset",
+			Value: bson.D{
+				{Key: "removedat", Value: ""},
+				{Key: "removedby", Value: ""},
+			},
 		},
+	}
+
+	//
+	updateOptions := options.FindOneAndUpdate().
+		SetSort(bson.M{"removedat": -1}).
+		SetReturnDocument(options.After)
+	err = collection.FindOneAndUpdate(ctx, filter, update, updateOptions).Decode(&post)
+	return
+
+}
+
+// PurgeRemovedPosts deletes the posts removed before the input time,
+// returning how many were deleted.
+func PurgeRemovedPosts(removedBefore time.Time, collection *mongo.Collection) (int64, error) {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	filter := bson.M{"removedat": bson.M{"$lt": removedBefore}}
+	res, err := collection.DeleteMany(ctx, filter, options.Delete())
+	if err != nil {
+		return 0, fmt.Errorf("PurgeRemovedPosts: %v", err)
+	}
+
+	return res.DeletedCount, nil
+
+}
+
+// GetNextPost retrieves the next post in the queue of a channel (not yet posted),
+// according to the input selection policy. In FIFO mode, that is the oldest
+// among the ones with the highest priority.
+func GetNextPost(channelID int64, policy selection.Policy, collection *mongo.Collection) (post entities.Post, err error) {
+
+	//
+	if !policy.IsFIFO() {
+
+		queue, err := GetOrderedQueue(channelID, policy, collection)
+		if err != nil {
+			return post, err
+		}
+
+		if len(queue) == 0 {
+			return post, mongo.ErrNoDocuments
+		}
+
+		return queue[0], nil
+
+	}
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	sortingOptions := options.FindOne().SetSort(queueSorting)
+
+	//
+	err = collection.FindOne(ctx, queueFilter(channelID, policy), sortingOptions).Decode(&post)
+	return
+
+}
+
+// GetQueue retrieves all the posts in the queue of a channel the input
+// selection policy admits, sorted by priority and addition time.
+func GetQueue(channelID int64, policy selection.Policy, collection *mongo.Collection) (posts []entities.Post, err error) {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	sortingOptions := options.Find().SetSort(queueSorting)
+
+	//
+	cursor, err := collection.Find(ctx, queueFilter(channelID, policy), sortingOptions)
+	if err != nil {
+		return nil, fmt.Errorf("GetQueue: %v", err)
+	}
+
+	//
+	err = cursor.All(ctx, &posts)
+	return
+
+}
+
+// GetOrderedQueue retrieves the queue of a channel,
+// in the order given by the input selection policy.
+func GetOrderedQueue(channelID int64, policy selection.Policy, collection *mongo.Collection) ([]entities.Post, error) {
+
+	//
+	queue, err := GetQueue(channelID, policy, collection)
+	if err != nil || policy.IsFIFO() {
+		return queue, err
+	}
+
+	//
+	lastPostTimes, err := GetLastPostTimes(channelID, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	//
+	recentTypes, err := GetRecentMediaTypes(channelID, selection.HistoryLength, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	history := selection.History{
+		LastPosted:  lastPostTimes,
+		RecentTypes: recentTypes,
+	}
+
+	return selection.Order(queue, history, policy), nil
+
+}
+
+// GetLastPostTimes returns the time of the last post of each contributor on a channel.
+func GetLastPostTimes(channelID int64, collection *mongo.Collection) (map[int32]time.Time, error) {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	pipeline := mongo.Pipeline{
 		{
-			Key:   "postedat",
-			Value: nil,
+			{
+				Key: "$match",
+				Value: bson.D{
+					{Key: "channelid", Value: channelID},
+					{Key: "postedat", Value: bson.D{{Key: "$ne", Value: nil}}},
+				},
+			},
 		},
 		{
-			Key:   "haserror",
-			Value: nil,
+			{
+				Key: "$group",
+				Value: bson.D{
+					{Key: "_id", Value: "$addedby"},
+					{Key: "lastpostedat", Value: bson.D{{Key: "$max", Value: "$postedat"}}},
+				},
+			},
 		},
 	}
 
 	//
-	sortingOptions := options.FindOne().SetSort(bson.M{"addedat": 1})
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("GetLastPostTimes: %v", err)
+	}
 
 	//
-	err = collection.FindOne(ctx, filter, sortingOptions).Decode(&post)
-	return
+	var results []struct {
+		AddedBy      int32     `bson:"_id"`
+		LastPostedAt time.Time `bson:"lastpostedat"`
+	}
+
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, fmt.Errorf("GetLastPostTimes: %v", err)
+	}
+
+	//
+	lastPostTimes := make(map[int32]time.Time, len(results))
+	for _, result := range results {
+		lastPostTimes[result.AddedBy] = result.LastPostedAt
+	}
+
+	return lastPostTimes, nil
 
 }
 
-// GetQueueLength returns the number of the enqueued posts.
-func GetQueueLength(collection *mongo.Collection) (length int64) {
+// GetRecentMediaTypes returns the media types of the last posts on a channel, the latest first.
+func GetRecentMediaTypes(channelID int64, limit int, collection *mongo.Collection) ([]string, error) {
 
 	//
 	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
@@ -215,15 +550,106 @@ func GetQueueLength(collection *mongo.Collection) (length int64) {
 
 	//
 	filter := bson.D{
+		{Key: "channelid", Value: channelID},
+		{Key: "postedat", Value: bson.D{{Key: "$ne", Value: nil}}},
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.M{"postedat": -1}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"media.type": 1})
+
+	//
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("GetRecentMediaTypes: %v", err)
+	}
+
+	//
+	var posts []entities.Post
+	err = cursor.All(ctx, &posts)
+	if err != nil {
+		return nil, fmt.Errorf("GetRecentMediaTypes: %v", err)
+	}
+
+	//
+	recentTypes := make([]string, 0, len(posts))
+	for _, post := range posts {
+		recentTypes = append(recentTypes, post.Media.Type)
+	}
+
+	return recentTypes, nil
+
+}
+
+// GetQueueLength returns the number of the posts enqueued on a channel
+// the input selection policy admits, or storage.UnknownQueueLength if they can't be counted.
+// Posts scheduled for a specific time are not part of the queue.
+func GetQueueLength(channelID int64, policy selection.Policy, collection *mongo.Collection) (length int64) {
+
+	err := retryTransient(func() (err error) {
+
+		//
+		ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+		defer cancelCtx()
+
+		//
+		length, err = collection.CountDocuments(ctx, queueFilter(channelID, policy), options.Count())
+		return err
+
+	})
+
+	if err != nil {
+		return storage.UnknownQueueLength
+	}
+
+	return length
+
+}
+
+// GetQueuePosition returns the position of a post in the queue of its channel,
+// according to the input selection policy.
+// In FIFO mode, the posts with a higher priority and the ones
+// with the same priority added before it are counted.
+func GetQueuePosition(post *entities.Post, policy selection.Policy, collection *mongo.Collection) (position int) {
+
+	//
+	if !policy.IsFIFO() {
+
+		queue, err := GetOrderedQueue(post.ChannelID, policy, collection)
+		if err != nil {
+			return -1
+		}
+
+		for i, enqueued := range queue {
+			if enqueued.ID == post.ID {
+				return i + 1
+			}
+		}
+
+		return -1
+
+	}
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	filter := append(bson.D{
 		{
-			Key:   "postedat",
-			Value: nil,
-		},
-		{
-			Key:   "haserror",
-			Value: nil,
+			Key: "$or",
+			Value: bson.A{
+				bson.D{
+					{Key: "priority", Value: bson.D{{Key: "$gt", Value: post.Priority}}},
+				},
+				bson.D{
+					{Key: "priority", Value: post.Priority},
+					{Key: "addedat", Value: bson.D{{Key: "$lte", Value: post.AddedAt}}},
+				},
+			},
 		},
-	}
+	}, queueFilter(post.ChannelID, policy)...)
 
 	//
 	res, err := collection.CountDocuments(ctx, filter, options.Count())
@@ -231,12 +657,50 @@ func GetQueueLength(collection *mongo.Collection) (length int64) {
 		return -1
 	}
 
-	return res
+	return int(res)
+
+}
+
+// SchedulePostByUniqueID schedules a post for a specific time given its channel and uniqueID,
+// removing it from the queue. A nil scheduledAt puts the post back in the queue.
+func SchedulePostByUniqueID(channelID int64, uniqueID string, scheduledAt *time.Time, collection *mongo.Collection) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	update := bson.D{
+		{
+			Key:   "$set",
+			Value: bson.D{{Key: "scheduledat", Value: scheduledAt}},
+		},
+	}
+
+	if scheduledAt == nil {
+		update = bson.D{
+			{
+				Key:   "$unset",
+				Value: bson.D{{Key: "scheduledat", Value: ""}},
+			},
+		}
+	}
+
+	//
+	filter := uniqueIDFilter(channelID, uniqueID)
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update())
+	return err
 
 }
 
-// GetQueuePositionByAddTime returns the position of the first post added before the input time.
-func GetQueuePositionByAddTime(addedAt time.Time, collection *mongo.Collection) (position int) {
+// GetScheduledPosts retrieves the posts scheduled for a specific time on a channel
+// that have yet to be posted, the closest first.
+func GetScheduledPosts(channelID int64, collection *mongo.Collection) (posts []entities.Post, err error) {
 
 	//
 	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
@@ -245,8 +709,8 @@ func GetQueuePositionByAddTime(addedAt time.Time, collection *mongo.Collection)
 	//
 	filter := bson.D{
 		{
-			Key:   "addedat",
-			Value: bson.D{{Key: "$lte", Value: addedAt}},
+			Key:   "channelid",
+			Value: channelID,
 		},
 		{
 			Key:   "postedat",
@@ -256,15 +720,50 @@ func GetQueuePositionByAddTime(addedAt time.Time, collection *mongo.Collection)
 			Key:   "haserror",
 			Value: nil,
 		},
+		{
+			Key:   "scheduledat",
+			Value: bson.D{{Key: "$ne", Value: nil}},
+		},
+		{
+			Key:   "removedat",
+			Value: nil,
+		},
 	}
 
 	//
-	res, err := collection.CountDocuments(ctx, filter, options.Count())
+	sortingOptions := options.Find().SetSort(bson.M{"scheduledat": 1})
+
+	//
+	cursor, err := collection.Find(ctx, filter, sortingOptions)
 	if err != nil {
-		return -1
+		return nil, fmt.Errorf("GetScheduledPosts: %v", err)
 	}
 
-	return int(res)
+	//
+	err = cursor.All(ctx, &posts)
+	return
+
+}
+
+// UpdatePostPriorityByUniqueID updates the priority of a post given its channel and uniqueID.
+func UpdatePostPriorityByUniqueID(channelID int64, uniqueID string, priority int, collection *mongo.Collection) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	filter := uniqueIDFilter(channelID, uniqueID)
+	update := bson.D{
+		{
+			Key:   "$set",
+			Value: bson.D{{Key: "priority", Value: priority}},
+		},
+	}
+
+	//
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update())
+	return err
 
 }
 
@@ -293,20 +792,36 @@ func MarkPostAsPosted(post *entities.Post, messageID int, collection *mongo.Coll
 
 }
 
-// MarkPostAsFailed marks a post as failed.
-func MarkPostAsFailed(post *entities.Post, collection *mongo.Collection) error {
+// RecordFailedAttempt records a failed posting attempt.
+// If permanent is true, the post will be marked as failed and removed from the queue.
+func RecordFailedAttempt(post *entities.Post, lastError string, permanent bool, collection *mongo.Collection) error {
 
 	//
 	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
 	defer cancelCtx()
 
+	//
+	set := bson.D{
+		{Key: "lasterror", Value: lastError},
+		{Key: "lastattemptat", Value: time.Now()},
+	}
+
+	if permanent {
+		set = append(set, bson.E{Key: "haserror", Value: true})
+	}
+
 	//
 	filter := bson.M{"_id": post.ID}
 	update := bson.D{
 		{
 			Key:   "$set",
-			Value: bson.D{{Key: "haserror", Value: true}},
-		}}
+			Value: set,
+		},
+		{
+			Key:   "$inc",
+			Value: bson.D{{Key: "failedattempts", Value: 1}},
+		},
+	}
 
 	//
 	_, err := collection.UpdateOne(ctx, filter, update, options.Update())
@@ -314,15 +829,94 @@ func MarkPostAsFailed(post *entities.Post, collection *mongo.Collection) error {
 
 }
 
-// MarkPostAsDeletedByMessageID marks a post as deleted.
-func MarkPostAsDeletedByMessageID(messageID int64, collection *mongo.Collection) error {
+// GetFailedPosts retrieves the posts of a channel that could not be posted, oldest first.
+func GetFailedPosts(channelID int64, collection *mongo.Collection) (posts []entities.Post, err error) {
 
 	//
 	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
 	defer cancelCtx()
 
 	//
-	filter := bson.M{"messageid": messageID}
+	filter := bson.D{
+		{
+			Key:   "channelid",
+			Value: channelID,
+		},
+		{
+			Key:   "postedat",
+			Value: nil,
+		},
+		{
+			Key:   "haserror",
+			Value: true,
+		},
+		{
+			Key:   "removedat",
+			Value: nil,
+		},
+	}
+
+	//
+	sortingOptions := options.Find().SetSort(bson.M{"addedat": 1})
+
+	//
+	cursor, err := collection.Find(ctx, filter, sortingOptions)
+	if err != nil {
+		return nil, fmt.Errorf("GetFailedPosts: %v", err)
+	}
+
+	//
+	err = cursor.All(ctx, &posts)
+	return
+
+}
+
+// RequeueFailedPostByUniqueID puts a failed post back in the queue,
+// resetting its failed attempts.
+func RequeueFailedPostByUniqueID(channelID int64, uniqueID string, collection *mongo.Collection) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	filter := bson.M{"channelid": channelID, "media.fileuniqueid": uniqueID, "haserror": true, "removedat": nil}
+	update := bson.D{
+		{
+			Key: "$unset",
+			Value: bson.D{
+				{Key: "haserror", Value: ""},
+				{Key: "failedattempts", Value: ""},
+				{Key: "lasterror", Value: ""},
+				{Key: "lastattemptat", Value: ""},
+			},
+		},
+	}
+
+	//
+	res, err := collection.UpdateOne(ctx, filter, update, options.Update())
+	if err == nil && res.MatchedCount == 0 {
+		err = mongo.ErrNoDocuments
+	}
+
+	return err
+
+}
+
+// MarkPostAsDeletedByMessageID marks a post sent on a channel as deleted.
+func MarkPostAsDeletedByMessageID(channelID, messageID int64, collection *mongo.Collection) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	filter := bson.M{"channelid": channelID, "messageid": messageID}
 	update := bson.D{
 		{
 			Key:   "$set",
@@ -335,15 +929,148 @@ func MarkPostAsDeletedByMessageID(messageID int64, collection *mongo.Collection)
 
 }
 
+// ExportPosts calls export with every stored post, removed ones included, oldest first.
+// Exporting stops at the first error returned by export.
+func ExportPosts(export func(post entities.Post) error, collection *mongo.Collection) error {
+
+	//
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	//
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return fmt.Errorf("ExportPosts: %v", err)
+	}
+
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	//
+	for cursor.Next(ctx) {
+
+		var post entities.Post
+		err = cursor.Decode(&post)
+		if err != nil {
+			return fmt.Errorf("ExportPosts: %v", err)
+		}
+
+		err = export(post)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return cursor.Err()
+
+}
+
+// ImportPost stores a post as it was exported. If a post with the same media exists on its channel,
+// it is replaced if replace is true, otherwise the post is not imported.
+// It returns true if the post was stored.
+func ImportPost(post entities.Post, replace bool, collection *mongo.Collection) (bool, error) {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	// Removed posts conflict too, as they hold the media until they're purged
+	filter := bson.M{"channelid": post.ChannelID, "media.fileuniqueid": post.Media.FileUniqueID}
+	existing, err := collection.CountDocuments(ctx, filter, options.Count())
+	if err != nil {
+		return false, fmt.Errorf("ImportPost: %v", err)
+	}
+
+	if existing > 0 && !replace {
+		return false, nil
+	}
+
+	//
+	_, err = collection.DeleteMany(ctx, filter, options.Delete())
+	if err != nil {
+		return false, fmt.Errorf("ImportPost: %v", err)
+	}
+
+	_, err = collection.InsertOne(ctx, post)
+	if err != nil {
+		return false, fmt.Errorf("ImportPost: %v", err)
+	}
+
+	return true, nil
+
+}
+
 // ============================================================================
 
+// uniqueIDFilter returns the filter matching the post of a channel
+// with the input uniqueID, unless it was removed.
+func uniqueIDFilter(channelID int64, uniqueID string) bson.M {
+	return bson.M{"channelid": channelID, "media.fileuniqueid": uniqueID, "removedat": nil}
+}
+
+// queueFilter returns the filter matching the posts in the queue of a channel
+// the input selection policy admits.
+// Posts scheduled for a specific time and removed posts are not part of the queue.
+func queueFilter(channelID int64, policy selection.Policy) bson.D {
+	return bson.D{
+		{
+			Key:   "channelid",
+			Value: channelID,
+		},
+		{
+			Key:   "postedat",
+			Value: nil,
+		},
+		{
+			Key:   "haserror",
+			Value: nil,
+		},
+		{
+			Key:   "scheduledat",
+			Value: nil,
+		},
+		{
+			Key:   "removedat",
+			Value: nil,
+		},
+		{
+			Key:   "tags",
+			Value: tagsFilter(policy),
+		},
+	}
+}
+
+// tagsFilter returns the filter matching the tags of the posts the input selection policy admits.
+// Posts without tags have either no tags field or an empty one.
+func tagsFilter(policy selection.Policy) interface{} {
+
+	//
+	if policy.Exclusive {
+		return policy.Campaign
+	}
+
+	//
+	admitted := bson.A{nil, bson.A{}}
+	if policy.Campaign != "" {
+		admitted = append(admitted, policy.Campaign)
+	}
+
+	return bson.D{{Key: "$in", Value: admitted}}
+
+}
+
 // findBestMatch finds the best match given the input referencePHash.
+// Posts that weren't removed are preferred over removed ones,
+// which are returned only if no other post matches.
 func findBestMatch(referencePHash string, similarityThreshold int, cursor *mongo.Cursor) (post entities.Post, err error) {
 
 	defer func() {
 		_ = cursor.Close(dsCtx)
 	}()
 
+	var removed *entities.Post
 	i := 0
 	for cursor.Next(context.TODO()) {
 
@@ -355,12 +1082,29 @@ func findBestMatch(referencePHash string, similarityThreshold int, cursor *mongo
 		// it will always keep being true.
 		var res entities.Post
 		err = cursor.Decode(&res)
-		if err == nil && fpcompare.PhotoSimilarity(referencePHash, res.Media.PHash) < similarityThreshold {
-			post = res
-			log.Debugln("match in ", i, "iterations. FileID", post.Media.FileUniqueID)
-			return
+		if err != nil || fpcompare.PhotoSimilarity(referencePHash, res.Media.PHash) >= similarityThreshold {
+			continue
 		}
 
+		// Keep looking for a post that wasn't removed
+		if res.RemovedAt != nil {
+			if removed == nil {
+				removed = &res
+			}
+
+			continue
+		}
+
+		post = res
+		log.Debugln("match in ", i, "iterations. FileID", post.Media.FileUniqueID)
+		return post, nil
+
+	}
+
+	//
+	if removed != nil {
+		log.Debugln("removed match in ", i, "iterations. FileID", removed.Media.FileUniqueID)
+		return *removed, nil
 	}
 
 	err = xerrors.New("no match found")
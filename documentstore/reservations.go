@@ -0,0 +1,65 @@
+package documentstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// ReserveFeatures reserves the features of a media on a channel until release is called,
+// waiting up to storage.ReservationTimeout for the other reservations of similar media,
+// even if made by other instances of the bot.
+// The reservations expire after storage.ReservationLifetime, so that those of an instance
+// that stopped without releasing them don't last forever.
+func ReserveFeatures(channelID int64, histogram []float64, collection *mongo.Collection) (release func(), err error) {
+
+	//
+	reservations := make(map[int64]primitive.ObjectID)
+	reserve := func(bucket int64) error {
+
+		ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+		defer cancelCtx()
+
+		// The expired reservations may not have been deleted by the TTL index yet
+		now := time.Now()
+		expired := bson.M{"channelid": channelID, "bucket": bucket, "expiresat": bson.M{"$lte": now}}
+		_, err := collection.DeleteOne(ctx, expired, options.Delete())
+		if err != nil {
+			return fmt.Errorf("ReserveFeatures: %v", err)
+		}
+
+		//
+		id := primitive.NewObjectID()
+		reservation := bson.M{"_id": id, "channelid": channelID, "bucket": bucket, "expiresat": now.Add(storage.ReservationLifetime)}
+		_, err = collection.InsertOne(ctx, reservation)
+		if isDuplicateKeyError(err) {
+			return storage.ErrReserved
+		}
+
+		if err != nil {
+			return fmt.Errorf("ReserveFeatures: %v", err)
+		}
+
+		reservations[bucket] = id
+		return nil
+
+	}
+
+	// Only the reservations still held are deleted
+	unreserve := func(bucket int64) {
+
+		ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+		defer cancelCtx()
+
+		_, _ = collection.DeleteOne(ctx, bson.M{"_id": reservations[bucket]}, options.Delete())
+
+	}
+
+	return storage.Reserve(storage.FeatureBuckets(histogram), reserve, unreserve)
+
+}
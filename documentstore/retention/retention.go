@@ -0,0 +1,49 @@
+package retention
+
+import (
+	"context"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+
+	// purgeInterval is the time between two purges of the removed posts.
+	purgeInterval = time.Hour
+)
+
+// Run purges the posts removed more than retention ago,
+// right away and then every purgeInterval, until the context is cancelled.
+func Run(ctx context.Context, posts storage.PostStore, retention time.Duration) {
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+
+		Purge(posts, retention, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+	}
+
+}
+
+// Purge deletes the posts removed more than retention before now.
+func Purge(posts storage.PostStore, retention time.Duration, now time.Time) {
+
+	purged, err := posts.PurgeRemovedPosts(now.Add(-retention))
+	if err != nil {
+		log.Error("Unable to purge the removed posts: ", err)
+		return
+	}
+
+	if purged > 0 {
+		log.Info("Purged ", purged, " removed posts")
+	}
+
+}
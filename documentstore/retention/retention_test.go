@@ -0,0 +1,34 @@
+package retention
+
+import (
+	"errors"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/memorystore"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	"testing"
+	"time"
+)
+
+const (
+	testChannelID = -1001234567890
+)
+
+func TestPurge(t *testing.T) {
+
+	s := memorystore.New(0.08, 6)
+	_ = s.AddPost(1, testChannelID, entities.Media{FileUniqueID: "a"}, "")
+	_ = s.RemovePostByUniqueID(testChannelID, "a", 10)
+
+	// Posts removed within the retention period are kept
+	Purge(s, time.Hour, time.Now())
+	if _, err := s.RestoreLastRemovedPost(10); err != nil {
+		t.Fatalf("recently removed post: %v", err)
+	}
+
+	_ = s.RemovePostByUniqueID(testChannelID, "a", 10)
+	Purge(s, time.Hour, time.Now().Add(2*time.Hour))
+	if _, err := s.RestoreLastRemovedPost(10); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("purged post: got %v, want %v", err, storage.ErrNotFound)
+	}
+
+}
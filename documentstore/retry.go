@@ -0,0 +1,90 @@
+package documentstore
+
+import (
+	"context"
+	"errors"
+	"github.com/shitpostingio/autopostingbot/documentstore/health"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+	"time"
+)
+
+const (
+
+	// maxConnectionBackoff is the maximum time to wait between two connection attempts.
+	maxConnectionBackoff = 30 * time.Second
+)
+
+var (
+
+	// transientErrorLabels are the labels MongoDB attaches to the errors
+	// that may not happen again if the operation is retried.
+	transientErrorLabels = []string{"NetworkError", "TransientTransactionError", "RetryableWriteError"}
+
+	// retryAttempts is the number of attempts made for an operation failing with a transient error.
+	retryAttempts int
+
+	// retryBackoff is the time to wait before retrying an operation for the first time.
+	retryBackoff time.Duration
+)
+
+// retryTransient runs an operation, retrying it with an exponential backoff
+// while it fails with a transient error, for up to retryAttempts attempts.
+// Operations are not retried while the document store is known to be unavailable.
+func retryTransient(operation func() error) (err error) {
+
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+
+		err = operation()
+		if err == nil || attempt >= retryAttempts || !health.Available() || !isTransientError(err) {
+			return err
+		}
+
+		log.Warn("Document store operation failed, retrying in ", backoff, ": ", err)
+		time.Sleep(backoff)
+		backoff *= 2
+
+	}
+
+}
+
+// isTransientError returns true if the error is caused by
+// network issues or by the document store being unreachable.
+func isTransientError(err error) bool {
+
+	//
+	var ce mongo.CommandError
+	if errors.As(err, &ce) {
+
+		for _, label := range transientErrorLabels {
+			if ce.HasErrorLabel(label) {
+				return true
+			}
+		}
+
+		return false
+
+	}
+
+	// The driver doesn't export the server selection errors
+	return errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "server selection error")
+
+}
+
+// connectionBackoff returns the time to wait before the input connection attempt.
+func connectionBackoff(attempt int) time.Duration {
+
+	backoff := retryBackoff
+	for i := 1; i < attempt && backoff < maxConnectionBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxConnectionBackoff {
+		return maxConnectionBackoff
+	}
+
+	return backoff
+
+}
@@ -0,0 +1,45 @@
+package documentstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// SaveSchedulerState saves the state of the posting scheduler of a channel,
+// replacing the previous one.
+func SaveSchedulerState(state entities.SchedulerState, collection *mongo.Collection) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	state.UpdatedAt = time.Now()
+
+	//
+	_, err := collection.ReplaceOne(ctx, bson.M{"channelid": state.ChannelID}, state, options.Replace().SetUpsert(true))
+	if err != nil {
+		err = fmt.Errorf("SaveSchedulerState: %v", err)
+	}
+
+	return err
+
+}
+
+// GetSchedulerState retrieves the last saved state of the posting scheduler of a channel.
+func GetSchedulerState(channelID int64, collection *mongo.Collection) (state entities.SchedulerState, err error) {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	err = collection.FindOne(ctx, bson.M{"channelid": channelID}, options.FindOne()).Decode(&state)
+	return
+
+}
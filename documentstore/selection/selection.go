@@ -0,0 +1,345 @@
+package selection
+
+import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"math"
+	"strings"
+	"time"
+)
+
+// Mode represents the policy used to pick the next post from the queue.
+type Mode string
+
+const (
+
+	// FIFO posts the oldest media first.
+	FIFO Mode = "fifo"
+
+	// RoundRobin interleaves the posts of the contributors, picking the oldest
+	// media of the contributor that has been waiting the longest for a post.
+	RoundRobin Mode = "roundrobin"
+)
+
+// HistoryLength is the number of recent posts whose media type
+// is taken into account when balancing the media types.
+const HistoryLength = 20
+
+// Policy represents how the next post is picked from the queue.
+type Policy struct {
+
+	// Mode is the order the posts are picked in.
+	Mode Mode
+
+	// MaxConsecutive is the maximum number of consecutive posts
+	// of each media type. Types not in the map have no limit.
+	MaxConsecutive map[string]int
+
+	// Mix is the target share of each media type among the recent posts.
+	// The ratios are relative to their sum. Types not in the map have no target.
+	Mix map[string]float64
+
+	// Campaign is the tag of the themed queue of the campaign running on the channel, if any.
+	// Its posts are picked before the others.
+	Campaign string
+
+	// Exclusive is true if only the posts of the campaign can be picked.
+	Exclusive bool
+}
+
+// History represents what has already been posted on a channel.
+type History struct {
+
+	// LastPosted contains the time of the last post of each contributor.
+	LastPosted map[int32]time.Time
+
+	// RecentTypes contains the media types of the most recent posts, the latest first.
+	RecentTypes []string
+}
+
+// IsFIFO returns true if the posts are picked in plain queue order.
+func (p Policy) IsFIFO() bool {
+	return p.Mode != RoundRobin && !p.IsBalanced() && (p.Campaign == "" || p.Exclusive)
+}
+
+// IsBalanced returns true if the policy balances the media types.
+func (p Policy) IsBalanced() bool {
+	return len(p.MaxConsecutive) > 0 || len(p.Mix) > 0
+}
+
+// Admits returns true if the post can be picked according to the policy.
+// Tagged posts can only be picked while a campaign drawing from one of their
+// themed queues runs, untagged ones unless the campaign is exclusive.
+func (p Policy) Admits(post *entities.Post) bool {
+
+	if p.Campaign != "" && post.HasTag(p.Campaign) {
+		return true
+	}
+
+	return !p.Exclusive && len(post.Tags) == 0
+
+}
+
+// ParseMode returns the Mode with the input name.
+// An empty name stands for FIFO.
+func ParseMode(name string) (Mode, error) {
+
+	switch Mode(strings.ToLower(name)) {
+	case "", FIFO:
+		return FIFO, nil
+	case RoundRobin:
+		return RoundRobin, nil
+	}
+
+	return "", fmt.Errorf("unknown queue selection mode %s", name)
+
+}
+
+// Order returns the posts in the order they will be posted according to the policy.
+// The queue must be sorted by priority, highest first, and then by addition time.
+// The posts of the running campaign come first, then posts with a higher priority.
+func Order(queue []entities.Post, history History, policy Policy) []entities.Post {
+
+	//
+	if policy.IsFIFO() {
+		return queue
+	}
+
+	// Contributors are ranked by the order they've been served in
+	served := make(map[int32]int64, len(history.LastPosted))
+	var turn int64
+	for contributor, postTime := range history.LastPosted {
+
+		served[contributor] = postTime.UnixNano()
+		if served[contributor] > turn {
+			turn = served[contributor]
+		}
+
+	}
+
+	//
+	recent := make([]string, len(history.RecentTypes))
+	copy(recent, history.RecentTypes)
+
+	//
+	ordered := make([]entities.Post, 0, len(queue))
+	for _, part := range policy.partition(queue) {
+		for start := 0; start < len(part); {
+
+			end := start
+			for end < len(part) && part[end].Priority == part[start].Priority {
+				end++
+			}
+
+			group := part[start:end]
+			if policy.Mode == RoundRobin {
+				group = roundRobin(nil, group, served, &turn)
+			}
+
+			if policy.IsBalanced() {
+				group = balance(group, &recent, policy)
+			}
+
+			ordered = append(ordered, group...)
+			start = end
+
+		}
+	}
+
+	return ordered
+
+}
+
+// partition splits the queue in the posts of the running campaign and the others,
+// keeping their order.
+func (p Policy) partition(queue []entities.Post) [][]entities.Post {
+
+	//
+	if p.Campaign == "" {
+		return [][]entities.Post{queue}
+	}
+
+	//
+	var campaign, others []entities.Post
+	for i := range queue {
+
+		if queue[i].HasTag(p.Campaign) {
+			campaign = append(campaign, queue[i])
+		} else {
+			others = append(others, queue[i])
+		}
+
+	}
+
+	return [][]entities.Post{campaign, others}
+
+}
+
+// roundRobin appends the posts of a priority group to ordered, serving
+// one post at a time to the contributor that has been waiting the longest.
+// Ties between contributors are broken by the addition time of their posts.
+func roundRobin(ordered, group []entities.Post, served map[int32]int64, turn *int64) []entities.Post {
+
+	//
+	pending := make(map[int32][]entities.Post)
+	var contributors []int32
+	for _, post := range group {
+
+		if _, found := pending[post.AddedBy]; !found {
+			contributors = append(contributors, post.AddedBy)
+		}
+
+		pending[post.AddedBy] = append(pending[post.AddedBy], post)
+
+	}
+
+	//
+	for remaining := len(group); remaining > 0; remaining-- {
+
+		var next int32
+		found := false
+		for _, contributor := range contributors {
+
+			if len(pending[contributor]) == 0 {
+				continue
+			}
+
+			if !found || waitsLonger(contributor, next, pending, served) {
+				next = contributor
+				found = true
+			}
+
+		}
+
+		//
+		ordered = append(ordered, pending[next][0])
+		pending[next] = pending[next][1:]
+		*turn++
+		served[next] = *turn
+
+	}
+
+	return ordered
+
+}
+
+// waitsLonger returns true if contributor a should be served before contributor b.
+func waitsLonger(a, b int32, pending map[int32][]entities.Post, served map[int32]int64) bool {
+
+	if served[a] != served[b] {
+		return served[a] < served[b]
+	}
+
+	return pending[a][0].AddedAt.Before(pending[b][0].AddedAt)
+
+}
+
+// balance reorders the posts of a priority group to avoid long runs of the same
+// media type and to follow the target mix, keeping the input order where possible.
+// recent holds the media types posted before the group, and is updated with the group's.
+func balance(group []entities.Post, recent *[]string, policy Policy) []entities.Post {
+
+	//
+	remaining := make([]entities.Post, len(group))
+	copy(remaining, group)
+
+	//
+	balanced := make([]entities.Post, 0, len(group))
+	for len(remaining) > 0 {
+
+		i := policy.pick(remaining, *recent)
+		balanced = append(balanced, remaining[i])
+
+		*recent = append([]string{remaining[i].Media.Type}, *recent...)
+		if len(*recent) > HistoryLength {
+			*recent = (*recent)[:HistoryLength]
+		}
+
+		remaining = append(remaining[:i], remaining[i+1:]...)
+
+	}
+
+	return balanced
+
+}
+
+// pick returns the index of the first post that doesn't exceed the run
+// or the mix limits of its media type. If there is none, the first post
+// that doesn't exceed the run limit is picked, and then the first one overall.
+func (p Policy) pick(posts []entities.Post, recent []string) int {
+
+	fallback := -1
+	for i, post := range posts {
+
+		if p.exceedsRun(post.Media.Type, recent) {
+			continue
+		}
+
+		if fallback == -1 {
+			fallback = i
+		}
+
+		if !p.exceedsMix(post.Media.Type, recent) {
+			return i
+		}
+
+	}
+
+	if fallback == -1 {
+		return 0
+	}
+
+	return fallback
+
+}
+
+// exceedsRun returns true if posting a media of the input type
+// would exceed the maximum number of consecutive posts of the type.
+func (p Policy) exceedsRun(mediaType string, recent []string) bool {
+
+	max, found := p.MaxConsecutive[mediaType]
+	if !found || max <= 0 {
+		return false
+	}
+
+	run := 0
+	for run < len(recent) && recent[run] == mediaType {
+		run++
+	}
+
+	return run >= max
+
+}
+
+// exceedsMix returns true if posting a media of the input type
+// would bring its share of the recent posts above the target.
+func (p Policy) exceedsMix(mediaType string, recent []string) bool {
+
+	ratio, found := p.Mix[mediaType]
+	if !found {
+		return false
+	}
+
+	//
+	var total float64
+	for _, r := range p.Mix {
+		total += r
+	}
+
+	// The window includes the post being picked
+	window := recent
+	if len(window) >= HistoryLength {
+		window = window[:HistoryLength-1]
+	}
+
+	count := 1
+	for _, t := range window {
+		if t == mediaType {
+			count++
+		}
+	}
+
+	allowed := math.Ceil(ratio / total * float64(len(window)+1))
+	return float64(count) > allowed
+
+}
@@ -0,0 +1,240 @@
+package selection
+
+import (
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	start = time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+)
+
+// post creates a post added by a contributor the input amount of minutes after start.
+func post(uniqueID string, addedBy int32, priority int, minutes int) entities.Post {
+	return entities.Post{
+		AddedBy:  addedBy,
+		Media:    entities.Media{FileUniqueID: uniqueID},
+		Priority: priority,
+		AddedAt:  start.Add(time.Duration(minutes) * time.Minute),
+	}
+}
+
+// typed creates a post of the input media type added the input amount of minutes after start.
+func typed(uniqueID, mediaType string, priority int, minutes int) entities.Post {
+	p := post(uniqueID, 1, priority, minutes)
+	p.Media.Type = mediaType
+	return p
+}
+
+// ids returns the unique IDs of the posts.
+func ids(posts []entities.Post) (ids []string) {
+
+	for _, p := range posts {
+		ids = append(ids, p.Media.FileUniqueID)
+	}
+
+	return
+
+}
+
+func TestParseMode(t *testing.T) {
+
+	tests := map[string]Mode{"": FIFO, "fifo": FIFO, "RoundRobin": RoundRobin}
+	for name, want := range tests {
+
+		mode, err := ParseMode(name)
+		if err != nil || mode != want {
+			t.Errorf("ParseMode(%q): got %q, %v, want %q", name, mode, err, want)
+		}
+
+	}
+
+	if _, err := ParseMode("random"); err == nil {
+		t.Error("ParseMode accepted an unknown mode")
+	}
+
+}
+
+func TestOrder(t *testing.T) {
+
+	// Contributor 1 dumps four posts before 2 and 3 add theirs
+	queue := []entities.Post{
+		post("a1", 1, 0, 0),
+		post("a2", 1, 0, 1),
+		post("a3", 1, 0, 2),
+		post("a4", 1, 0, 3),
+		post("b1", 2, 0, 10),
+		post("c1", 3, 0, 20),
+		post("b2", 2, 0, 30),
+	}
+
+	tests := []struct {
+		name       string
+		queue      []entities.Post
+		lastPosted map[int32]time.Time
+		mode       Mode
+		want       []string
+	}{
+		{
+			name:  "fifo",
+			queue: queue,
+			mode:  FIFO,
+			want:  []string{"a1", "a2", "a3", "a4", "b1", "c1", "b2"},
+		},
+		{
+			name:  "round robin",
+			queue: queue,
+			mode:  RoundRobin,
+			want:  []string{"a1", "b1", "c1", "a2", "b2", "a3", "a4"},
+		},
+		{
+			name:       "round robin after previous posts",
+			queue:      queue,
+			lastPosted: map[int32]time.Time{1: start, 2: start.Add(-time.Hour)},
+			mode:       RoundRobin,
+			want:       []string{"c1", "b1", "a1", "b2", "a2", "a3", "a4"},
+		},
+		{
+			name: "round robin with priorities",
+			queue: []entities.Post{
+				post("a3", 1, 1, 2),
+				post("a1", 1, 0, 0),
+				post("a2", 1, 0, 1),
+				post("b1", 2, 0, 10),
+			},
+			mode: RoundRobin,
+			want: []string{"a3", "b1", "a1", "a2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			got := ids(Order(test.queue, History{LastPosted: test.lastPosted}, Policy{Mode: test.mode}))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+
+		})
+	}
+
+}
+
+func TestOrderBalanced(t *testing.T) {
+
+	videosFirst := []entities.Post{
+		typed("v1", "video", 0, 0),
+		typed("v2", "video", 0, 1),
+		typed("v3", "video", 0, 2),
+		typed("v4", "video", 0, 3),
+		typed("p1", "photo", 0, 4),
+		typed("p2", "photo", 0, 5),
+	}
+
+	tests := []struct {
+		name    string
+		queue   []entities.Post
+		history History
+		policy  Policy
+		want    []string
+	}{
+		{
+			name:   "max consecutive",
+			queue:  videosFirst,
+			policy: Policy{Mode: FIFO, MaxConsecutive: map[string]int{"video": 2}},
+			want:   []string{"v1", "v2", "p1", "v3", "v4", "p2"},
+		},
+		{
+			name:    "max consecutive after previous posts",
+			queue:   videosFirst[:5],
+			history: History{RecentTypes: []string{"video", "video"}},
+			policy:  Policy{Mode: FIFO, MaxConsecutive: map[string]int{"video": 2}},
+			want:    []string{"p1", "v1", "v2", "v3", "v4"},
+		},
+		{
+			name: "mix",
+			queue: []entities.Post{
+				typed("v1", "video", 0, 0),
+				typed("v2", "video", 0, 1),
+				typed("v3", "video", 0, 2),
+				typed("p1", "photo", 0, 3),
+				typed("p2", "photo", 0, 4),
+				typed("p3", "photo", 0, 5),
+			},
+			policy: Policy{Mode: FIFO, Mix: map[string]float64{"photo": 2, "video": 1}},
+			want:   []string{"v1", "p1", "p2", "v2", "p3", "v3"},
+		},
+		{
+			name: "priorities come first",
+			queue: []entities.Post{
+				typed("v0", "video", 1, 10),
+				typed("v1", "video", 0, 0),
+				typed("p1", "photo", 0, 1),
+			},
+			history: History{RecentTypes: []string{"video"}},
+			policy:  Policy{Mode: FIFO, MaxConsecutive: map[string]int{"video": 1}},
+			want:    []string{"v0", "p1", "v1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			got := ids(Order(test.queue, test.history, test.policy))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+
+		})
+	}
+
+}
+
+func TestOrderCampaign(t *testing.T) {
+
+	xmas := post("x1", 2, 0, 10)
+	xmas.Tags = []string{"christmas"}
+	queue := []entities.Post{
+		post("a1", 1, 1, 0),
+		post("a2", 1, 0, 1),
+		xmas,
+	}
+
+	// The campaign comes before the priorities
+	got := ids(Order(queue, History{}, Policy{Mode: FIFO, Campaign: "christmas"}))
+	want := []string{"x1", "a1", "a2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+}
+
+func TestAdmits(t *testing.T) {
+
+	untagged := post("a", 1, 0, 0)
+	xmas := post("x", 1, 0, 0)
+	xmas.Tags = []string{"winter", "christmas"}
+	halloween := post("h", 1, 0, 0)
+	halloween.Tags = []string{"halloween"}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		want   []bool
+	}{
+		{"no campaign", Policy{}, []bool{true, false, false}},
+		{"preferred campaign", Policy{Campaign: "christmas"}, []bool{true, true, false}},
+		{"exclusive campaign", Policy{Campaign: "christmas", Exclusive: true}, []bool{false, true, false}},
+	}
+
+	for _, test := range tests {
+		for i, p := range []*entities.Post{&untagged, &xmas, &halloween} {
+			if got := test.policy.Admits(p); got != test.want[i] {
+				t.Errorf("%s: Admits(%s): got %t, want %t", test.name, p.Media.FileUniqueID, got, test.want[i])
+			}
+		}
+	}
+
+}
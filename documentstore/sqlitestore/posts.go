@@ -0,0 +1,928 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	fpcompare "github.com/shitpostingio/image-fingerprinting/comparer"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+
+	// postColumns are the columns a post is read from, in the order scanPost expects.
+	postColumns = `id, addedby, channelid, media_type, media_tdlibid, media_fileuniqueid, media_fileid,
+		media_histogram, media_histogramaverage, media_histogramsum, media_phash, caption, priority, tags,
+		haserror, failedattempts, lasterror, lastattemptat, addedat, updatedat, scheduledat, postedat,
+		messageid, deletedat, captionhistory, removedat, removedby`
+
+	// queueSorting sorts the posts in queue order:
+	// highest priority first, then oldest first.
+	queueSorting = `ORDER BY priority DESC, addedat ASC`
+
+	// byUniqueID matches the post of the input channel with the input uniqueID, unless it was removed.
+	// As in the document store, only one post is affected.
+	byUniqueID = `id = (SELECT id FROM posts WHERE channelid = ? AND media_fileuniqueid = ? AND removedat IS NULL
+		ORDER BY rowid LIMIT 1)`
+)
+
+// scanner is implemented by sql.Row and sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// AddPost adds a post to the queue of a channel.
+// A removed post with the same media on the channel is replaced, while storage.ErrDuplicate
+// is returned if another post with the same media exists on the channel.
+func (s *Store) AddPost(addedBy int32, channelID int64, media entities.Media, caption string) error {
+
+	//
+	histogram, err := json.Marshal(media.Histogram)
+	if err != nil {
+		return fmt.Errorf("AddPost: %v", err)
+	}
+
+	//
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("AddPost: %v", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec(`DELETE FROM posts WHERE channelid = ? AND media_fileuniqueid = ? AND removedat IS NOT NULL`,
+		channelID, media.FileUniqueID)
+	if err != nil {
+		return fmt.Errorf("AddPost: %v", err)
+	}
+
+	//
+	_, err = tx.Exec(`INSERT INTO posts (id, addedby, channelid, media_type, media_tdlibid, media_fileuniqueid,
+		media_fileid, media_histogram, media_histogramaverage, media_histogramsum, media_phash, caption, addedat)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		primitive.NewObjectID().Hex(), addedBy, channelID, media.Type, media.TdlibID, media.FileUniqueID,
+		media.FileID, string(histogram), media.HistogramAverage, media.HistogramSum, media.PHash, caption, s.now().UnixNano())
+	if isUniqueConstraintError(err) {
+		return storage.ErrDuplicate
+	}
+
+	if err != nil {
+		return fmt.Errorf("AddPost: %v", err)
+	}
+
+	return tx.Commit()
+
+}
+
+// UpdatePostCaptionByUniqueID updates the caption of a post given its channel and uniqueID,
+// recording the previous one and the user who edited it in the caption history.
+func (s *Store) UpdatePostCaptionByUniqueID(channelID int64, uniqueID, caption string, editedBy int32) error {
+
+	//
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	//
+	post, err := scanPost(tx.QueryRow(`SELECT `+postColumns+` FROM posts WHERE `+byUniqueID, channelID, uniqueID))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && post.Caption == caption) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	//
+	now := s.now()
+	history := append(post.CaptionHistory, entities.CaptionRevision{
+		Caption:  post.Caption,
+		EditedBy: editedBy,
+		EditedAt: now,
+	})
+
+	if len(history) > entities.MaxCaptionRevisions {
+		history = history[len(history)-entities.MaxCaptionRevisions:]
+	}
+
+	encoded, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+
+	//
+	_, err = tx.Exec(`UPDATE posts SET caption = ?, updatedat = ?, captionhistory = ? WHERE id = ?`,
+		caption, now.UnixNano(), string(encoded), post.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+
+}
+
+// AddPostTagsByUniqueID adds a post to the themed queues with the input tags, given its channel and uniqueID.
+func (s *Store) AddPostTagsByUniqueID(channelID int64, uniqueID string, tags []string) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	return s.updateTags(channelID, uniqueID, func(post *entities.Post) []string {
+
+		updated := post.Tags
+		for _, tag := range tags {
+			if !post.HasTag(tag) {
+				updated = append(updated, tag)
+			}
+		}
+
+		return updated
+
+	})
+
+}
+
+// RemovePostTagsByUniqueID removes a post from the themed queues with the input tags,
+// given its channel and uniqueID. If no tag is passed, the post is removed from every themed queue.
+func (s *Store) RemovePostTagsByUniqueID(channelID int64, uniqueID string, tags []string) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	removed := entities.Post{Tags: tags}
+	return s.updateTags(channelID, uniqueID, func(post *entities.Post) (updated []string) {
+
+		for _, tag := range post.Tags {
+			if len(tags) > 0 && !removed.HasTag(tag) {
+				updated = append(updated, tag)
+			}
+		}
+
+		return
+
+	})
+
+}
+
+// ReserveFeatures reserves the features of a media on a channel until release is called,
+// waiting up to storage.ReservationTimeout for the other reservations of similar media.
+// The reservations expire after storage.ReservationLifetime, so that those of a process
+// that stopped without releasing them don't last forever.
+func (s *Store) ReserveFeatures(channelID int64, histogram []float64) (release func(), err error) {
+
+	//
+	token := primitive.NewObjectID().Hex()
+	reserve := func(bucket int64) error {
+
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("ReserveFeatures: %v", err)
+		}
+
+		defer func() {
+			_ = tx.Rollback()
+		}()
+
+		now := time.Now()
+		_, err = tx.Exec(`DELETE FROM reservations WHERE channelid = ? AND bucket = ? AND expiresat <= ?`,
+			channelID, bucket, now.UnixNano())
+		if err != nil {
+			return fmt.Errorf("ReserveFeatures: %v", err)
+		}
+
+		_, err = tx.Exec(`INSERT INTO reservations (channelid, bucket, token, expiresat) VALUES (?, ?, ?, ?)`,
+			channelID, bucket, token, now.Add(storage.ReservationLifetime).UnixNano())
+		if isUniqueConstraintError(err) {
+			return storage.ErrReserved
+		}
+
+		if err != nil {
+			return fmt.Errorf("ReserveFeatures: %v", err)
+		}
+
+		return tx.Commit()
+
+	}
+
+	// Only the reservations still held are deleted
+	unreserve := func(bucket int64) {
+		_, _ = s.db.Exec(`DELETE FROM reservations WHERE channelid = ? AND bucket = ? AND token = ?`,
+			channelID, bucket, token)
+	}
+
+	return storage.Reserve(storage.FeatureBuckets(histogram), reserve, unreserve)
+
+}
+
+// FindPostByFeatures finds a post of a channel similar to the media with the input features,
+// with the same approximations as the document store.
+// Removed posts are matched only if no other post is similar.
+func (s *Store) FindPostByFeatures(channelID int64, histogram []float64, pHash string) (entities.Post, error) {
+
+	//
+	if histogram == nil {
+		return entities.Post{}, errors.New("FindPostByFeatures: histogram was nil")
+	}
+
+	//
+	if pHash == "" {
+		return entities.Post{}, errors.New("FindPostByFeatures: pHash was empty")
+	}
+
+	//
+	average, sum := entities.GetHistogramAverageAndSum(histogram)
+	minAvg := math.Trunc(average - 1)
+	maxAvg := math.Ceil(average + 1)
+	minSum := math.Trunc(sum - (sum * s.mediaApproximation))
+	maxSum := math.Ceil(sum + (sum * s.mediaApproximation))
+
+	//
+	posts, err := s.queryPosts(`WHERE channelid = ? AND media_histogramaverage BETWEEN ? AND ?
+		AND media_histogramsum BETWEEN ? AND ? ORDER BY rowid`, channelID, minAvg, maxAvg, minSum, maxSum)
+	if err != nil {
+		return entities.Post{}, fmt.Errorf("FindPostByFeatures: unable to retrieve post: %v", err)
+	}
+
+	//
+	var removed *entities.Post
+	for i, post := range posts {
+
+		if fpcompare.PhotoSimilarity(pHash, post.Media.PHash) >= s.similarityThreshold {
+			continue
+		}
+
+		// Keep looking for a post that wasn't removed
+		if post.RemovedAt != nil {
+			if removed == nil {
+				removed = &posts[i]
+			}
+
+			continue
+		}
+
+		return post, nil
+
+	}
+
+	//
+	if removed != nil {
+		return *removed, nil
+	}
+
+	return entities.Post{}, errors.New("FindPostByFeatures: no match found")
+
+}
+
+// FindPostByUniqueID retrieves a post via its channel and uniqueID, unless it was removed.
+func (s *Store) FindPostByUniqueID(channelID int64, uniqueID string) (entities.Post, error) {
+
+	//
+	if uniqueID == "" {
+		return entities.Post{}, errors.New("uniqueID empty")
+	}
+
+	//
+	row := s.db.QueryRow(`SELECT `+postColumns+` FROM posts WHERE `+byUniqueID, channelID, uniqueID)
+	post, err := scanPost(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = storage.ErrNotFound
+	}
+
+	return post, err
+
+}
+
+// RemovePostByUniqueID removes a post via its channel and uniqueID, keeping it until it's purged
+// so that the removal can be undone.
+func (s *Store) RemovePostByUniqueID(channelID int64, uniqueID string, removedBy int32) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	_, err := s.db.Exec(`UPDATE posts SET removedat = ?, removedby = ? WHERE `+byUniqueID,
+		s.now().UnixNano(), removedBy, channelID, uniqueID)
+	return err
+
+}
+
+// RestoreLastRemovedPost restores the post removed most recently by a user.
+// storage.ErrNotFound is returned if there's none.
+func (s *Store) RestoreLastRemovedPost(removedBy int32) (entities.Post, error) {
+
+	//
+	tx, err := s.db.Begin()
+	if err != nil {
+		return entities.Post{}, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	//
+	post, err := scanPost(tx.QueryRow(`SELECT `+postColumns+` FROM posts
+		WHERE removedby = ? AND removedat IS NOT NULL ORDER BY removedat DESC LIMIT 1`, removedBy))
+	if errors.Is(err, sql.ErrNoRows) {
+		return post, storage.ErrNotFound
+	}
+
+	if err != nil {
+		return post, err
+	}
+
+	//
+	_, err = tx.Exec(`UPDATE posts SET removedat = NULL, removedby = 0 WHERE id = ?`, post.ID.Hex())
+	if err != nil {
+		return post, err
+	}
+
+	post.RemovedAt = nil
+	post.RemovedBy = 0
+	return post, tx.Commit()
+
+}
+
+// PurgeRemovedPosts deletes the posts removed before the input time,
+// returning how many were deleted.
+func (s *Store) PurgeRemovedPosts(removedBefore time.Time) (int64, error) {
+
+	res, err := s.db.Exec(`DELETE FROM posts WHERE removedat < ?`, removedBefore.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("PurgeRemovedPosts: %v", err)
+	}
+
+	return res.RowsAffected()
+
+}
+
+// GetNextPost retrieves the next post in the queue of a channel,
+// according to the input selection policy.
+func (s *Store) GetNextPost(channelID int64, policy selection.Policy) (entities.Post, error) {
+
+	//
+	if !policy.IsFIFO() {
+
+		queue, err := s.GetQueue(channelID, policy)
+		if err != nil {
+			return entities.Post{}, err
+		}
+
+		if len(queue) == 0 {
+			return entities.Post{}, storage.ErrNotFound
+		}
+
+		return queue[0], nil
+
+	}
+
+	//
+	condition, args := queueCondition(channelID, policy)
+	row := s.db.QueryRow(`SELECT `+postColumns+` FROM posts WHERE `+condition+` `+queueSorting+` LIMIT 1`, args...)
+	post, err := scanPost(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = storage.ErrNotFound
+	}
+
+	return post, err
+
+}
+
+// GetQueue retrieves the queue of a channel,
+// in the order given by the input selection policy.
+func (s *Store) GetQueue(channelID int64, policy selection.Policy) ([]entities.Post, error) {
+
+	//
+	condition, args := queueCondition(channelID, policy)
+	queue, err := s.queryPosts(`WHERE `+condition+` `+queueSorting, args...)
+	if err != nil || policy.IsFIFO() {
+		return queue, err
+	}
+
+	//
+	history, err := s.history(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return selection.Order(queue, history, policy), nil
+
+}
+
+// GetQueueLength returns the number of the posts enqueued on a channel
+// the input selection policy admits, or storage.UnknownQueueLength if they can't be counted.
+// Posts scheduled for a specific time are not part of the queue.
+func (s *Store) GetQueueLength(channelID int64, policy selection.Policy) int64 {
+
+	//
+	condition, args := queueCondition(channelID, policy)
+
+	var length int64
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM posts WHERE `+condition, args...).Scan(&length)
+	if err != nil {
+		return storage.UnknownQueueLength
+	}
+
+	return length
+
+}
+
+// GetQueuePosition returns the position of a post in the queue of its channel,
+// according to the input selection policy.
+// In FIFO mode, the posts with a higher priority and the ones
+// with the same priority added before it are counted.
+func (s *Store) GetQueuePosition(post *entities.Post, policy selection.Policy) int {
+
+	//
+	if !policy.IsFIFO() {
+
+		queue, err := s.GetQueue(post.ChannelID, policy)
+		if err != nil {
+			return -1
+		}
+
+		for i, enqueued := range queue {
+			if enqueued.ID == post.ID {
+				return i + 1
+			}
+		}
+
+		return -1
+
+	}
+
+	//
+	condition, args := queueCondition(post.ChannelID, policy)
+	args = append(args, post.Priority, post.Priority, post.AddedAt.UnixNano())
+
+	var position int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM posts WHERE `+condition+`
+		AND (priority > ? OR (priority = ? AND addedat <= ?))`, args...).Scan(&position)
+	if err != nil {
+		return -1
+	}
+
+	return position
+
+}
+
+// SchedulePostByUniqueID schedules a post for a specific time given its channel and uniqueID,
+// removing it from the queue. A nil scheduledAt puts the post back in the queue.
+func (s *Store) SchedulePostByUniqueID(channelID int64, uniqueID string, scheduledAt *time.Time) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	_, err := s.db.Exec(`UPDATE posts SET scheduledat = ? WHERE `+byUniqueID, nullableTime(scheduledAt), channelID, uniqueID)
+	return err
+
+}
+
+// GetScheduledPosts retrieves the posts scheduled for a specific time on a channel
+// that have yet to be posted, the closest first.
+func (s *Store) GetScheduledPosts(channelID int64) ([]entities.Post, error) {
+
+	posts, err := s.queryPosts(`WHERE channelid = ? AND postedat IS NULL AND haserror = 0 AND scheduledat IS NOT NULL
+		AND removedat IS NULL ORDER BY scheduledat ASC`, channelID)
+	if err != nil {
+		err = fmt.Errorf("GetScheduledPosts: %v", err)
+	}
+
+	return posts, err
+
+}
+
+// UpdatePostPriorityByUniqueID updates the priority of a post given its channel and uniqueID.
+func (s *Store) UpdatePostPriorityByUniqueID(channelID int64, uniqueID string, priority int) error {
+
+	_, err := s.db.Exec(`UPDATE posts SET priority = ? WHERE `+byUniqueID, priority, channelID, uniqueID)
+	return err
+
+}
+
+// MarkPostAsPosted marks a post as posted.
+func (s *Store) MarkPostAsPosted(post *entities.Post, messageID int) error {
+
+	_, err := s.db.Exec(`UPDATE posts SET messageid = ?, postedat = ? WHERE id = ?`,
+		messageID, s.now().UnixNano(), post.ID.Hex())
+	return err
+
+}
+
+// RecordFailedAttempt records a failed posting attempt.
+// If permanent is true, the post will be marked as failed and removed from the queue.
+func (s *Store) RecordFailedAttempt(post *entities.Post, lastError string, permanent bool) error {
+
+	_, err := s.db.Exec(`UPDATE posts SET lasterror = ?, lastattemptat = ?, failedattempts = failedattempts + 1,
+		haserror = MAX(haserror, ?) WHERE id = ?`,
+		lastError, s.now().UnixNano(), permanent, post.ID.Hex())
+	return err
+
+}
+
+// GetFailedPosts retrieves the posts of a channel that could not be posted, oldest first.
+func (s *Store) GetFailedPosts(channelID int64) ([]entities.Post, error) {
+
+	posts, err := s.queryPosts(`WHERE channelid = ? AND postedat IS NULL AND haserror = 1 AND removedat IS NULL
+		ORDER BY addedat ASC`, channelID)
+	if err != nil {
+		err = fmt.Errorf("GetFailedPosts: %v", err)
+	}
+
+	return posts, err
+
+}
+
+// RequeueFailedPostByUniqueID puts a failed post back in the queue,
+// resetting its failed attempts.
+func (s *Store) RequeueFailedPostByUniqueID(channelID int64, uniqueID string) error {
+
+	//
+	if uniqueID == "" {
+		return errors.New("uniqueID empty")
+	}
+
+	//
+	res, err := s.db.Exec(`UPDATE posts SET haserror = 0, failedattempts = 0, lasterror = '', lastattemptat = NULL
+		WHERE id = (SELECT id FROM posts WHERE channelid = ? AND media_fileuniqueid = ? AND haserror = 1
+		AND removedat IS NULL ORDER BY rowid LIMIT 1)`, channelID, uniqueID)
+	if err != nil {
+		return err
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+
+}
+
+// MarkPostAsDeletedByMessageID marks a post sent on a channel as deleted.
+func (s *Store) MarkPostAsDeletedByMessageID(channelID, messageID int64) error {
+
+	_, err := s.db.Exec(`UPDATE posts SET deletedat = ?
+		WHERE id = (SELECT id FROM posts WHERE channelid = ? AND messageid = ? ORDER BY rowid LIMIT 1)`,
+		s.now().UnixNano(), channelID, messageID)
+	return err
+
+}
+
+// ExportPosts calls export with every stored post, removed ones included, oldest first.
+// Exporting stops at the first error returned by export.
+func (s *Store) ExportPosts(export func(post entities.Post) error) error {
+
+	//
+	posts, err := s.queryPosts(`ORDER BY rowid`)
+	if err != nil {
+		return fmt.Errorf("ExportPosts: %v", err)
+	}
+
+	//
+	for _, post := range posts {
+		err = export(post)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+// ImportPost stores a post as it was exported. If a post with the same media exists on its channel,
+// it is replaced if replace is true, otherwise the post is not imported.
+// It returns true if the post was stored.
+func (s *Store) ImportPost(post entities.Post, replace bool) (bool, error) {
+
+	//
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Removed posts conflict too, as they hold the media until they're purged
+	var existing int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM posts WHERE channelid = ? AND media_fileuniqueid = ?`,
+		post.ChannelID, post.Media.FileUniqueID).Scan(&existing)
+	if err != nil {
+		return false, fmt.Errorf("ImportPost: %v", err)
+	}
+
+	if existing > 0 && !replace {
+		return false, nil
+	}
+
+	//
+	_, err = tx.Exec(`DELETE FROM posts WHERE channelid = ? AND media_fileuniqueid = ?`, post.ChannelID, post.Media.FileUniqueID)
+	if err != nil {
+		return false, fmt.Errorf("ImportPost: %v", err)
+	}
+
+	err = insertPost(tx, &post)
+	if err != nil {
+		return false, fmt.Errorf("ImportPost: %v", err)
+	}
+
+	return true, tx.Commit()
+
+}
+
+// ============================================================================
+
+// insertPost stores a post with all its fields.
+func insertPost(tx *sql.Tx, post *entities.Post) error {
+
+	//
+	histogram, err := json.Marshal(post.Media.Histogram)
+	if err != nil {
+		return err
+	}
+
+	tags, err := nullableJSON(len(post.Tags), post.Tags)
+	if err != nil {
+		return err
+	}
+
+	captionHistory, err := nullableJSON(len(post.CaptionHistory), post.CaptionHistory)
+	if err != nil {
+		return err
+	}
+
+	//
+	if post.ID.IsZero() {
+		post.ID = primitive.NewObjectID()
+	}
+
+	_, err = tx.Exec(`INSERT INTO posts (`+postColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		post.ID.Hex(), post.AddedBy, post.ChannelID, post.Media.Type, post.Media.TdlibID, post.Media.FileUniqueID,
+		post.Media.FileID, string(histogram), post.Media.HistogramAverage, post.Media.HistogramSum, post.Media.PHash,
+		post.Caption, post.Priority, tags, post.HasError, post.FailedAttempts, post.LastError,
+		nullableTime(post.LastAttemptAt), post.AddedAt.UnixNano(), nullableTime(post.UpdatedAt), nullableTime(post.ScheduledAt),
+		nullableTime(post.PostedAt), post.MessageID, nullableTime(post.DeletedAt), captionHistory,
+		nullableTime(post.RemovedAt), post.RemovedBy)
+	return err
+
+}
+
+// nullableJSON returns the value a nullable JSON array of the input length is stored as.
+func nullableJSON(length int, value interface{}) (interface{}, error) {
+
+	if length == 0 {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(encoded), nil
+
+}
+
+// queueCondition returns the condition matching the posts in the queue of a channel
+// the input selection policy admits, with its arguments.
+// Posts scheduled for a specific time and removed posts are not part of the queue.
+func queueCondition(channelID int64, policy selection.Policy) (string, []interface{}) {
+
+	//
+	condition := `channelid = ? AND postedat IS NULL AND haserror = 0 AND scheduledat IS NULL AND removedat IS NULL`
+	args := []interface{}{channelID}
+
+	//
+	hasCampaignTag := `EXISTS (SELECT 1 FROM json_each(posts.tags) WHERE value = ?)`
+	switch {
+	case policy.Exclusive:
+		condition += ` AND ` + hasCampaignTag
+		args = append(args, policy.Campaign)
+	case policy.Campaign != "":
+		condition += ` AND (NOT EXISTS (SELECT 1 FROM json_each(posts.tags)) OR ` + hasCampaignTag + `)`
+		args = append(args, policy.Campaign)
+	default:
+		condition += ` AND NOT EXISTS (SELECT 1 FROM json_each(posts.tags))`
+	}
+
+	return condition, args
+
+}
+
+// queryPosts retrieves the posts matching the input clauses.
+func (s *Store) queryPosts(clauses string, args ...interface{}) ([]entities.Post, error) {
+
+	//
+	rows, err := s.db.Query(`SELECT `+postColumns+` FROM posts `+clauses, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	//
+	var posts []entities.Post
+	for rows.Next() {
+
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+
+	}
+
+	return posts, rows.Err()
+
+}
+
+// history returns what has already been posted on a channel.
+func (s *Store) history(channelID int64) (selection.History, error) {
+
+	//
+	history := selection.History{LastPosted: make(map[int32]time.Time)}
+	rows, err := s.db.Query(`SELECT addedby, MAX(postedat) FROM posts
+		WHERE channelid = ? AND postedat IS NOT NULL GROUP BY addedby`, channelID)
+	if err != nil {
+		return history, fmt.Errorf("GetLastPostTimes: %v", err)
+	}
+
+	for rows.Next() {
+
+		var addedBy int32
+		var postedAt int64
+		err = rows.Scan(&addedBy, &postedAt)
+		if err != nil {
+			_ = rows.Close()
+			return history, fmt.Errorf("GetLastPostTimes: %v", err)
+		}
+
+		history.LastPosted[addedBy] = timeOf(postedAt)
+
+	}
+
+	_ = rows.Close()
+
+	//
+	rows, err = s.db.Query(`SELECT media_type FROM posts
+		WHERE channelid = ? AND postedat IS NOT NULL ORDER BY postedat DESC LIMIT ?`, channelID, selection.HistoryLength)
+	if err != nil {
+		return history, fmt.Errorf("GetRecentMediaTypes: %v", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+
+		var mediaType string
+		err = rows.Scan(&mediaType)
+		if err != nil {
+			return history, fmt.Errorf("GetRecentMediaTypes: %v", err)
+		}
+
+		history.RecentTypes = append(history.RecentTypes, mediaType)
+
+	}
+
+	return history, rows.Err()
+
+}
+
+// updateTags replaces the tags of the post of a channel with the input uniqueID with the ones
+// returned by update, in a transaction. Posts left without tags have none stored.
+func (s *Store) updateTags(channelID int64, uniqueID string, update func(post *entities.Post) []string) error {
+
+	//
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	//
+	post, err := scanPost(tx.QueryRow(`SELECT `+postColumns+` FROM posts WHERE `+byUniqueID, channelID, uniqueID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	//
+	var tags interface{}
+	if updated := update(&post); len(updated) > 0 {
+
+		encoded, err := json.Marshal(updated)
+		if err != nil {
+			return err
+		}
+
+		tags = string(encoded)
+
+	}
+
+	_, err = tx.Exec(`UPDATE posts SET tags = ? WHERE id = ?`, tags, post.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+
+}
+
+// scanPost reads a post selected with postColumns.
+func scanPost(row scanner) (post entities.Post, err error) {
+
+	//
+	var id string
+	var histogram, pHash, tags, captionHistory sql.NullString
+	var histogramAverage, histogramSum sql.NullFloat64
+	var lastAttemptAt, updatedAt, scheduledAt, postedAt, deletedAt, removedAt sql.NullInt64
+	var addedAt int64
+
+	err = row.Scan(&id, &post.AddedBy, &post.ChannelID, &post.Media.Type, &post.Media.TdlibID,
+		&post.Media.FileUniqueID, &post.Media.FileID, &histogram, &histogramAverage, &histogramSum, &pHash,
+		&post.Caption, &post.Priority, &tags, &post.HasError, &post.FailedAttempts, &post.LastError,
+		&lastAttemptAt, &addedAt, &updatedAt, &scheduledAt, &postedAt, &post.MessageID, &deletedAt, &captionHistory, &removedAt, &post.RemovedBy)
+	if err != nil {
+		return
+	}
+
+	//
+	post.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return
+	}
+
+	if histogram.Valid && strings.TrimSpace(histogram.String) != "null" {
+		err = json.Unmarshal([]byte(histogram.String), &post.Media.Histogram)
+		if err != nil {
+			return
+		}
+	}
+
+	if tags.Valid {
+		err = json.Unmarshal([]byte(tags.String), &post.Tags)
+		if err != nil {
+			return
+		}
+	}
+
+	if captionHistory.Valid {
+		err = json.Unmarshal([]byte(captionHistory.String), &post.CaptionHistory)
+		if err != nil {
+			return
+		}
+	}
+
+	//
+	post.Media.HistogramAverage = histogramAverage.Float64
+	post.Media.HistogramSum = histogramSum.Float64
+	post.Media.PHash = pHash.String
+	post.AddedAt = timeOf(addedAt)
+	post.LastAttemptAt = timePointerOf(lastAttemptAt)
+	post.UpdatedAt = timePointerOf(updatedAt)
+	post.ScheduledAt = timePointerOf(scheduledAt)
+	post.PostedAt = timePointerOf(postedAt)
+	post.DeletedAt = timePointerOf(deletedAt)
+	post.RemovedAt = timePointerOf(removedAt)
+	return
+
+}
@@ -0,0 +1,48 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+)
+
+// SaveSchedulerState saves the state of the posting scheduler of a channel,
+// replacing the previous one.
+func (s *Store) SaveSchedulerState(state entities.SchedulerState) error {
+
+	//
+	state.UpdatedAt = s.now()
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	//
+	_, err = s.db.Exec(`INSERT INTO scheduler (channelid, state, updatedat) VALUES (?, ?, ?)
+		ON CONFLICT (channelid) DO UPDATE SET state = excluded.state, updatedat = excluded.updatedat`,
+		state.ChannelID, string(encoded), state.UpdatedAt.UnixNano())
+	return err
+
+}
+
+// GetSchedulerState retrieves the last saved state of the posting scheduler of a channel.
+func (s *Store) GetSchedulerState(channelID int64) (state entities.SchedulerState, err error) {
+
+	//
+	var encoded string
+	err = s.db.QueryRow(`SELECT state FROM scheduler WHERE channelid = ?`, channelID).Scan(&encoded)
+	if errors.Is(err, sql.ErrNoRows) {
+		return state, storage.ErrNotFound
+	}
+
+	if err != nil {
+		return
+	}
+
+	//
+	err = json.Unmarshal([]byte(encoded), &state)
+	return
+
+}
@@ -0,0 +1,254 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"time"
+)
+
+const (
+	driverName = "sqlite"
+)
+
+// migrations bring the database created by any older version of the bot up to date.
+// The version reached is recorded as the user_version of the database:
+// the statements of version n are the n-th element, applied in a transaction.
+// New migrations must be appended.
+// Times are stored as nanoseconds since the Unix epoch,
+// the histograms, the tags and the caption histories as JSON arrays.
+var migrations = [][]string{
+	{
+		`CREATE TABLE IF NOT EXISTS posts (
+			id TEXT PRIMARY KEY,
+			addedby INTEGER NOT NULL,
+			channelid INTEGER NOT NULL,
+			media_type TEXT NOT NULL,
+			media_tdlibid INTEGER NOT NULL,
+			media_fileuniqueid TEXT NOT NULL,
+			media_fileid TEXT NOT NULL,
+			media_histogram TEXT,
+			media_histogramaverage REAL,
+			media_histogramsum REAL,
+			media_phash TEXT,
+			caption TEXT NOT NULL,
+			priority INTEGER NOT NULL DEFAULT 0,
+			tags TEXT,
+			haserror INTEGER NOT NULL DEFAULT 0,
+			failedattempts INTEGER NOT NULL DEFAULT 0,
+			lasterror TEXT NOT NULL DEFAULT '',
+			lastattemptat INTEGER,
+			addedat INTEGER NOT NULL,
+			updatedat INTEGER,
+			scheduledat INTEGER,
+			postedat INTEGER,
+			messageid INTEGER NOT NULL DEFAULT 0,
+			deletedat INTEGER
+		)`,
+		`CREATE TABLE IF NOT EXISTS users (
+			id TEXT PRIMARY KEY,
+			telegramid INTEGER NOT NULL UNIQUE,
+			targetchannelid INTEGER NOT NULL DEFAULT 0,
+			createdat INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS scheduler (
+			channelid INTEGER PRIMARY KEY,
+			state TEXT NOT NULL,
+			updatedat INTEGER NOT NULL
+		)`,
+	},
+	{
+		// Of each group of posts with the same media, the first posted is kept, otherwise the oldest
+		`DELETE FROM posts WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (
+					PARTITION BY media_fileuniqueid ORDER BY postedat IS NULL, addedat, rowid
+				) AS n FROM posts
+			) WHERE n > 1
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS posts_fileuniqueid ON posts (media_fileuniqueid)`,
+		`CREATE INDEX IF NOT EXISTS posts_queue ON posts (channelid, priority DESC, addedat)`,
+		`CREATE INDEX IF NOT EXISTS posts_histogram ON posts (media_histogramaverage, media_histogramsum)`,
+	},
+	{
+		`ALTER TABLE posts ADD COLUMN captionhistory TEXT`,
+	},
+	{
+		`ALTER TABLE posts ADD COLUMN removedat INTEGER`,
+		`ALTER TABLE posts ADD COLUMN removedby INTEGER NOT NULL DEFAULT 0`,
+	},
+	{
+		`CREATE TABLE IF NOT EXISTS duplicates (
+			id TEXT PRIMARY KEY,
+			submittedby INTEGER NOT NULL,
+			channelid INTEGER NOT NULL,
+			matchedpostid TEXT NOT NULL,
+			submittedat INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS duplicates_submissions ON duplicates (channelid, submittedat)`,
+	},
+	{
+		// The media were unique across all the channels in older versions
+		`DROP INDEX IF EXISTS posts_fileuniqueid`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS posts_channel_fileuniqueid ON posts (channelid, media_fileuniqueid)`,
+	},
+	{
+		`CREATE TABLE IF NOT EXISTS reservations (
+			channelid INTEGER NOT NULL,
+			bucket INTEGER NOT NULL,
+			token TEXT NOT NULL,
+			expiresat INTEGER NOT NULL,
+			UNIQUE (channelid, bucket)
+		)`,
+	},
+}
+
+// Store is a store of posts, users, scheduler states and statistics backed by
+// an embedded SQLite database, with the same semantics as the document store.
+type Store struct {
+	db *sql.DB
+
+	//
+	mediaApproximation  float64
+	similarityThreshold int
+
+	//
+	now func() time.Time
+}
+
+// Open opens the SQLite database at the input path, creating it if needed.
+// mediaApproximation and similarityThreshold are used by the similarity search,
+// as in the document store.
+func Open(path string, mediaApproximation float64, similarityThreshold int) (*Store, error) {
+
+	//
+	db, err := sql.Open(driverName, path)
+	if err != nil {
+		return nil, fmt.Errorf("sqlitestore.Open: %w", err)
+	}
+
+	// SQLite allows a single writer at a time
+	db.SetMaxOpenConns(1)
+
+	//
+	err = migrate(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("sqlitestore.Open: %w", err)
+	}
+
+	return &Store{
+		db:                  db,
+		mediaApproximation:  mediaApproximation,
+		similarityThreshold: similarityThreshold,
+		now:                 time.Now,
+	}, nil
+
+}
+
+// Ping checks that the database can be reached.
+func (s *Store) Ping() error {
+	return s.db.Ping()
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// ============================================================================
+
+// migrate applies the migrations the database is missing, in order.
+func migrate(db *sql.DB) error {
+
+	//
+	var version int
+	err := db.QueryRow(`PRAGMA user_version`).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve the schema version: %w", err)
+	}
+
+	//
+	for ; version < len(migrations); version++ {
+
+		err = applyMigration(db, version+1, migrations[version])
+		if err != nil {
+			return fmt.Errorf("migration to version %d failed: %w", version+1, err)
+		}
+
+	}
+
+	return nil
+
+}
+
+// applyMigration applies the statements of a migration
+// and records the version reached, in a transaction.
+func applyMigration(db *sql.DB, version int, statements []string) error {
+
+	//
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	//
+	for _, statement := range statements {
+		_, err = tx.Exec(statement)
+		if err != nil {
+			return err
+		}
+	}
+
+	// PRAGMA statements take no parameters
+	_, err = tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+
+}
+
+// nullableTime returns the value a nullable time is stored as.
+func nullableTime(t *time.Time) interface{} {
+
+	if t == nil {
+		return nil
+	}
+
+	return t.UnixNano()
+
+}
+
+// isUniqueConstraintError returns true if a statement failed
+// because it violated a unique index.
+func isUniqueConstraintError(err error) bool {
+
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+
+}
+
+// timeOf returns the time stored as the input value.
+func timeOf(nanoseconds int64) time.Time {
+	return time.Unix(0, nanoseconds).UTC()
+}
+
+// timePointerOf returns the nullable time stored as the input value.
+func timePointerOf(nanoseconds sql.NullInt64) *time.Time {
+
+	if !nanoseconds.Valid {
+		return nil
+	}
+
+	t := timeOf(nanoseconds.Int64)
+	return &t
+
+}
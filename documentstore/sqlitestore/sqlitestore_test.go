@@ -0,0 +1,129 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage/storagetest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTestStore creates a Store in a temporary directory
+// whose clock advances by a minute at every reading.
+func newTestStore(t *testing.T) storagetest.Store {
+
+	t.Helper()
+
+	s, err := Open(filepath.Join(t.TempDir(), "test.db"), storagetest.MediaApproximation, storagetest.SimilarityThreshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	now := time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+
+	return s
+
+}
+
+func TestStore(t *testing.T) {
+	storagetest.Run(t, newTestStore)
+}
+
+func TestMigrate(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "test.db")
+	for i := 0; i < 2; i++ {
+
+		s, err := Open(path, storagetest.MediaApproximation, storagetest.SimilarityThreshold)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var version int
+		_ = s.db.QueryRow(`PRAGMA user_version`).Scan(&version)
+		_ = s.Close()
+		if version != len(migrations) {
+			t.Errorf("schema version after opening %d times: got %d, want %d", i+1, version, len(migrations))
+		}
+
+	}
+
+}
+
+func TestMigrateDuplicates(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		postedAt []interface{}
+		wantKept string
+	}{
+		{"the first posted is kept", []interface{}{nil, 2, 1}, "1"},
+		{"otherwise the oldest is kept", []interface{}{nil, nil, nil}, "0"},
+	}
+
+	for _, test := range tests {
+
+		path := filepath.Join(t.TempDir(), "test.db")
+		db, err := sql.Open(driverName, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Two posts with the same media on the test channel, one on another channel
+		err = applyMigration(db, 1, migrations[0])
+		for i, channelID := range []int64{storagetest.ChannelID, storagetest.ChannelID, storagetest.ChannelID + 1} {
+			if err == nil {
+				_, err = db.Exec(`INSERT INTO posts (id, addedby, channelid, media_type, media_tdlibid, media_fileuniqueid,
+					media_fileid, caption, addedat, postedat) VALUES (?, 1, ?, 'photo', 0, 'a', 'a', '', ?, ?)`,
+					strconv.Itoa(i), channelID, i, test.postedAt[i])
+			}
+		}
+
+		_ = db.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The media were unique across all the channels before version 6
+		s, err := Open(path, storagetest.MediaApproximation, storagetest.SimilarityThreshold)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		var kept []string
+		rows, err := s.db.Query(`SELECT id FROM posts`)
+		for err == nil && rows.Next() {
+			var id string
+			err = rows.Scan(&id)
+			kept = append(kept, id)
+		}
+
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		_ = rows.Close()
+		if len(kept) != 1 || kept[0] != test.wantKept {
+			t.Errorf("%s: posts left: got %v, want [%s]", test.name, kept, test.wantKept)
+		}
+
+		// Since then, they are unique within a channel
+		if err := s.AddPost(1, storagetest.ChannelID+2, entities.Media{FileUniqueID: "a"}, ""); err != nil {
+			t.Errorf("%s: adding a media to another channel: %v", test.name, err)
+		}
+
+		_ = s.Close()
+
+	}
+
+}
@@ -0,0 +1,158 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/statistics"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"time"
+)
+
+// RecordDuplicate records the submission of a media similar to a stored one,
+// at the current time.
+func (s *Store) RecordDuplicate(duplicate entities.Duplicate) error {
+
+	_, err := s.db.Exec(`INSERT INTO duplicates (id, submittedby, channelid, matchedpostid, submittedat) VALUES (?, ?, ?, ?, ?)`,
+		primitive.NewObjectID().Hex(), duplicate.SubmittedBy, duplicate.ChannelID, duplicate.MatchedPostID.Hex(), s.now().UnixNano())
+	if err != nil {
+		err = fmt.Errorf("RecordDuplicate: %v", err)
+	}
+
+	return err
+
+}
+
+// GetStatistics aggregates the activity on a channel between since, included,
+// and until, excluded. A zero since covers the whole history.
+func (s *Store) GetStatistics(channelID int64, since, until time.Time) (statistics.Report, error) {
+
+	//
+	report := statistics.Report{
+		Since:      since,
+		Until:      until,
+		MediaTypes: make(map[string]int),
+	}
+
+	counts := statistics.Counts{}
+
+	// The zero time can't be represented in nanoseconds
+	from := int64(math.MinInt64)
+	if !since.IsZero() {
+		from = since.UnixNano()
+	}
+
+	to := until.UnixNano()
+
+	//
+	err := s.countByContributor(counts, `SELECT addedby, COUNT(*) FROM posts WHERE channelid = ? AND addedat >= ? AND addedat < ? GROUP BY addedby`,
+		channelID, from, to, func(contributor *statistics.Contributor, count int) {
+			report.Added += count
+			contributor.Added = count
+		})
+	if err != nil {
+		return report, err
+	}
+
+	err = s.countByContributor(counts, `SELECT addedby, COUNT(*) FROM posts WHERE channelid = ? AND postedat >= ? AND postedat < ? GROUP BY addedby`,
+		channelID, from, to, func(contributor *statistics.Contributor, count int) {
+			report.Posted += count
+			contributor.Posted = count
+		})
+	if err != nil {
+		return report, err
+	}
+
+	err = s.countByContributor(counts, `SELECT addedby, COUNT(*) FROM posts WHERE channelid = ? AND deletedat >= ? AND deletedat < ? GROUP BY addedby`,
+		channelID, from, to, func(contributor *statistics.Contributor, count int) {
+			report.Deleted += count
+			contributor.Deleted = count
+		})
+	if err != nil {
+		return report, err
+	}
+
+	err = s.countByContributor(counts, `SELECT submittedby, COUNT(*) FROM duplicates WHERE channelid = ? AND submittedat >= ? AND submittedat < ? GROUP BY submittedby`,
+		channelID, from, to, func(contributor *statistics.Contributor, count int) {
+			report.Duplicates += count
+			contributor.Duplicates = count
+		})
+	if err != nil {
+		return report, err
+	}
+
+	//
+	var wait sql.NullFloat64
+	var firstPostedAt sql.NullInt64
+	err = s.db.QueryRow(`SELECT AVG(postedat - addedat), MIN(postedat) FROM posts WHERE channelid = ? AND postedat >= ? AND postedat < ?`,
+		channelID, from, to).Scan(&wait, &firstPostedAt)
+	if err != nil {
+		return report, fmt.Errorf("GetStatistics: %v", err)
+	}
+
+	report.AverageQueueWait = time.Duration(wait.Float64)
+	if firstPostedAt.Valid {
+		report.FirstPostedAt = timeOf(firstPostedAt.Int64)
+	}
+
+	//
+	rows, err := s.db.Query(`SELECT media_type, COUNT(*) FROM posts WHERE channelid = ? AND postedat >= ? AND postedat < ? GROUP BY media_type`,
+		channelID, from, to)
+	if err != nil {
+		return report, fmt.Errorf("GetStatistics: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+
+		var mediaType string
+		var count int
+		err = rows.Scan(&mediaType, &count)
+		if err != nil {
+			return report, fmt.Errorf("GetStatistics: %v", err)
+		}
+
+		report.MediaTypes[mediaType] = count
+
+	}
+
+	if err = rows.Err(); err != nil {
+		return report, fmt.Errorf("GetStatistics: %v", err)
+	}
+
+	//
+	report.Contributors = counts.Sorted()
+	return report, nil
+
+}
+
+// countByContributor runs a query grouping the activity in a period by user
+// and passes each count to add, along with the activity of the user in counts.
+func (s *Store) countByContributor(counts statistics.Counts, query string, channelID, from, to int64, add func(contributor *statistics.Contributor, count int)) error {
+
+	//
+	rows, err := s.db.Query(query, channelID, from, to)
+	if err != nil {
+		return fmt.Errorf("GetStatistics: %v", err)
+	}
+
+	defer rows.Close()
+
+	//
+	for rows.Next() {
+
+		var userID int32
+		var count int
+		err = rows.Scan(&userID, &count)
+		if err != nil {
+			return fmt.Errorf("GetStatistics: %v", err)
+		}
+
+		add(counts.Get(userID), count)
+
+	}
+
+	return rows.Err()
+
+}
@@ -0,0 +1,137 @@
+package sqlitestore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+
+	// userColumns are the columns a user is read from, in the order scanUser expects.
+	userColumns = `id, telegramid, targetchannelid, createdat`
+)
+
+// AddUser adds a user to the authorized admins.
+func (s *Store) AddUser(userID int32) error {
+
+	//
+	if userID <= 0 {
+		return fmt.Errorf("AddUser: userID <= 0: %d", userID)
+	}
+
+	//
+	_, err := s.db.Exec(`INSERT INTO users (id, telegramid, createdat) VALUES (?, ?, ?)`,
+		primitive.NewObjectID().Hex(), userID, s.now().UnixNano())
+	if err != nil {
+		err = fmt.Errorf("AddUser: %v", err)
+	}
+
+	return err
+
+}
+
+// UserIsAuthorized returns true if the user can interact with the bot.
+func (s *Store) UserIsAuthorized(userID int32) bool {
+
+	//
+	if userID <= 0 {
+		return false
+	}
+
+	//
+	_, err := s.FindUserByTelegramID(userID)
+	return err == nil
+
+}
+
+// GetUsers retrieves all the authorized users.
+func (s *Store) GetUsers() ([]entities.User, error) {
+
+	//
+	rows, err := s.db.Query(`SELECT ` + userColumns + ` FROM users ORDER BY rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("GetUsers: %v", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	//
+	var users []entities.User
+	for rows.Next() {
+
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetUsers: %v", err)
+		}
+
+		users = append(users, user)
+
+	}
+
+	return users, rows.Err()
+
+}
+
+// FindUserByTelegramID retrieves an authorized user via its Telegram ID.
+func (s *Store) FindUserByTelegramID(userID int32) (entities.User, error) {
+
+	user, err := scanUser(s.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE telegramid = ?`, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		err = storage.ErrNotFound
+	}
+
+	return user, err
+
+}
+
+// UpdateUserTargetChannel updates the channel the media added by a user will be posted on.
+func (s *Store) UpdateUserTargetChannel(userID int32, channelID int64) error {
+
+	//
+	res, err := s.db.Exec(`UPDATE users SET targetchannelid = ? WHERE telegramid = ?`, channelID, userID)
+	if err != nil {
+		return err
+	}
+
+	//
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+
+}
+
+// DeleteUser deletes a user.
+func (s *Store) DeleteUser(userID int32) error {
+
+	_, err := s.db.Exec(`DELETE FROM users WHERE telegramid = ?`, userID)
+	return err
+
+}
+
+// ============================================================================
+
+// scanUser reads a user selected with userColumns.
+func scanUser(row scanner) (user entities.User, err error) {
+
+	//
+	var id string
+	var createdAt int64
+	err = row.Scan(&id, &user.TelegramID, &user.TargetChannelID, &createdAt)
+	if err != nil {
+		return
+	}
+
+	//
+	user.ID, err = primitive.ObjectIDFromHex(id)
+	user.CreatedAt = timeOf(createdAt)
+	return
+
+}
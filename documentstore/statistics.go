@@ -0,0 +1,180 @@
+package documentstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/statistics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// countResult is the number of documents of a group in an aggregation.
+type countResult struct {
+	ID    int32 `bson:"_id"`
+	Count int   `bson:"count"`
+}
+
+// RecordDuplicate records the submission of a media similar to a stored one,
+// at the current time.
+func RecordDuplicate(duplicate entities.Duplicate, collection *mongo.Collection) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	duplicate.SubmittedAt = time.Now()
+	_, err := collection.InsertOne(ctx, duplicate)
+	if err != nil {
+		err = fmt.Errorf("RecordDuplicate: %v", err)
+	}
+
+	return err
+
+}
+
+// GetStatistics aggregates the activity on a channel between since, included,
+// and until, excluded. A zero since covers the whole history.
+func GetStatistics(channelID int64, since, until time.Time, postCollection, duplicateCollection *mongo.Collection) (statistics.Report, error) {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	report := statistics.Report{
+		Since:      since,
+		Until:      until,
+		MediaTypes: make(map[string]int),
+	}
+
+	counts := statistics.Counts{}
+
+	// The activity on the posts is aggregated in a single pass, one facet per timestamp
+	period := bson.D{{Key: "$gte", Value: since}, {Key: "$lt", Value: until}}
+	countByContributor := bson.D{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: "$addedby"},
+		{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+	}}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "channelid", Value: channelID}}}},
+		{{Key: "$facet", Value: bson.D{
+			{Key: "added", Value: bson.A{
+				bson.D{{Key: "$match", Value: bson.D{{Key: "addedat", Value: period}}}},
+				countByContributor,
+			}},
+			{Key: "posted", Value: bson.A{
+				bson.D{{Key: "$match", Value: bson.D{{Key: "postedat", Value: period}}}},
+				countByContributor,
+			}},
+			{Key: "deleted", Value: bson.A{
+				bson.D{{Key: "$match", Value: bson.D{{Key: "deletedat", Value: period}}}},
+				countByContributor,
+			}},
+			{Key: "wait", Value: bson.A{
+				bson.D{{Key: "$match", Value: bson.D{{Key: "postedat", Value: period}}}},
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: nil},
+					{Key: "wait", Value: bson.D{{Key: "$avg", Value: bson.D{{Key: "$subtract", Value: bson.A{"$postedat", "$addedat"}}}}}},
+					{Key: "firstpostedat", Value: bson.D{{Key: "$min", Value: "$postedat"}}},
+				}}},
+			}},
+			{Key: "mediatypes", Value: bson.A{
+				bson.D{{Key: "$match", Value: bson.D{{Key: "postedat", Value: period}}}},
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: "$media.type"},
+					{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+				}}},
+			}},
+		}}},
+	}
+
+	cursor, err := postCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return report, fmt.Errorf("GetStatistics: %v", err)
+	}
+
+	var facets []struct {
+		Added   []countResult `bson:"added"`
+		Posted  []countResult `bson:"posted"`
+		Deleted []countResult `bson:"deleted"`
+		Wait    []struct {
+			Milliseconds  float64   `bson:"wait"`
+			FirstPostedAt time.Time `bson:"firstpostedat"`
+		} `bson:"wait"`
+		MediaTypes []struct {
+			Type  string `bson:"_id"`
+			Count int    `bson:"count"`
+		} `bson:"mediatypes"`
+	}
+
+	err = cursor.All(ctx, &facets)
+	if err != nil {
+		return report, fmt.Errorf("GetStatistics: %v", err)
+	}
+
+	//
+	if len(facets) == 1 {
+
+		for _, result := range facets[0].Added {
+			report.Added += result.Count
+			counts.Get(result.ID).Added = result.Count
+		}
+
+		for _, result := range facets[0].Posted {
+			report.Posted += result.Count
+			counts.Get(result.ID).Posted = result.Count
+		}
+
+		for _, result := range facets[0].Deleted {
+			report.Deleted += result.Count
+			counts.Get(result.ID).Deleted = result.Count
+		}
+
+		for _, result := range facets[0].MediaTypes {
+			report.MediaTypes[result.Type] = result.Count
+		}
+
+		if len(facets[0].Wait) == 1 {
+			report.AverageQueueWait = time.Duration(facets[0].Wait[0].Milliseconds * float64(time.Millisecond))
+			report.FirstPostedAt = facets[0].Wait[0].FirstPostedAt
+		}
+
+	}
+
+	// Duplicates are recorded in their own collection
+	pipeline = mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "channelid", Value: channelID},
+			{Key: "submittedat", Value: period},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$submittedby"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+
+	cursor, err = duplicateCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return report, fmt.Errorf("GetStatistics: %v", err)
+	}
+
+	var duplicates []countResult
+	err = cursor.All(ctx, &duplicates)
+	if err != nil {
+		return report, fmt.Errorf("GetStatistics: %v", err)
+	}
+
+	for _, result := range duplicates {
+		report.Duplicates += result.Count
+		counts.Get(result.ID).Duplicates = result.Count
+	}
+
+	//
+	report.Contributors = counts.Sorted()
+	return report, nil
+
+}
@@ -0,0 +1,214 @@
+package statistics
+
+import (
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"sort"
+	"time"
+)
+
+// Report represents the activity on a channel over a period.
+type Report struct {
+
+	// Since is the beginning of the period, included.
+	// It is zero if the period covers the whole history.
+	Since time.Time
+
+	// Until is the end of the period, excluded.
+	Until time.Time
+
+	// Added is the number of posts added in the period.
+	Added int
+
+	// Posted is the number of posts sent on the channel in the period.
+	Posted int
+
+	// Deleted is the number of posts deleted from the channel in the period.
+	Deleted int
+
+	// Duplicates is the number of media submitted in the period
+	// that matched a stored post.
+	Duplicates int
+
+	// AverageQueueWait is the average time between the addition
+	// and the posting of the posts sent in the period.
+	AverageQueueWait time.Duration
+
+	// FirstPostedAt is the timestamp of the first post sent in the period.
+	FirstPostedAt time.Time
+
+	// MediaTypes maps the media types to the number of posts of that type sent in the period.
+	MediaTypes map[string]int
+
+	// Contributors are the users that added, got posted or submitted duplicates in the period,
+	// the most active first.
+	Contributors []Contributor
+}
+
+// Contributor represents the activity of a user over a period.
+type Contributor struct {
+
+	// UserID is the Telegram user ID of the contributor.
+	UserID int32
+
+	// Added is the number of posts the user added in the period.
+	Added int
+
+	// Posted is the number of posts added by the user that were sent in the period.
+	Posted int
+
+	// Deleted is the number of posts added by the user that were deleted from the channel in the period.
+	Deleted int
+
+	// Duplicates is the number of media the user submitted in the period that matched a stored post.
+	Duplicates int
+}
+
+// Counts collects the activity of each contributor while a report is computed.
+type Counts map[int32]*Contributor
+
+// PostsPerDay returns the average number of posts sent each day of the period.
+// If the period covers the whole history, it starts with the first post.
+func (r *Report) PostsPerDay() float64 {
+
+	//
+	start := r.Since
+	if start.IsZero() {
+		start = r.FirstPostedAt
+	}
+
+	//
+	days := r.Until.Sub(start).Hours() / 24
+	if days < 1 {
+		days = 1
+	}
+
+	return float64(r.Posted) / days
+
+}
+
+// DuplicateRate returns the fraction of the media submitted in the period
+// that matched a stored post.
+func (r *Report) DuplicateRate() float64 {
+
+	submissions := r.Added + r.Duplicates
+	if submissions == 0 {
+		return 0
+	}
+
+	return float64(r.Duplicates) / float64(submissions)
+
+}
+
+// Get returns the activity of a contributor, adding it if it's missing.
+func (c Counts) Get(userID int32) *Contributor {
+
+	contributor, found := c[userID]
+	if !found {
+		contributor = &Contributor{UserID: userID}
+		c[userID] = contributor
+	}
+
+	return contributor
+
+}
+
+// Sorted returns the activity of the contributors, the ones who added the most posts first.
+// Ties are broken by the number of posts sent, then by user ID.
+func (c Counts) Sorted() []Contributor {
+
+	//
+	contributors := make([]Contributor, 0, len(c))
+	for _, contributor := range c {
+		contributors = append(contributors, *contributor)
+	}
+
+	//
+	sort.Slice(contributors, func(i, j int) bool {
+
+		a, b := contributors[i], contributors[j]
+		switch {
+		case a.Added != b.Added:
+			return a.Added > b.Added
+		case a.Posted != b.Posted:
+			return a.Posted > b.Posted
+		default:
+			return a.UserID < b.UserID
+		}
+
+	})
+
+	return contributors
+
+}
+
+// Aggregate computes the report of the activity on a channel between since, included,
+// and until, excluded, from its posts and the duplicates submitted for it.
+// A zero since covers the whole history.
+// It is meant for the stores that can't aggregate the activity themselves.
+func Aggregate(channelID int64, posts []entities.Post, duplicates []entities.Duplicate, since, until time.Time) Report {
+
+	//
+	report := Report{
+		Since:      since,
+		Until:      until,
+		MediaTypes: make(map[string]int),
+	}
+
+	counts := Counts{}
+	var totalWait time.Duration
+
+	//
+	for i := range posts {
+
+		post := &posts[i]
+		if post.ChannelID != channelID {
+			continue
+		}
+
+		if within(post.AddedAt, since, until) {
+			report.Added++
+			counts.Get(post.AddedBy).Added++
+		}
+
+		if post.PostedAt != nil && within(*post.PostedAt, since, until) {
+
+			report.Posted++
+			counts.Get(post.AddedBy).Posted++
+			report.MediaTypes[post.Media.Type]++
+			totalWait += post.PostedAt.Sub(post.AddedAt)
+
+			if report.FirstPostedAt.IsZero() || post.PostedAt.Before(report.FirstPostedAt) {
+				report.FirstPostedAt = *post.PostedAt
+			}
+
+		}
+
+		if post.DeletedAt != nil && within(*post.DeletedAt, since, until) {
+			report.Deleted++
+			counts.Get(post.AddedBy).Deleted++
+		}
+
+	}
+
+	//
+	for _, duplicate := range duplicates {
+		if duplicate.ChannelID == channelID && within(duplicate.SubmittedAt, since, until) {
+			report.Duplicates++
+			counts.Get(duplicate.SubmittedBy).Duplicates++
+		}
+	}
+
+	//
+	if report.Posted > 0 {
+		report.AverageQueueWait = totalWait / time.Duration(report.Posted)
+	}
+
+	report.Contributors = counts.Sorted()
+	return report
+
+}
+
+// within returns true if t is between since, included, and until, excluded.
+func within(t, since, until time.Time) bool {
+	return !t.Before(since) && t.Before(until)
+}
@@ -0,0 +1,83 @@
+package statistics
+
+import (
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"testing"
+	"time"
+)
+
+const (
+	testChannelID = -1001234567890
+)
+
+var (
+	start = time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// at returns the time the input number of hours after the start of the tests.
+func at(hours int) *time.Time {
+	t := start.Add(time.Duration(hours) * time.Hour)
+	return &t
+}
+
+func TestAggregate(t *testing.T) {
+
+	posts := []entities.Post{
+		{AddedBy: 1, ChannelID: testChannelID, Media: entities.Media{Type: "photo"}, AddedAt: *at(-24), PostedAt: at(24)},
+		{AddedBy: 1, ChannelID: testChannelID, Media: entities.Media{Type: "video"}, AddedAt: *at(1), PostedAt: at(3), DeletedAt: at(5)},
+		{AddedBy: 2, ChannelID: testChannelID, Media: entities.Media{Type: "photo"}, AddedAt: *at(2)},
+		{AddedBy: 2, ChannelID: -1009876543210, Media: entities.Media{Type: "photo"}, AddedAt: *at(2), PostedAt: at(3)},
+		{AddedBy: 3, ChannelID: testChannelID, Media: entities.Media{Type: "photo"}, AddedAt: *at(1), PostedAt: at(24 * 3)},
+	}
+
+	duplicates := []entities.Duplicate{
+		{SubmittedBy: 2, ChannelID: testChannelID, SubmittedAt: *at(4)},
+		{SubmittedBy: 3, ChannelID: testChannelID, SubmittedAt: *at(-1)},
+	}
+
+	//
+	report := Aggregate(testChannelID, posts, duplicates, start, *at(48))
+	if report.Added != 3 || report.Posted != 2 || report.Deleted != 1 || report.Duplicates != 1 {
+		t.Fatalf("report: got %+v", report)
+	}
+
+	if report.AverageQueueWait != 25*time.Hour {
+		t.Errorf("average queue wait: got %s, want 25h", report.AverageQueueWait)
+	}
+
+	if report.MediaTypes["photo"] != 1 || report.MediaTypes["video"] != 1 {
+		t.Errorf("media types: got %v", report.MediaTypes)
+	}
+
+	if rate := report.DuplicateRate(); rate != 0.25 {
+		t.Errorf("duplicate rate: got %f, want 0.25", rate)
+	}
+
+	if perDay := report.PostsPerDay(); perDay != 1 {
+		t.Errorf("posts per day: got %f, want 1", perDay)
+	}
+
+	//
+	want := []Contributor{
+		{UserID: 1, Added: 1, Posted: 2, Deleted: 1},
+		{UserID: 2, Added: 1, Duplicates: 1},
+		{UserID: 3, Added: 1},
+	}
+
+	if len(report.Contributors) != len(want) {
+		t.Fatalf("contributors: got %+v", report.Contributors)
+	}
+
+	for i := range want {
+		if report.Contributors[i] != want[i] {
+			t.Errorf("contributor %d: got %+v, want %+v", i, report.Contributors[i], want[i])
+		}
+	}
+
+	// The whole history starts with the first post
+	report = Aggregate(testChannelID, posts, duplicates, time.Time{}, *at(3 + 24*3))
+	if report.Posted != 3 || report.Duplicates != 2 || report.PostsPerDay() != 1 {
+		t.Errorf("whole history: got %d posts, %d duplicates, %f per day", report.Posted, report.Duplicates, report.PostsPerDay())
+	}
+
+}
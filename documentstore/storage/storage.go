@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"errors"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"github.com/shitpostingio/autopostingbot/documentstore/statistics"
+	"math"
+	"time"
+)
+
+var (
+
+	// ErrNotFound is returned when the requested document does not exist.
+	ErrNotFound = errors.New("document not found")
+
+	// ErrDuplicate is returned when a post with the same media already exists on a channel.
+	ErrDuplicate = errors.New("media already added")
+
+	// ErrReserved is returned by the functions reserving a feature bucket
+	// when the bucket is reserved by someone else.
+	ErrReserved = errors.New("features reserved")
+)
+
+const (
+
+	// UnknownQueueLength is returned by GetQueueLength
+	// when the posts enqueued can't be counted.
+	UnknownQueueLength = -1
+
+	// ReservationLifetime is the time after which the reservations of the features of a media expire,
+	// in case the instance of the bot holding them stopped without releasing them.
+	ReservationLifetime = time.Minute
+
+	// ReservationTimeout is the time waited for the features of a media reserved by others.
+	ReservationTimeout = 30 * time.Second
+
+	// reservationPollInterval is the interval between two attempts to reserve a feature bucket.
+	reservationPollInterval = 20 * time.Millisecond
+)
+
+// PostStore represents the operations on the posts.
+type PostStore interface {
+
+	// AddPost adds a post to the queue of a channel.
+	// A removed post with the same media on the channel is replaced, while ErrDuplicate
+	// is returned if another post with the same media exists on the channel.
+	AddPost(addedBy int32, channelID int64, media entities.Media, caption string) error
+
+	// UpdatePostCaptionByUniqueID updates the caption of a post given its channel and uniqueID,
+	// recording the previous one and the user who edited it in the caption history.
+	UpdatePostCaptionByUniqueID(channelID int64, uniqueID, caption string, editedBy int32) error
+
+	// AddPostTagsByUniqueID adds a post to the themed queues with the input tags, given its channel and uniqueID.
+	AddPostTagsByUniqueID(channelID int64, uniqueID string, tags []string) error
+
+	// RemovePostTagsByUniqueID removes a post from the themed queues with the input tags,
+	// given its channel and uniqueID. If no tag is passed, the post is removed from every themed queue.
+	RemovePostTagsByUniqueID(channelID int64, uniqueID string, tags []string) error
+
+	// ReserveFeatures reserves the features of a media on a channel until release is called,
+	// so that the duplicate checks and the addition of similar media can't run concurrently,
+	// even on other instances of the bot sharing the store.
+	ReserveFeatures(channelID int64, histogram []float64) (release func(), err error)
+
+	// FindPostByFeatures finds a post of a channel similar to the media with the input features.
+	// Removed posts are matched only if no other post is similar.
+	FindPostByFeatures(channelID int64, histogram []float64, pHash string) (entities.Post, error)
+
+	// FindPostByUniqueID retrieves a post via its channel and uniqueID, unless it was removed.
+	FindPostByUniqueID(channelID int64, uniqueID string) (entities.Post, error)
+
+	// RemovePostByUniqueID removes a post via its channel and uniqueID, keeping it until it's purged
+	// so that the removal can be undone.
+	RemovePostByUniqueID(channelID int64, uniqueID string, removedBy int32) error
+
+	// RestoreLastRemovedPost restores the post removed most recently by a user.
+	// ErrNotFound is returned if there's none.
+	RestoreLastRemovedPost(removedBy int32) (entities.Post, error)
+
+	// PurgeRemovedPosts deletes the posts removed before the input time,
+	// returning how many were deleted.
+	PurgeRemovedPosts(removedBefore time.Time) (int64, error)
+
+	// GetNextPost retrieves the next post in the queue of a channel,
+	// according to the input selection policy.
+	// ErrNotFound is returned if the queue is empty.
+	GetNextPost(channelID int64, policy selection.Policy) (entities.Post, error)
+
+	// GetQueue retrieves the queue of a channel,
+	// in the order given by the input selection policy.
+	GetQueue(channelID int64, policy selection.Policy) ([]entities.Post, error)
+
+	// GetQueueLength returns the number of the posts enqueued on a channel
+	// the input selection policy admits, or UnknownQueueLength if they can't be counted.
+	GetQueueLength(channelID int64, policy selection.Policy) int64
+
+	// GetQueuePosition returns the position of a post in the queue of its channel,
+	// according to the input selection policy.
+	GetQueuePosition(post *entities.Post, policy selection.Policy) int
+
+	// SchedulePostByUniqueID schedules a post for a specific time given its channel and uniqueID,
+	// removing it from the queue. A nil scheduledAt puts the post back in the queue.
+	SchedulePostByUniqueID(channelID int64, uniqueID string, scheduledAt *time.Time) error
+
+	// GetScheduledPosts retrieves the posts scheduled for a specific time on a channel
+	// that have yet to be posted, the closest first.
+	GetScheduledPosts(channelID int64) ([]entities.Post, error)
+
+	// UpdatePostPriorityByUniqueID updates the priority of a post given its channel and uniqueID.
+	UpdatePostPriorityByUniqueID(channelID int64, uniqueID string, priority int) error
+
+	// MarkPostAsPosted marks a post as posted.
+	MarkPostAsPosted(post *entities.Post, messageID int) error
+
+	// RecordFailedAttempt records a failed posting attempt.
+	// If permanent is true, the post will be marked as failed and removed from the queue.
+	RecordFailedAttempt(post *entities.Post, lastError string, permanent bool) error
+
+	// GetFailedPosts retrieves the posts of a channel that could not be posted, oldest first.
+	GetFailedPosts(channelID int64) ([]entities.Post, error)
+
+	// RequeueFailedPostByUniqueID puts a failed post back in the queue,
+	// resetting its failed attempts.
+	RequeueFailedPostByUniqueID(channelID int64, uniqueID string) error
+
+	// MarkPostAsDeletedByMessageID marks a post sent on a channel as deleted.
+	MarkPostAsDeletedByMessageID(channelID, messageID int64) error
+
+	// ExportPosts calls export with every stored post, removed ones included, oldest first.
+	// Exporting stops at the first error returned by export.
+	ExportPosts(export func(post entities.Post) error) error
+
+	// ImportPost stores a post as it was exported. If a post with the same media exists on its channel,
+	// it is replaced if replace is true, otherwise the post is not imported.
+	// It returns true if the post was stored.
+	ImportPost(post entities.Post, replace bool) (bool, error)
+}
+
+// UserStore represents the operations on the authorized users.
+type UserStore interface {
+
+	// AddUser adds a user to the authorized admins.
+	AddUser(userID int32) error
+
+	// UserIsAuthorized returns true if the user can interact with the bot.
+	UserIsAuthorized(userID int32) bool
+
+	// GetUsers retrieves all the authorized users.
+	GetUsers() ([]entities.User, error)
+
+	// FindUserByTelegramID retrieves an authorized user via its Telegram ID.
+	FindUserByTelegramID(userID int32) (entities.User, error)
+
+	// UpdateUserTargetChannel updates the channel the media added by a user will be posted on.
+	UpdateUserTargetChannel(userID int32, channelID int64) error
+
+	// DeleteUser deletes a user.
+	DeleteUser(userID int32) error
+}
+
+// SchedulerStore represents the operations on the state of the posting schedulers.
+type SchedulerStore interface {
+
+	// SaveSchedulerState saves the state of the posting scheduler of a channel,
+	// replacing the previous one.
+	SaveSchedulerState(state entities.SchedulerState) error
+
+	// GetSchedulerState retrieves the last saved state of the posting scheduler of a channel.
+	GetSchedulerState(channelID int64) (entities.SchedulerState, error)
+}
+
+// StatisticsStore represents the operations on the activity statistics.
+type StatisticsStore interface {
+
+	// RecordDuplicate records the submission of a media similar to a stored one,
+	// at the current time.
+	RecordDuplicate(duplicate entities.Duplicate) error
+
+	// GetStatistics aggregates the activity on a channel between since, included,
+	// and until, excluded. A zero since covers the whole history.
+	GetStatistics(channelID int64, since, until time.Time) (statistics.Report, error)
+}
+
+// ============================================================================
+
+// FeatureBuckets returns the buckets of the features of a media with the input histogram,
+// in ascending order. Similar media have histogram averages whose integer parts
+// differ by two at most, so that the buckets of two similar media always overlap.
+// Media without a histogram have no buckets, as they can't be similar to others.
+func FeatureBuckets(histogram []float64) []int64 {
+
+	if histogram == nil {
+		return nil
+	}
+
+	average, _ := entities.GetHistogramAverageAndSum(histogram)
+	bucket := int64(math.Floor(average))
+	return []int64{bucket - 1, bucket, bucket + 1}
+
+}
+
+// Reserve reserves the input buckets in order with reserve, that must return ErrReserved
+// if a bucket is reserved by someone else, waiting up to ReservationTimeout for them to release it.
+// The returned function releases the buckets with release.
+func Reserve(buckets []int64, reserve func(bucket int64) error, release func(bucket int64)) (func(), error) {
+
+	//
+	var reserved []int64
+	releaseAll := func() {
+		for _, bucket := range reserved {
+			release(bucket)
+		}
+	}
+
+	//
+	deadline := time.Now().Add(ReservationTimeout)
+	for _, bucket := range buckets {
+
+		err := reserve(bucket)
+		for errors.Is(err, ErrReserved) && time.Now().Before(deadline) {
+			time.Sleep(reservationPollInterval)
+			err = reserve(bucket)
+		}
+
+		if err != nil {
+			releaseAll()
+			return nil, err
+		}
+
+		reserved = append(reserved, bucket)
+
+	}
+
+	return releaseAll, nil
+
+}
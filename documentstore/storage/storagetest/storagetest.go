@@ -0,0 +1,558 @@
+// Package storagetest verifies that the storage backends have the same semantics,
+// running the same tests against each of them.
+package storagetest
+
+import (
+	"errors"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"github.com/shitpostingio/autopostingbot/documentstore/statistics"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+
+	// ChannelID is the channel the tests add the posts to.
+	ChannelID = -1001234567890
+
+	// MediaApproximation is the approximation factor the stores must be created with.
+	MediaApproximation = 0.08
+
+	// SimilarityThreshold is the similarity threshold the stores must be created with.
+	SimilarityThreshold = 6
+)
+
+// Store represents a storage backend.
+type Store interface {
+	storage.PostStore
+	storage.UserStore
+	storage.SchedulerStore
+	storage.StatisticsStore
+}
+
+// Factory creates an empty Store for a test,
+// with MediaApproximation and SimilarityThreshold.
+type Factory func(t *testing.T) Store
+
+// Run runs the tests against the stores created by newStore.
+func Run(t *testing.T, newStore Factory) {
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, newStore Factory)
+	}{
+		{"Queue", testQueue},
+		{"FindPostByFeatures", testFindPostByFeatures},
+		{"Users", testUsers},
+		{"DeletionAndSchedulerState", testDeletionAndSchedulerState},
+		{"CaptionHistory", testCaptionHistory},
+		{"Removal", testRemoval},
+		{"ExportImport", testExportImport},
+		{"Statistics", testStatistics},
+		{"ConcurrentAdd", testConcurrentAdd},
+		{"ConcurrentSimilarAdd", testConcurrentSimilarAdd},
+	}
+
+	for _, test := range tests {
+
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, newStore)
+		})
+
+	}
+
+}
+
+// ============================================================================
+
+// mustAdd adds a photo with the input uniqueID to the test channel.
+func mustAdd(t *testing.T, s Store, uniqueID string, media entities.Media) {
+
+	t.Helper()
+
+	media.FileUniqueID = uniqueID
+	media.Type = "photo"
+	err := s.AddPost(1, ChannelID, media, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+// elapse lets some time pass between two operations,
+// for the stores keeping times with a millisecond precision.
+func elapse() {
+	time.Sleep(2 * time.Millisecond)
+}
+
+func testQueue(t *testing.T, newStore Factory) {
+
+	s := newStore(t)
+	for _, uniqueID := range []string{"a", "b", "c", "d"} {
+		mustAdd(t, s, uniqueID, entities.Media{})
+	}
+
+	//
+	_ = s.UpdatePostPriorityByUniqueID(ChannelID, "c", entities.PriorityHigh)
+	scheduledAt := time.Now()
+	_ = s.SchedulePostByUniqueID(ChannelID, "d", &scheduledAt)
+
+	if err := s.AddPost(1, ChannelID, entities.Media{FileUniqueID: "a"}, ""); !errors.Is(err, storage.ErrDuplicate) {
+		t.Errorf("adding a media twice: got %v, want %v", err, storage.ErrDuplicate)
+	}
+
+	if err := s.AddPost(1, ChannelID+1, entities.Media{FileUniqueID: "a"}, ""); err != nil {
+		t.Errorf("adding a media to another channel: %v", err)
+	}
+
+	next, err := s.GetNextPost(ChannelID, selection.Policy{})
+	if err != nil || next.Media.FileUniqueID != "c" {
+		t.Fatalf("next post: got %s, %v, want c", next.Media.FileUniqueID, err)
+	}
+
+	if length := s.GetQueueLength(ChannelID, selection.Policy{}); length != 3 {
+		t.Errorf("queue length: got %d, want 3", length)
+	}
+
+	// Posted and failed posts leave the queue
+	_ = s.MarkPostAsPosted(&next, 42)
+	a, _ := s.FindPostByUniqueID(ChannelID, "a")
+	_ = s.RecordFailedAttempt(&a, "network unreachable", true)
+
+	b, _ := s.FindPostByUniqueID(ChannelID, "b")
+	if position := s.GetQueuePosition(&b, selection.Policy{}); position != 1 {
+		t.Errorf("queue position: got %d, want 1", position)
+	}
+
+	failed, _ := s.GetFailedPosts(ChannelID)
+	if len(failed) != 1 || failed[0].FailedAttempts != 1 {
+		t.Errorf("failed posts: got %v", failed)
+	}
+
+	if failed, _ := s.GetFailedPosts(ChannelID + 1); len(failed) != 0 {
+		t.Errorf("failed posts of another channel: got %v", failed)
+	}
+
+	// Failed posts can be put back in the queue only once
+	if err = s.RequeueFailedPostByUniqueID(ChannelID, "a"); err != nil {
+		t.Error(err)
+	}
+
+	if err = s.RequeueFailedPostByUniqueID(ChannelID, "a"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("requeueing a post twice: got %v, want %v", err, storage.ErrNotFound)
+	}
+
+	// Tagged posts are held back
+	_ = s.AddPostTagsByUniqueID(ChannelID, "a", []string{"christmas"})
+	if length := s.GetQueueLength(ChannelID, selection.Policy{}); length != 1 {
+		t.Errorf("queue length with tagged posts: got %d, want 1", length)
+	}
+
+	campaign := selection.Policy{Campaign: "christmas", Exclusive: true}
+	if next, _ = s.GetNextPost(ChannelID, campaign); next.Media.FileUniqueID != "a" {
+		t.Errorf("next post of the campaign: got %s, want a", next.Media.FileUniqueID)
+	}
+
+}
+
+func testFindPostByFeatures(t *testing.T, newStore Factory) {
+
+	s := newStore(t)
+	histogram := make([]float64, 32)
+	for i := range histogram {
+		histogram[i] = float64(i % 4)
+	}
+
+	// Media without a perceptual hash are never similar to the others
+	average, sum := entities.GetHistogramAverageAndSum(histogram)
+	mustAdd(t, s, "v", entities.Media{
+		Histogram:        histogram,
+		HistogramAverage: average,
+		HistogramSum:     sum,
+	})
+
+	_, err := s.FindPostByFeatures(ChannelID, histogram, "p:ffffffffffffffff")
+	if err == nil {
+		t.Error("a media without a perceptual hash was matched")
+	}
+
+	mustAdd(t, s, "a", entities.Media{
+		Histogram:        histogram,
+		HistogramAverage: average,
+		HistogramSum:     sum,
+		PHash:            "p:ffffffffffffffff",
+	})
+
+	//
+	match, err := s.FindPostByFeatures(ChannelID, histogram, "p:fffffffffffffff0")
+	if err != nil || match.Media.FileUniqueID != "a" {
+		t.Errorf("similar media: got %s, %v, want a", match.Media.FileUniqueID, err)
+	}
+
+	_, err = s.FindPostByFeatures(ChannelID, histogram, "p:0000000000000000")
+	if err == nil {
+		t.Error("a different media was matched")
+	}
+
+	// Removed posts are matched only if no other post is similar
+	_ = s.RemovePostByUniqueID(ChannelID, "a", 10)
+	match, err = s.FindPostByFeatures(ChannelID, histogram, "p:fffffffffffffff0")
+	if err != nil || match.Media.FileUniqueID != "a" {
+		t.Errorf("removed similar media: got %s, %v, want a", match.Media.FileUniqueID, err)
+	}
+
+	mustAdd(t, s, "b", entities.Media{
+		Histogram:        histogram,
+		HistogramAverage: average,
+		HistogramSum:     sum,
+		PHash:            "p:fffffffffffffff1",
+	})
+
+	match, err = s.FindPostByFeatures(ChannelID, histogram, "p:fffffffffffffff0")
+	if err != nil || match.Media.FileUniqueID != "b" {
+		t.Errorf("similar media after a removal: got %s, %v, want b", match.Media.FileUniqueID, err)
+	}
+
+}
+
+func testUsers(t *testing.T, newStore Factory) {
+
+	s := newStore(t)
+	if err := s.AddUser(10); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.AddUser(10); err == nil {
+		t.Error("a user was added twice")
+	}
+
+	//
+	_ = s.UpdateUserTargetChannel(10, ChannelID)
+	user, err := s.FindUserByTelegramID(10)
+	if err != nil || user.TargetChannelID != ChannelID {
+		t.Errorf("target channel: got %d, %v, want %d", user.TargetChannelID, err, ChannelID)
+	}
+
+	_ = s.DeleteUser(10)
+	if s.UserIsAuthorized(10) {
+		t.Error("deleted user is still authorized")
+	}
+
+}
+
+func testDeletionAndSchedulerState(t *testing.T, newStore Factory) {
+
+	s := newStore(t)
+	mustAdd(t, s, "a", entities.Media{})
+
+	//
+	a, _ := s.FindPostByUniqueID(ChannelID, "a")
+	_ = s.MarkPostAsPosted(&a, 42)
+	_ = s.MarkPostAsDeletedByMessageID(ChannelID, 42)
+
+	a, _ = s.FindPostByUniqueID(ChannelID, "a")
+	if a.DeletedAt == nil || a.MessageID != 42 {
+		t.Errorf("deleted post: got %v", a)
+	}
+
+	//
+	if _, err := s.GetSchedulerState(ChannelID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("missing state: got %v, want %v", err, storage.ErrNotFound)
+	}
+
+	_ = s.SaveSchedulerState(entities.SchedulerState{ChannelID: ChannelID, PausedIndefinitely: true})
+	state, err := s.GetSchedulerState(ChannelID)
+	if err != nil || !state.PausedIndefinitely {
+		t.Errorf("saved state: got %v, %v", state, err)
+	}
+
+}
+
+func testCaptionHistory(t *testing.T, newStore Factory) {
+
+	s := newStore(t)
+	mustAdd(t, s, "a", entities.Media{})
+	_ = s.UpdatePostCaptionByUniqueID(ChannelID, "a", "first", 10)
+	_ = s.UpdatePostCaptionByUniqueID(ChannelID, "a", "first", 10)
+
+	// Unchanged captions are not recorded
+	a, _ := s.FindPostByUniqueID(ChannelID, "a")
+	if len(a.CaptionHistory) != 1 || a.CaptionHistory[0].Caption != "" || a.CaptionHistory[0].EditedBy != 10 || a.UpdatedAt == nil {
+		t.Fatalf("caption history: got %v, updated at %v", a.CaptionHistory, a.UpdatedAt)
+	}
+
+	// Only the latest revisions are kept
+	for i := 0; i <= entities.MaxCaptionRevisions; i++ {
+		_ = s.UpdatePostCaptionByUniqueID(ChannelID, "a", strconv.Itoa(i), 11)
+	}
+
+	a, _ = s.FindPostByUniqueID(ChannelID, "a")
+	if len(a.CaptionHistory) != entities.MaxCaptionRevisions || a.CaptionHistory[0].Caption != "0" {
+		t.Errorf("trimmed caption history: got %v", a.CaptionHistory)
+	}
+
+}
+
+func testRemoval(t *testing.T, newStore Factory) {
+
+	s := newStore(t)
+	mustAdd(t, s, "a", entities.Media{})
+	mustAdd(t, s, "b", entities.Media{})
+	_ = s.RemovePostByUniqueID(ChannelID, "a", 10)
+	elapse()
+	_ = s.RemovePostByUniqueID(ChannelID, "b", 10)
+
+	// Removed posts leave the queue
+	if length := s.GetQueueLength(ChannelID, selection.Policy{}); length != 0 {
+		t.Errorf("queue length: got %d, want 0", length)
+	}
+
+	if _, err := s.FindPostByUniqueID(ChannelID, "a"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("removed post: got %v, want %v", err, storage.ErrNotFound)
+	}
+
+	// The last removal is undone first, by its author only
+	if _, err := s.RestoreLastRemovedPost(11); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("restoring the removal of another user: got %v, want %v", err, storage.ErrNotFound)
+	}
+
+	restored, err := s.RestoreLastRemovedPost(10)
+	if err != nil || restored.Media.FileUniqueID != "b" || restored.RemovedAt != nil {
+		t.Fatalf("restored post: got %s, %v, want b", restored.Media.FileUniqueID, err)
+	}
+
+	// Adding a removed media again replaces the removed post
+	mustAdd(t, s, "a", entities.Media{})
+	if purged, _ := s.PurgeRemovedPosts(time.Now().Add(time.Hour * 24 * 365)); purged != 0 {
+		t.Errorf("purged posts: got %d, want 0", purged)
+	}
+
+	if length := s.GetQueueLength(ChannelID, selection.Policy{}); length != 2 {
+		t.Errorf("queue length after the restoration: got %d, want 2", length)
+	}
+
+}
+
+func testExportImport(t *testing.T, newStore Factory) {
+
+	s := newStore(t)
+	mustAdd(t, s, "a", entities.Media{Histogram: []float64{1, 2}, PHash: "p:ffffffffffffffff"})
+	_ = s.UpdatePostCaptionByUniqueID(ChannelID, "a", "edited", 10)
+	_ = s.AddPostTagsByUniqueID(ChannelID, "a", []string{"cats"})
+	mustAdd(t, s, "b", entities.Media{})
+	_ = s.RemovePostByUniqueID(ChannelID, "b", 10)
+
+	var exported []entities.Post
+	err := s.ExportPosts(func(post entities.Post) error {
+		exported = append(exported, post)
+		return nil
+	})
+
+	if err != nil || len(exported) != 2 || exported[0].Media.FileUniqueID != "a" {
+		t.Fatalf("exported posts: got %d, %v", len(exported), err)
+	}
+
+	// Every field survives the import
+	destination := newStore(t)
+	for _, post := range exported {
+		if imported, err := destination.ImportPost(post, false); !imported || err != nil {
+			t.Fatalf("import of %s: got %t, %v", post.Media.FileUniqueID, imported, err)
+		}
+	}
+
+	a, err := destination.FindPostByUniqueID(ChannelID, "a")
+	if err != nil || a.ID != exported[0].ID || a.Caption != "edited" || len(a.CaptionHistory) != 1 ||
+		len(a.Tags) != 1 || a.Media.PHash != "p:ffffffffffffffff" || len(a.Media.Histogram) != 2 {
+		t.Errorf("imported post: got %+v, %v", a, err)
+	}
+
+	if _, err := destination.RestoreLastRemovedPost(10); err != nil {
+		t.Errorf("restoring the imported removed post: got %v", err)
+	}
+
+	// Conflicts are skipped unless replaced
+	if imported, err := destination.ImportPost(exported[0], false); imported || err != nil {
+		t.Errorf("conflicting import: got %t, %v", imported, err)
+	}
+
+	if imported, err := destination.ImportPost(exported[0], true); !imported || err != nil {
+		t.Errorf("replacing import: got %t, %v", imported, err)
+	}
+
+}
+
+func testStatistics(t *testing.T, newStore Factory) {
+
+	s := newStore(t)
+	mustAdd(t, s, "a", entities.Media{})
+	mustAdd(t, s, "b", entities.Media{})
+	_ = s.AddPost(2, ChannelID, entities.Media{Type: "video", FileUniqueID: "c"}, "")
+
+	elapse()
+	a, _ := s.FindPostByUniqueID(ChannelID, "a")
+	_ = s.MarkPostAsPosted(&a, 42)
+	_ = s.MarkPostAsDeletedByMessageID(ChannelID, 42)
+	_ = s.RecordDuplicate(entities.Duplicate{SubmittedBy: 2, ChannelID: ChannelID, MatchedPostID: a.ID})
+	_ = s.RecordDuplicate(entities.Duplicate{SubmittedBy: 2, ChannelID: -1009876543210, MatchedPostID: a.ID})
+
+	//
+	elapse()
+	now := time.Now()
+	report, err := s.GetStatistics(ChannelID, time.Time{}, now)
+	if err != nil || report.Added != 3 || report.Posted != 1 || report.Deleted != 1 || report.Duplicates != 1 {
+		t.Fatalf("report: got %+v, %v", report, err)
+	}
+
+	if report.AverageQueueWait <= 0 || report.MediaTypes["photo"] != 1 || report.FirstPostedAt.IsZero() {
+		t.Errorf("posted activity: got %s wait, %v types, first post %s", report.AverageQueueWait, report.MediaTypes, report.FirstPostedAt)
+	}
+
+	want := []statistics.Contributor{
+		{UserID: 1, Added: 2, Posted: 1, Deleted: 1},
+		{UserID: 2, Added: 1, Duplicates: 1},
+	}
+
+	if len(report.Contributors) != 2 || report.Contributors[0] != want[0] || report.Contributors[1] != want[1] {
+		t.Errorf("contributors: got %+v, want %+v", report.Contributors, want)
+	}
+
+	// Nothing happened after the duplicates were recorded
+	report, err = s.GetStatistics(ChannelID, now, now.Add(time.Hour))
+	if err != nil || report.Added != 0 || report.Duplicates != 0 || len(report.Contributors) != 0 {
+		t.Errorf("empty period: got %+v, %v", report, err)
+	}
+
+}
+
+func testConcurrentAdd(t *testing.T, newStore Factory) {
+
+	s := newStore(t)
+	const submissions = 8
+
+	//
+	errs := make(chan error, submissions)
+	for i := 0; i < submissions; i++ {
+		go func(addedBy int32) {
+			errs <- s.AddPost(addedBy, ChannelID, entities.Media{Type: "photo", FileUniqueID: "a"}, "")
+		}(int32(i + 1))
+	}
+
+	// Only one of the concurrent submissions is added
+	added := 0
+	for i := 0; i < submissions; i++ {
+
+		err := <-errs
+		switch {
+		case err == nil:
+			added++
+		case !errors.Is(err, storage.ErrDuplicate):
+			t.Errorf("concurrent add: got %v, want %v", err, storage.ErrDuplicate)
+		}
+
+	}
+
+	if added != 1 {
+		t.Errorf("added posts: got %d, want 1", added)
+	}
+
+}
+
+func testConcurrentSimilarAdd(t *testing.T, newStore Factory) {
+
+	s := newStore(t)
+	const submissions = 8
+
+	//
+	histogram := make([]float64, 32)
+	for i := range histogram {
+		histogram[i] = float64(i % 4)
+	}
+
+	average, sum := entities.GetHistogramAverageAndSum(histogram)
+
+	// Each submission of a similar media checks for duplicates and adds the post
+	// while holding the reservation, as the bot does
+	errs := make(chan error, submissions)
+	for i := 0; i < submissions; i++ {
+		go func(uniqueID string) {
+
+			release, err := s.ReserveFeatures(ChannelID, histogram)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			defer release()
+
+			_, err = s.FindPostByFeatures(ChannelID, histogram, "p:ffffffffffffffff")
+			if err == nil {
+				errs <- storage.ErrDuplicate
+				return
+			}
+
+			// Without the reservation, the others would check for duplicates in the meantime
+			elapse()
+			errs <- s.AddPost(1, ChannelID, entities.Media{
+				Type:             "photo",
+				FileUniqueID:     uniqueID,
+				Histogram:        histogram,
+				HistogramAverage: average,
+				HistogramSum:     sum,
+				PHash:            "p:ffffffffffffffff",
+			}, "")
+
+		}(strconv.Itoa(i))
+	}
+
+	// Only one of the concurrent submissions is added
+	added := 0
+	for i := 0; i < submissions; i++ {
+
+		err := <-errs
+		switch {
+		case err == nil:
+			added++
+		case !errors.Is(err, storage.ErrDuplicate):
+			t.Errorf("concurrent similar add: got %v, want %v", err, storage.ErrDuplicate)
+		}
+
+	}
+
+	if added != 1 {
+		t.Errorf("added posts: got %d, want 1", added)
+	}
+
+	// The reservations of a channel don't hold back the others
+	release, err := s.ReserveFeatures(ChannelID, histogram)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer release()
+
+	done := make(chan error, 1)
+	go func() {
+
+		otherRelease, err := s.ReserveFeatures(ChannelID+1, histogram)
+		if err == nil {
+			otherRelease()
+		}
+
+		done <- err
+
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("reservation on another channel: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("reservation on another channel: timed out")
+	}
+
+}
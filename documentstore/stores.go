@@ -0,0 +1,366 @@
+package documentstore
+
+import (
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"github.com/shitpostingio/autopostingbot/documentstore/statistics"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// PostStore is the post store backed by a MongoDB collection
+// and by the collection of the reserved media features.
+type PostStore struct {
+	collection          *mongo.Collection
+	reservations        *mongo.Collection
+	mediaApproximation  float64
+	similarityThreshold int
+}
+
+// UserStore is the user store backed by a MongoDB collection.
+type UserStore struct {
+	collection *mongo.Collection
+}
+
+// SchedulerStore is the scheduler state store backed by a MongoDB collection.
+type SchedulerStore struct {
+	collection *mongo.Collection
+}
+
+// StatisticsStore is the statistics store backed by the post
+// and the duplicate collections.
+type StatisticsStore struct {
+	posts      *mongo.Collection
+	duplicates *mongo.Collection
+}
+
+// NewPostStore creates a PostStore backed by the post and the reservation collections
+// of the document store. Connect must be called first.
+func NewPostStore() *PostStore {
+	return &PostStore{
+		collection:          PostCollection,
+		reservations:        ReservationCollection,
+		mediaApproximation:  MediaApproximation,
+		similarityThreshold: SimilarityThreshold,
+	}
+}
+
+// NewUserStore creates a UserStore backed by the user collection
+// of the document store. Connect must be called first.
+func NewUserStore() *UserStore {
+	return &UserStore{collection: UserCollection}
+}
+
+// NewSchedulerStore creates a SchedulerStore backed by the scheduler collection
+// of the document store. Connect must be called first.
+func NewSchedulerStore() *SchedulerStore {
+	return &SchedulerStore{collection: SchedulerCollection}
+}
+
+// NewStatisticsStore creates a StatisticsStore backed by the post and the duplicate collections
+// of the document store. Connect must be called first.
+func NewStatisticsStore() *StatisticsStore {
+	return &StatisticsStore{
+		posts:      PostCollection,
+		duplicates: DuplicateCollection,
+	}
+}
+
+// AddPost adds a post to the queue of a channel.
+// A removed post with the same media on the channel is replaced, while storage.ErrDuplicate
+// is returned if another post with the same media exists on the channel.
+func (s *PostStore) AddPost(addedBy int32, channelID int64, media entities.Media, caption string) error {
+
+	err := AddPost(addedBy, channelID, media, caption, s.collection)
+	if isDuplicateKeyError(err) {
+		err = storage.ErrDuplicate
+	}
+
+	return err
+
+}
+
+// UpdatePostCaptionByUniqueID updates the caption of a post given its channel and uniqueID,
+// recording the previous one and the user who edited it in the caption history.
+func (s *PostStore) UpdatePostCaptionByUniqueID(channelID int64, uniqueID, caption string, editedBy int32) error {
+	return UpdatePostCaptionByUniqueID(channelID, uniqueID, caption, editedBy, s.collection)
+}
+
+// AddPostTagsByUniqueID adds a post to the themed queues with the input tags, given its channel and uniqueID.
+func (s *PostStore) AddPostTagsByUniqueID(channelID int64, uniqueID string, tags []string) error {
+	return AddPostTagsByUniqueID(channelID, uniqueID, tags, s.collection)
+}
+
+// RemovePostTagsByUniqueID removes a post from the themed queues with the input tags,
+// given its channel and uniqueID. If no tag is passed, the post is removed from every themed queue.
+func (s *PostStore) RemovePostTagsByUniqueID(channelID int64, uniqueID string, tags []string) error {
+	return RemovePostTagsByUniqueID(channelID, uniqueID, tags, s.collection)
+}
+
+// ReserveFeatures reserves the features of a media on a channel until release is called,
+// waiting up to storage.ReservationTimeout for the other reservations of similar media.
+func (s *PostStore) ReserveFeatures(channelID int64, histogram []float64) (release func(), err error) {
+	return ReserveFeatures(channelID, histogram, s.reservations)
+}
+
+// FindPostByFeatures finds a post of a channel similar to the media with the input features.
+// Removed posts are matched only if no other post is similar.
+func (s *PostStore) FindPostByFeatures(channelID int64, histogram []float64, pHash string) (post entities.Post, err error) {
+
+	err = retryTransient(func() error {
+		post, err = FindPostByFeatures(channelID, histogram, pHash, s.mediaApproximation, s.similarityThreshold, s.collection)
+		return err
+	})
+
+	return post, err
+
+}
+
+// FindPostByUniqueID retrieves a post via its channel and uniqueID, unless it was removed.
+func (s *PostStore) FindPostByUniqueID(channelID int64, uniqueID string) (post entities.Post, err error) {
+
+	err = retryTransient(func() error {
+		post, err = FindPostByUniqueID(channelID, uniqueID, s.collection)
+		return err
+	})
+
+	if err == mongo.ErrNoDocuments {
+		err = storage.ErrNotFound
+	}
+
+	return post, err
+
+}
+
+// RemovePostByUniqueID removes a post via its channel and uniqueID, keeping it until it's purged
+// so that the removal can be undone.
+func (s *PostStore) RemovePostByUniqueID(channelID int64, uniqueID string, removedBy int32) error {
+	return RemovePostByUniqueID(channelID, uniqueID, removedBy, s.collection)
+}
+
+// RestoreLastRemovedPost restores the post removed most recently by a user.
+// storage.ErrNotFound is returned if there's none.
+func (s *PostStore) RestoreLastRemovedPost(removedBy int32) (entities.Post, error) {
+
+	post, err := RestoreLastRemovedPost(removedBy, s.collection)
+	if err == mongo.ErrNoDocuments {
+		err = storage.ErrNotFound
+	}
+
+	return post, err
+
+}
+
+// PurgeRemovedPosts deletes the posts removed before the input time,
+// returning how many were deleted.
+func (s *PostStore) PurgeRemovedPosts(removedBefore time.Time) (int64, error) {
+	return PurgeRemovedPosts(removedBefore, s.collection)
+}
+
+// GetNextPost retrieves the next post in the queue of a channel,
+// according to the input selection policy.
+// storage.ErrNotFound is returned if the queue is empty.
+func (s *PostStore) GetNextPost(channelID int64, policy selection.Policy) (post entities.Post, err error) {
+
+	err = retryTransient(func() error {
+		post, err = GetNextPost(channelID, policy, s.collection)
+		return err
+	})
+
+	if err == mongo.ErrNoDocuments {
+		err = storage.ErrNotFound
+	}
+
+	return post, err
+
+}
+
+// GetQueue retrieves the queue of a channel,
+// in the order given by the input selection policy.
+func (s *PostStore) GetQueue(channelID int64, policy selection.Policy) (queue []entities.Post, err error) {
+
+	err = retryTransient(func() error {
+		queue, err = GetOrderedQueue(channelID, policy, s.collection)
+		return err
+	})
+
+	return queue, err
+
+}
+
+// GetQueueLength returns the number of the posts enqueued on a channel
+// the input selection policy admits, or storage.UnknownQueueLength if they can't be counted.
+func (s *PostStore) GetQueueLength(channelID int64, policy selection.Policy) int64 {
+	return GetQueueLength(channelID, policy, s.collection)
+}
+
+// GetQueuePosition returns the position of a post in the queue of its channel,
+// according to the input selection policy.
+func (s *PostStore) GetQueuePosition(post *entities.Post, policy selection.Policy) int {
+	return GetQueuePosition(post, policy, s.collection)
+}
+
+// SchedulePostByUniqueID schedules a post for a specific time given its channel and uniqueID,
+// removing it from the queue. A nil scheduledAt puts the post back in the queue.
+func (s *PostStore) SchedulePostByUniqueID(channelID int64, uniqueID string, scheduledAt *time.Time) error {
+	return SchedulePostByUniqueID(channelID, uniqueID, scheduledAt, s.collection)
+}
+
+// GetScheduledPosts retrieves the posts scheduled for a specific time on a channel
+// that have yet to be posted, the closest first.
+func (s *PostStore) GetScheduledPosts(channelID int64) (posts []entities.Post, err error) {
+
+	err = retryTransient(func() error {
+		posts, err = GetScheduledPosts(channelID, s.collection)
+		return err
+	})
+
+	return posts, err
+
+}
+
+// UpdatePostPriorityByUniqueID updates the priority of a post given its channel and uniqueID.
+func (s *PostStore) UpdatePostPriorityByUniqueID(channelID int64, uniqueID string, priority int) error {
+	return UpdatePostPriorityByUniqueID(channelID, uniqueID, priority, s.collection)
+}
+
+// MarkPostAsPosted marks a post as posted.
+func (s *PostStore) MarkPostAsPosted(post *entities.Post, messageID int) error {
+	return MarkPostAsPosted(post, messageID, s.collection)
+}
+
+// RecordFailedAttempt records a failed posting attempt.
+// If permanent is true, the post will be marked as failed and removed from the queue.
+func (s *PostStore) RecordFailedAttempt(post *entities.Post, lastError string, permanent bool) error {
+	return RecordFailedAttempt(post, lastError, permanent, s.collection)
+}
+
+// GetFailedPosts retrieves the posts of a channel that could not be posted, oldest first.
+func (s *PostStore) GetFailedPosts(channelID int64) ([]entities.Post, error) {
+	return GetFailedPosts(channelID, s.collection)
+}
+
+// RequeueFailedPostByUniqueID puts a failed post back in the queue,
+// resetting its failed attempts.
+// storage.ErrNotFound is returned if the post didn't fail.
+func (s *PostStore) RequeueFailedPostByUniqueID(channelID int64, uniqueID string) error {
+
+	err := RequeueFailedPostByUniqueID(channelID, uniqueID, s.collection)
+	if err == mongo.ErrNoDocuments {
+		err = storage.ErrNotFound
+	}
+
+	return err
+
+}
+
+// MarkPostAsDeletedByMessageID marks a post sent on a channel as deleted.
+func (s *PostStore) MarkPostAsDeletedByMessageID(channelID, messageID int64) error {
+	return MarkPostAsDeletedByMessageID(channelID, messageID, s.collection)
+}
+
+// ExportPosts calls export with every stored post, removed ones included, oldest first.
+// Exporting stops at the first error returned by export.
+func (s *PostStore) ExportPosts(export func(post entities.Post) error) error {
+	return ExportPosts(export, s.collection)
+}
+
+// ImportPost stores a post as it was exported. If a post with the same media exists on its channel,
+// it is replaced if replace is true, otherwise the post is not imported.
+// It returns true if the post was stored.
+func (s *PostStore) ImportPost(post entities.Post, replace bool) (bool, error) {
+	return ImportPost(post, replace, s.collection)
+}
+
+// AddUser adds a user to the authorized admins.
+func (s *UserStore) AddUser(userID int32) error {
+	return AddUser(userID, s.collection)
+}
+
+// UserIsAuthorized returns true if the user can interact with the bot.
+func (s *UserStore) UserIsAuthorized(userID int32) bool {
+	return UserIsAuthorized(userID, s.collection)
+}
+
+// GetUsers retrieves all the authorized users.
+func (s *UserStore) GetUsers() (users []entities.User, err error) {
+
+	err = retryTransient(func() error {
+		users, err = GetUsers(s.collection)
+		return err
+	})
+
+	return users, err
+
+}
+
+// FindUserByTelegramID retrieves an authorized user via its Telegram ID.
+func (s *UserStore) FindUserByTelegramID(userID int32) (entities.User, error) {
+
+	user, err := FindUserByTelegramID(userID, s.collection)
+	if err == mongo.ErrNoDocuments {
+		err = storage.ErrNotFound
+	}
+
+	return user, err
+
+}
+
+// UpdateUserTargetChannel updates the channel the media added by a user will be posted on.
+func (s *UserStore) UpdateUserTargetChannel(userID int32, channelID int64) error {
+
+	err := UpdateUserTargetChannel(userID, channelID, s.collection)
+	if err == mongo.ErrNoDocuments {
+		err = storage.ErrNotFound
+	}
+
+	return err
+
+}
+
+// DeleteUser deletes a user.
+func (s *UserStore) DeleteUser(userID int32) error {
+	return DeleteUser(userID, s.collection)
+}
+
+// SaveSchedulerState saves the state of the posting scheduler of a channel,
+// replacing the previous one.
+func (s *SchedulerStore) SaveSchedulerState(state entities.SchedulerState) error {
+	return SaveSchedulerState(state, s.collection)
+}
+
+// GetSchedulerState retrieves the last saved state of the posting scheduler of a channel.
+func (s *SchedulerStore) GetSchedulerState(channelID int64) (state entities.SchedulerState, err error) {
+
+	err = retryTransient(func() error {
+		state, err = GetSchedulerState(channelID, s.collection)
+		return err
+	})
+
+	if err == mongo.ErrNoDocuments {
+		err = storage.ErrNotFound
+	}
+
+	return state, err
+
+}
+
+// RecordDuplicate records the submission of a media similar to a stored one,
+// at the current time.
+func (s *StatisticsStore) RecordDuplicate(duplicate entities.Duplicate) error {
+	return RecordDuplicate(duplicate, s.duplicates)
+}
+
+// GetStatistics aggregates the activity on a channel between since, included,
+// and until, excluded. A zero since covers the whole history.
+func (s *StatisticsStore) GetStatistics(channelID int64, since, until time.Time) (report statistics.Report, err error) {
+
+	err = retryTransient(func() error {
+		report, err = GetStatistics(channelID, since, until, s.posts, s.duplicates)
+		return err
+	})
+
+	return report, err
+
+}
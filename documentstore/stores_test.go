@@ -0,0 +1,77 @@
+package documentstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage/storagetest"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"testing"
+	"time"
+)
+
+const (
+
+	// testURIVariable is the environment variable with the URI of the MongoDB server
+	// the tests run against. The tests are skipped if it's not set.
+	testURIVariable = "AUTOPOSTINGBOT_TEST_MONGODB_URI"
+)
+
+// testStore is a storage backend made of the stores backed by a test database.
+type testStore struct {
+	*PostStore
+	*UserStore
+	*SchedulerStore
+	*StatisticsStore
+}
+
+func TestStores(t *testing.T) {
+
+	uri := os.Getenv(testURIVariable)
+	if uri == "" {
+		t.Skip(testURIVariable, " not set")
+	}
+
+	//
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = client.Disconnect(context.Background())
+	}()
+
+	// Every test gets its own database, dropped at the end
+	storagetest.Run(t, func(t *testing.T) storagetest.Store {
+
+		db := client.Database(fmt.Sprintf("autopostingbot_test_%d", time.Now().UnixNano()))
+		t.Cleanup(func() {
+			_ = db.Drop(context.Background())
+		})
+
+		err := migrate(db, storagetest.ChannelID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		posts := db.Collection(postCollectionName)
+		return testStore{
+			PostStore: &PostStore{
+				collection:          posts,
+				reservations:        db.Collection(reservationCollectionName),
+				mediaApproximation:  storagetest.MediaApproximation,
+				similarityThreshold: storagetest.SimilarityThreshold,
+			},
+			UserStore:      &UserStore{collection: db.Collection(userCollectionName)},
+			SchedulerStore: &SchedulerStore{collection: db.Collection(schedulerCollectionName)},
+			StatisticsStore: &StatisticsStore{
+				posts:      posts,
+				duplicates: db.Collection(duplicateCollectionName),
+			},
+		}
+
+	})
+
+}
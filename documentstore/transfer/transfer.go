@@ -0,0 +1,201 @@
+package transfer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	"io"
+	"strings"
+)
+
+const (
+	postRecord = "post"
+	userRecord = "user"
+
+	// maxLineLength is the maximum length of a line of an export.
+	maxLineLength = 16 << 20
+)
+
+// record is a line of an export: either a post or a user.
+type record struct {
+	Type string         `json:"type"`
+	Post *entities.Post `json:"post,omitempty"`
+	User *entities.User `json:"user,omitempty"`
+}
+
+// Summary represents the outcome of an export or an import.
+type Summary struct {
+
+	// Posts is the number of posts exported or imported.
+	Posts int
+
+	// Users is the number of users exported or imported.
+	Users int
+
+	// SkippedPosts is the number of posts not imported
+	// because a post with the same media already existed.
+	SkippedPosts int
+
+	// SkippedUsers is the number of users not imported
+	// because they were already authorized.
+	SkippedUsers int
+}
+
+// ImportOptions represents how an export is imported.
+type ImportOptions struct {
+
+	// Replace tells whether the posts with the same media as an imported one
+	// are replaced. Otherwise, the imported post is skipped.
+	Replace bool
+
+	// ChannelID is the channel the imported posts are moved to.
+	// If zero, the posts keep their channel.
+	ChannelID int64
+}
+
+// Export writes the users and the posts to w as newline delimited JSON,
+// one record per line, with the media fingerprints, the captions and the timestamps.
+// If channelID is not zero, only the posts of that channel are exported.
+func Export(w io.Writer, posts storage.PostStore, users storage.UserStore, channelID int64) (summary Summary, err error) {
+
+	//
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	//
+	authorized, err := users.GetUsers()
+	if err != nil {
+		return summary, fmt.Errorf("unable to retrieve the users: %v", err)
+	}
+
+	for i := range authorized {
+
+		err = encoder.Encode(record{Type: userRecord, User: &authorized[i]})
+		if err != nil {
+			return summary, err
+		}
+
+		summary.Users++
+
+	}
+
+	//
+	err = posts.ExportPosts(func(post entities.Post) error {
+
+		if channelID != 0 && post.ChannelID != channelID {
+			return nil
+		}
+
+		err := encoder.Encode(record{Type: postRecord, Post: &post})
+		if err == nil {
+			summary.Posts++
+		}
+
+		return err
+
+	})
+
+	return summary, err
+
+}
+
+// Import reads an export from r and stores its records.
+// Posts conflict with the stored ones with the same media, users with the authorized ones
+// with the same Telegram ID.
+func Import(r io.Reader, posts storage.PostStore, users storage.UserStore, options ImportOptions) (summary Summary, err error) {
+
+	//
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineLength)
+
+	for line := 1; scanner.Scan(); line++ {
+
+		//
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var rec record
+		err = json.Unmarshal([]byte(text), &rec)
+		if err != nil {
+			return summary, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		//
+		switch {
+		case rec.Type == postRecord && rec.Post != nil:
+			err = importPost(*rec.Post, posts, options, &summary)
+		case rec.Type == userRecord && rec.User != nil:
+			err = importUser(*rec.User, users, &summary)
+		default:
+			err = fmt.Errorf("unknown record type %q", rec.Type)
+		}
+
+		if err != nil {
+			return summary, fmt.Errorf("line %d: %v", line, err)
+		}
+
+	}
+
+	return summary, scanner.Err()
+
+}
+
+// importPost stores an exported post.
+func importPost(post entities.Post, posts storage.PostStore, options ImportOptions, summary *Summary) error {
+
+	//
+	if post.Media.FileUniqueID == "" {
+		return errors.New("post without a file unique ID")
+	}
+
+	if options.ChannelID != 0 {
+		post.ChannelID = options.ChannelID
+	}
+
+	//
+	imported, err := posts.ImportPost(post, options.Replace)
+	if err != nil {
+		return err
+	}
+
+	if imported {
+		summary.Posts++
+	} else {
+		summary.SkippedPosts++
+	}
+
+	return nil
+
+}
+
+// importUser authorizes an exported user, with its target channel.
+func importUser(user entities.User, users storage.UserStore, summary *Summary) error {
+
+	//
+	if users.UserIsAuthorized(user.TelegramID) {
+		summary.SkippedUsers++
+		return nil
+	}
+
+	//
+	err := users.AddUser(user.TelegramID)
+	if err != nil {
+		return err
+	}
+
+	if user.TargetChannelID != 0 {
+		err = users.UpdateUserTargetChannel(user.TelegramID, user.TargetChannelID)
+		if err != nil {
+			return err
+		}
+	}
+
+	summary.Users++
+	return nil
+
+}
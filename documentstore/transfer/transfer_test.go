@@ -0,0 +1,88 @@
+package transfer
+
+import (
+	"bytes"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/memorystore"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"strings"
+	"testing"
+)
+
+const (
+	sourceChannelID = -1001234567890
+	targetChannelID = -1009876543210
+)
+
+func TestExportImport(t *testing.T) {
+
+	//
+	source := memorystore.New(0.08, 6)
+	_ = source.AddUser(10)
+	_ = source.UpdateUserTargetChannel(10, sourceChannelID)
+	for _, uniqueID := range []string{"a", "b"} {
+		_ = source.AddPost(10, sourceChannelID, entities.Media{
+			Type:         "photo",
+			FileUniqueID: uniqueID,
+			Histogram:    []float64{1, 2, 3},
+			PHash:        "p:ffffffffffffffff",
+		}, "<b>caption</b> "+uniqueID)
+	}
+
+	_ = source.UpdatePostCaptionByUniqueID(sourceChannelID, "a", "edited", 10)
+	_ = source.AddPost(10, targetChannelID, entities.Media{FileUniqueID: "c"}, "")
+
+	var export bytes.Buffer
+	summary, err := Export(&export, source, source, sourceChannelID)
+	if err != nil || summary.Posts != 2 || summary.Users != 1 {
+		t.Fatalf("export: got %+v, %v", summary, err)
+	}
+
+	if lines := strings.Count(export.String(), "\n"); lines != 3 {
+		t.Errorf("export lines: got %d, want 3", lines)
+	}
+
+	// The post already in the destination is skipped
+	destination := memorystore.New(0.08, 6)
+	_ = destination.AddPost(11, targetChannelID, entities.Media{FileUniqueID: "b"}, "existing")
+
+	summary, err = Import(bytes.NewReader(export.Bytes()), destination, destination, ImportOptions{ChannelID: targetChannelID})
+	if err != nil || summary.Posts != 1 || summary.SkippedPosts != 1 || summary.Users != 1 {
+		t.Fatalf("import: got %+v, %v", summary, err)
+	}
+
+	a, err := destination.FindPostByUniqueID(targetChannelID, "a")
+	if err != nil || a.Caption != "edited" || len(a.CaptionHistory) != 1 || a.Media.PHash != "p:ffffffffffffffff" || a.ChannelID != targetChannelID {
+		t.Errorf("imported post: got %+v, %v", a, err)
+	}
+
+	user, _ := destination.FindUserByTelegramID(10)
+	if user.TargetChannelID != sourceChannelID {
+		t.Errorf("imported user target channel: got %d, want %d", user.TargetChannelID, sourceChannelID)
+	}
+
+	// Replacing overwrites the conflicting post
+	summary, err = Import(bytes.NewReader(export.Bytes()), destination, destination, ImportOptions{ChannelID: targetChannelID, Replace: true})
+	if err != nil || summary.Posts != 2 || summary.SkippedUsers != 1 {
+		t.Fatalf("import with replacement: got %+v, %v", summary, err)
+	}
+
+	if b, _ := destination.FindPostByUniqueID(targetChannelID, "b"); b.Caption != "<b>caption</b> b" {
+		t.Errorf("replaced post caption: got %q", b.Caption)
+	}
+
+	if length := destination.GetQueueLength(targetChannelID, selection.Policy{}); length != 2 {
+		t.Errorf("queue length: got %d, want 2", length)
+	}
+
+}
+
+func TestImportInvalidRecord(t *testing.T) {
+
+	s := memorystore.New(0.08, 6)
+	_, err := Import(strings.NewReader("\n{\"type\":\"comment\"}\n"), s, s, ImportOptions{})
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2") {
+		t.Errorf("invalid record: got %v", err)
+	}
+
+}
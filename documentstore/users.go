@@ -57,14 +57,61 @@ func UserIsAuthorized(userID int32, collection *mongo.Collection) bool {
 }
 
 // GetUsers retrieves all the authorized users from the database.
-func GetUsers(collection *mongo.Collection) (*mongo.Cursor, error) {
+func GetUsers(collection *mongo.Collection) (users []entities.User, err error) {
 
 	//
 	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
 	defer cancelCtx()
 
 	//
-	return collection.Find(ctx, bson.M{}, options.Find())
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find())
+	if err != nil {
+		return nil, fmt.Errorf("GetUsers: %v", err)
+	}
+
+	//
+	err = cursor.All(ctx, &users)
+	return
+
+}
+
+// FindUserByTelegramID retrieves an authorized user via its Telegram ID.
+func FindUserByTelegramID(userID int32, collection *mongo.Collection) (user entities.User, err error) {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	filter := bson.M{"telegramid": userID}
+	err = collection.FindOne(ctx, filter, options.FindOne()).Decode(&user)
+	return
+
+}
+
+// UpdateUserTargetChannel updates the channel the media added by a user will be posted on.
+func UpdateUserTargetChannel(userID int32, channelID int64, collection *mongo.Collection) error {
+
+	//
+	ctx, cancelCtx := context.WithTimeout(context.Background(), opDeadline)
+	defer cancelCtx()
+
+	//
+	filter := bson.M{"telegramid": userID}
+	update := bson.D{
+		{
+			Key:   "$set",
+			Value: bson.D{{Key: "targetchannelid", Value: channelID}},
+		},
+	}
+
+	//
+	res, err := collection.UpdateOne(ctx, filter, update, options.Update())
+	if err == nil && res.MatchedCount == 0 {
+		err = mongo.ErrNoDocuments
+	}
+
+	return err
 
 }
 
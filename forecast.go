@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/utility"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"time"
+)
+
+const (
+	// forecastCommand is the CLI subcommand printing the projected posting timeline.
+	forecastCommand = "forecast"
+
+	// defaultForecastHours is the default number of hours the forecast covers.
+	defaultForecastHours = 24
+)
+
+// runForecast prints the posting timeline of every channel for the next hours,
+// reading the document store without posting anything.
+// The number of hours can be passed as argument.
+func runForecast(cfg *structs.Config, arguments []string) {
+
+	//
+	hours := defaultForecastHours
+	if len(arguments) > 0 {
+
+		var err error
+		hours, err = strconv.Atoi(arguments[0])
+		if err != nil || hours < 1 {
+			log.Fatal("The forecast hours must be a positive number, got ", arguments[0])
+		}
+
+	}
+
+	//
+	until := time.Now().Add(time.Duration(hours) * time.Hour)
+	for _, channel := range cfg.Autoposting.GetChannels() {
+
+		timeline, err := posting.Simulate(cfg, channel)
+		if err != nil {
+			log.Fatal("Unable to forecast the posting on channel ", channel.Name, ": ", err)
+		}
+
+		fmt.Printf("Channel %s (%d), next %d hours:\n", channel.Name, channel.ChannelID, hours)
+		for _, slot := range timeline {
+
+			if slot.Time.After(until) {
+				break
+			}
+
+			pinned := ""
+			if slot.Pinned {
+				pinned = " (pinned)"
+			}
+
+			fmt.Printf("  %s  %-9s %s added by %d%s\n",
+				utility.FormatDate(slot.Time), slot.Post.Media.Type, slot.Post.Media.FileUniqueID, slot.Post.AddedBy, pinned)
+
+		}
+
+		fmt.Println()
+
+	}
+
+}
@@ -1,4 +1,4 @@
-//nolint
+// nolint
 package localization
 
 //goland:noinspection GoSnakeCaseUsage
@@ -15,13 +15,92 @@ const (
 	COMMANDS_CREDIT_CAPTION_WITHOUT_URL     = "commands_credit_caption_without_url"
 	COMMANDS_DELETE_SUCCESS                 = "commands_delete_deleted_correctly"
 	COMMANDS_DELETE_FAILURE                 = "Unable to delete the post"
+	COMMANDS_FAILED_NO_FAILED_POSTS         = "commands_failed_no_failed_posts"
+	COMMANDS_FAILED_LIST_HEADER             = "commands_failed_list_header"
+	COMMANDS_FAILED_LIST_ENTRY              = "commands_failed_list_entry"
+	COMMANDS_FAILED_USAGE                   = "commands_failed_usage"
+	COMMANDS_FAILED_NOT_FOUND               = "commands_failed_not_found"
+	COMMANDS_FAILED_REQUEUED                = "commands_failed_requeued"
+	COMMANDS_FAILED_DISCARDED               = "commands_failed_discarded"
 	COMMANDS_INFO_ALREADY_POSTED            = "commands_info_post_already_posted"
 	COMMANDS_INFO_NOT_YET_POSTED            = "commands_info_post_not_yet_posted"
+	COMMANDS_INFO_NOT_YET_POSTED_PAUSED     = "commands_info_post_not_yet_posted_paused"
+	COMMANDS_INFO_SCHEDULED                 = "commands_info_post_scheduled"
+	COMMANDS_INFO_HELD_FOR_CAMPAIGN         = "commands_info_post_held_for_campaign"
+	COMMANDS_INFO_HELD_BY_CAMPAIGN          = "commands_info_post_held_by_campaign"
 	COMMANDS_PEEK_NO_POST_FOUND             = "commands_peek_no_post_found"
 	COMMANDS_PAUSE_UNSUCCESSFUL             = "commands_pause_unsuccessful"
+	COMMANDS_PAUSE_USAGE                    = "commands_pause_usage"
 	COMMANDS_POSTNOW_SUCCESSFUL             = "commands_postnow_successful"
 	COMMANDS_POSTNOW_UNSUCCESSFUL           = "commands_postnow_unsuccessful"
+	COMMANDS_PRIORITY_USAGE                 = "commands_priority_usage"
+	COMMANDS_PRIORITY_ALREADY_POSTED        = "commands_priority_already_posted"
+	COMMANDS_PRIORITY_SUCCESSFUL            = "commands_priority_successful"
+	COMMANDS_PRIORITY_UNSUCCESSFUL          = "commands_priority_unsuccessful"
+	COMMANDS_RESUME_UNSUCCESSFUL            = "commands_resume_unsuccessful"
 	COMMANDS_STATUS_POSTS_ENQUEUED          = "commands_status_posts_enqueued"
+	COMMANDS_STATUS_POSTS_ENQUEUED_PAUSED   = "commands_status_posts_enqueued_paused"
+	COMMANDS_STATUS_OUTSIDE_POSTING_WINDOW  = "commands_status_outside_posting_window"
+	COMMANDS_STATUS_CHANNEL                 = "commands_status_channel"
+	COMMANDS_STATUS_PINNED_POSTS            = "commands_status_pinned_posts"
+	COMMANDS_STATUS_PINNED_POST_ENTRY       = "commands_status_pinned_post_entry"
+	COMMANDS_STATUS_PAUSED_UNTIL            = "commands_status_paused_until"
+	COMMANDS_STATUS_PAUSED_INDEFINITELY     = "commands_status_paused_indefinitely"
+	COMMANDS_STATUS_UNABLE_TO_COUNT_POSTS   = "commands_status_unable_to_count_posts"
+	COMMANDS_SCHEDULE_USAGE                 = "commands_schedule_usage"
+	COMMANDS_SCHEDULE_SUCCESSFUL            = "commands_schedule_successful"
+	COMMANDS_SCHEDULE_CANCELLED             = "commands_schedule_cancelled"
+	COMMANDS_SCHEDULE_UNSUCCESSFUL          = "commands_schedule_unsuccessful"
+	COMMANDS_CHANNEL_LIST_HEADER            = "commands_channel_list_header"
+	COMMANDS_CHANNEL_LIST_ENTRY             = "commands_channel_list_entry"
+	COMMANDS_CHANNEL_LIST_SELECTED_ENTRY    = "commands_channel_list_selected_entry"
+	COMMANDS_CHANNEL_NOT_FOUND              = "commands_channel_not_found"
+	COMMANDS_CHANNEL_SUCCESSFUL             = "commands_channel_successful"
+	COMMANDS_CHANNEL_UNSUCCESSFUL           = "commands_channel_unsuccessful"
+	COMMANDS_UPCOMING_USAGE                 = "commands_upcoming_usage"
+	COMMANDS_UPCOMING_NO_POSTS              = "commands_upcoming_no_posts"
+	COMMANDS_UPCOMING_HEADER                = "commands_upcoming_header"
+	COMMANDS_UPCOMING_ENTRY                 = "commands_upcoming_entry"
+	COMMANDS_UPCOMING_PINNED_ENTRY          = "commands_upcoming_pinned_entry"
+	COMMANDS_TAG_USAGE                      = "commands_tag_usage"
+	COMMANDS_TAG_ALREADY_POSTED             = "commands_tag_already_posted"
+	COMMANDS_TAG_SUCCESSFUL                 = "commands_tag_successful"
+	COMMANDS_TAG_UNSUCCESSFUL               = "commands_tag_unsuccessful"
+	COMMANDS_UNTAG_SUCCESSFUL               = "commands_untag_successful"
+	COMMANDS_UNTAG_UNSUCCESSFUL             = "commands_untag_unsuccessful"
+	COMMANDS_CAMPAIGNS_NO_CAMPAIGNS         = "commands_campaigns_no_campaigns"
+	COMMANDS_CAMPAIGNS_HEADER               = "commands_campaigns_header"
+	COMMANDS_CAMPAIGNS_ENTRY                = "commands_campaigns_entry"
+	COMMANDS_CAMPAIGNS_RUNNING_ENTRY        = "commands_campaigns_running_entry"
+	COMMANDS_CAMPAIGNS_EXCLUSIVE            = "commands_campaigns_exclusive"
+	COMMANDS_CAMPAIGNS_PREFERRED            = "commands_campaigns_preferred"
+	COMMANDS_HISTORY_NO_REVISIONS           = "commands_history_no_revisions"
+	COMMANDS_HISTORY_HEADER                 = "commands_history_header"
+	COMMANDS_HISTORY_ENTRY                  = "commands_history_entry"
+	COMMANDS_HISTORY_CURRENT                = "commands_history_current"
+	COMMANDS_HISTORY_EMPTY_CAPTION          = "commands_history_empty_caption"
+	COMMANDS_HISTORY_CHANNEL_EDITOR         = "commands_history_channel_editor"
+	COMMANDS_REVERT_USAGE                   = "commands_revert_usage"
+	COMMANDS_REVERT_FAILURE                 = "commands_revert_failure"
+	COMMANDS_UNDO_NOTHING_TO_UNDO           = "commands_undo_nothing_to_undo"
+	COMMANDS_UNDO_SUCCESSFUL                = "commands_undo_successful"
+	COMMANDS_UNDO_FAILURE                   = "commands_undo_failure"
+	COMMANDS_QUEUE_USAGE                    = "commands_queue_usage"
+	COMMANDS_QUEUE_EMPTY                    = "commands_queue_empty"
+	COMMANDS_QUEUE_HEADER                   = "commands_queue_header"
+	COMMANDS_QUEUE_ENTRY                    = "commands_queue_entry"
+	COMMANDS_QUEUE_POST_NOT_FOUND           = "commands_queue_post_not_found"
+	COMMANDS_STATS_USAGE                    = "commands_stats_usage"
+	COMMANDS_STATS_FAILURE                  = "commands_stats_failure"
+	COMMANDS_STATS_NO_ACTIVITY              = "commands_stats_no_activity"
+	COMMANDS_STATS_HEADER                   = "commands_stats_header"
+	COMMANDS_STATS_HEADER_ALL_TIME          = "commands_stats_header_all_time"
+	COMMANDS_STATS_POSTS                    = "commands_stats_posts"
+	COMMANDS_STATS_QUEUE_WAIT               = "commands_stats_queue_wait"
+	COMMANDS_STATS_MEDIA_TYPES              = "commands_stats_media_types"
+	COMMANDS_STATS_DUPLICATES               = "commands_stats_duplicates"
+	COMMANDS_STATS_CONTRIBUTORS_HEADER      = "commands_stats_contributors_header"
+	COMMANDS_STATS_CONTRIBUTOR_ENTRY        = "commands_stats_contributor_entry"
 	COMMANDS_THANK_UNABLE_TO_THANK          = "commands_thanks_unable_to_thank"
 	COMMANDS_THANK_CANT_THANK_CHANNELS      = "commands_thanks_cant_thank_channels"
 	COMMANDS_THANK_CANT_THANK_BOTS          = "commands_thanks_cant_thank_bots"
@@ -36,9 +115,21 @@ const (
 
 	// POSTING
 	POSTING_ALERTS_LOW_POSTS                 = "posting_alerts_low_posts"
+	POSTING_ALERTS_CRITICAL_POSTS            = "posting_alerts_critical_posts"
+	POSTING_ALERTS_ALL_CLEAR                 = "posting_alerts_all_clear"
+	POSTING_ALERTS_STORAGE_UNAVAILABLE       = "posting_alerts_storage_unavailable"
+	POSTING_ALERTS_STORAGE_AVAILABLE         = "posting_alerts_storage_available"
+	POSTING_ALERTS_CHANNEL_UNAVAILABLE       = "posting_alerts_channel_unavailable"
 	POSTING_POSTING_PREVIOUS_POST_TOO_CLOSE  = "posting_posting_previous_post_too_close"
 	POSTING_POSTING_UNABLE_TO_PARSE_CAPTION  = "posting_posting_unable_to_parse_caption"
 	POSTING_POSTING_PREVIOUS_PAUSE_TOO_CLOSE = "posting_posting_previous_pause_too_close"
+	POSTING_POSTING_ALREADY_POSTED           = "posting_posting_already_posted"
+	POSTING_POSTING_PIN_IN_THE_PAST          = "posting_posting_pin_in_the_past"
+	POSTING_POSTING_PAUSE_IN_THE_PAST        = "posting_posting_pause_in_the_past"
+	POSTING_POSTING_NOT_PAUSED               = "posting_posting_not_paused"
+	POSTING_POSTING_UNKNOWN_CHANNEL          = "posting_posting_unknown_channel"
+	POSTING_POSTING_STORAGE_UNAVAILABLE      = "posting_posting_storage_unavailable"
+	POSTING_POSTING_UNMARKED_POSTS           = "posting_posting_unmarked_posts"
 
 	// UPDATES
 	UPDATES_DUPLICATES_DUPLICATE_ADDED_BY         = "updates_duplicates_duplicate_added_by"
@@ -46,4 +137,6 @@ const (
 	UPDATES_TEXTS_COMMAND_UNIMPLEMENTED           = "updates_texts_command_unimplemented"
 	UPDATES_TEXTS_UNABLE_TO_GET_REPLY_MESSAGE     = "updates_texts_unable_to_get_reply_message"
 	UPDATES_MEDIA_UNABLE_TO_GET_DUPLICATE_CAPTION = "updates_media_unable_to_get_duplicate_caption"
+	UPDATES_MEDIA_UNABLE_TO_ADD_MEDIA             = "updates_media_unable_to_add_media"
+	UPDATES_STORAGE_UNAVAILABLE                   = "updates_storage_unavailable"
 )
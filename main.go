@@ -1,17 +1,23 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/bykovme/gotrans"
 	"github.com/shitpostingio/autopostingbot/analysisadapter"
 	"github.com/shitpostingio/autopostingbot/api"
 	"github.com/shitpostingio/autopostingbot/config"
-	"github.com/shitpostingio/autopostingbot/documentstore"
+	"github.com/shitpostingio/autopostingbot/documentstore/backend"
+	"github.com/shitpostingio/autopostingbot/documentstore/health"
+	"github.com/shitpostingio/autopostingbot/documentstore/retention"
 	"github.com/shitpostingio/autopostingbot/localization"
 	"github.com/shitpostingio/autopostingbot/posting"
 	"github.com/shitpostingio/autopostingbot/updates"
 	log "github.com/sirupsen/logrus"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/shitpostingio/autopostingbot/repository"
 )
@@ -59,8 +65,15 @@ func main() {
 	// Configure analysis adapter
 	analysisadapter.Start(cfg.AnalysisAPI)
 
-	// Connect to the database
-	documentstore.Connect(&cfg.DocumentStore, cfg.Autoposting.MediaApproximation, cfg.Autoposting.SimilarityThreshold)
+	// Open the storage backend
+	backend.Open(&cfg)
+
+	// Print the projected posting timeline without starting the bot
+	if flag.Arg(0) == forecastCommand {
+		runForecast(&cfg, flag.Args()[1:])
+		_ = backend.Close()
+		return
+	}
 
 	// Authorize on tdlib
 	tdlibClient, err := api.Authorize(cfg.Autoposting.BotToken, &cfg.Tdlib)
@@ -76,15 +89,89 @@ func main() {
 		log.Fatal("Error while getting information on self from Telegram: ", err)
 	}
 
-	// Start listening for updates
+	// The updates received while the posting managers start are buffered
+	// by the listener, and handled once the managers the commands rely on are ready
 	listener := tdlibClient.GetListener()
-	defer listener.Close()
-	go updates.HandleUpdates(listener)
 
-	// Start the posting manager
+	// Start the posting managers
 	posting.Start(&cfg, debug)
-	log.Info(fmt.Sprintf("Shitposting autoposting-bot version v%s, build %s, edition %s", Version, Build, posting.GetPostingManager().GetEditionName()))
-	posting.Listen()
+	log.Info(fmt.Sprintf("Shitposting autoposting-bot version v%s, build %s", Version, Build))
+	for _, channel := range posting.GetChannels() {
+		log.Info(fmt.Sprintf("Posting on channel %s (%d), edition %s", channel.Name, channel.ChannelID, channel.Edition))
+	}
+
+	// Start listening for updates
+	ctx := shutdownContext()
+	updatesDone := make(chan struct{})
+	go func() {
+		updates.HandleUpdates(ctx, listener)
+		close(updatesDone)
+	}()
+
+	// The posting managers are stopped after the updates, so that
+	// the requests of the commands being handled can still be served
+	postingCtx, stopPosting := context.WithCancel(context.Background())
+	postingDone := make(chan struct{})
+	go func() {
+		posting.Listen(postingCtx)
+		close(postingDone)
+	}()
+
+	// Purge the removed posts once their retention period is over
+	retentionDone := make(chan struct{})
+	go func() {
+		if cfg.Autoposting.RemovedPostRetention > 0 {
+			retention.Run(ctx, repository.Posts, cfg.Autoposting.RemovedPostRetention)
+		}
+		close(retentionDone)
+	}()
+
+	// Alert the admins when the storage backend becomes unavailable and when it's back
+	healthDone := make(chan struct{})
+	go func() {
+		if cfg.DocumentStore.HealthCheckInterval > 0 {
+			health.Run(ctx, backend.Ping, cfg.DocumentStore.HealthCheckInterval, posting.NotifyStorageAvailability)
+		}
+		close(healthDone)
+	}()
+
+	//
+	<-ctx.Done()
+	log.Info("Shutting down")
+	listener.Close()
+	<-updatesDone
+	stopPosting()
+	<-postingDone
+	<-retentionDone
+	<-healthDone
+
+	//
+	tdlibClient.Stop()
+	err = backend.Close()
+	if err != nil {
+		log.Error("Error while closing the storage backend: ", err)
+	}
+
+	log.Info("Shutdown complete")
+
+}
+
+// shutdownContext returns a context that is cancelled
+// when the process receives SIGINT or SIGTERM.
+func shutdownContext() context.Context {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		log.Info("Received ", sig)
+		signal.Stop(signals)
+		cancel()
+	}()
+
+	return ctx
 
 }
 
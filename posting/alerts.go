@@ -1,43 +1,105 @@
 package posting
 
 import (
-	"context"
 	"fmt"
-	"github.com/shitpostingio/autopostingbot/api"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
-	"github.com/shitpostingio/autopostingbot/documentstore/entities"
 	l "github.com/shitpostingio/autopostingbot/localization"
-	log "github.com/sirupsen/logrus"
 )
 
-// sendLowPostAlerts sends a message to all the authorized users,
-// notifying them of a low number of posts enqueued.
-func sendLowPostAlerts(postsEnqueued int) {
+const (
+	alertLevelNone = iota
+	alertLevelLow
+	alertLevelCritical
+)
+
+var (
+	alertKeys = map[int]string{
+		alertLevelNone:     l.POSTING_ALERTS_ALL_CLEAR,
+		alertLevelLow:      l.POSTING_ALERTS_LOW_POSTS,
+		alertLevelCritical: l.POSTING_ALERTS_CRITICAL_POSTS,
+	}
+)
+
+// checkQueueAlerts notifies the admins of a low number of posts enqueued on the channel.
+// An alert is sent when the queue goes below the alert threshold or the critical one,
+// when it halves since the last alert and, if it didn't, after the alert cooldown.
+// Once the queue is back above the threshold, an all-clear is sent.
+func (m *Manager) checkQueueAlerts(queueLength int) {
 
 	//
-	cur, err := dbwrapper.GetUsers()
-	if err != nil {
-		log.Error(err)
+	level := m.queueAlertLevel(queueLength)
+	if level == alertLevelNone {
+
+		if m.alertLevel != alertLevelNone {
+			m.alertLevel = alertLevelNone
+			m.sendQueueAlert(alertLevelNone, queueLength)
+		}
+
+		return
+
+	}
+
+	// Going back below the threshold shortly after the all-clear
+	// only results in an alert if the queue is critical
+	cooledDown := m.since(m.alertedAt) >= m.config.Autoposting.AlertCooldown
+	switch {
+	case level > m.alertLevel && (m.alertLevel != alertLevelNone || level == alertLevelCritical || cooledDown):
+	case queueLength < m.alertedQueueLength && queueLength <= m.alertedQueueLength/2:
+	case cooledDown:
+	default:
 		return
 	}
 
 	//
-	defer func() {
-		_ = cur.Close(context.TODO())
-	}()
+	m.alertLevel = level
+	m.alertedAt = m.clock.Now()
+	m.alertedQueueLength = queueLength
+	m.sendQueueAlert(level, queueLength)
+
+}
+
+// queueAlertLevel returns the alert level for the input queue length.
+func (m *Manager) queueAlertLevel(queueLength int) int {
+
+	switch {
+	case queueLength <= m.channel.CriticalPostAlertThreshold:
+		return alertLevelCritical
+	case queueLength < m.channel.PostAlertThreshold:
+		return alertLevelLow
+	default:
+		return alertLevelNone
+	}
+
+}
+
+// sendQueueAlert sends the alert of the input level to the staff chat
+// or, if there is none, to all the authorized users.
+func (m *Manager) sendQueueAlert(level, queueLength int) {
+	alert := fmt.Sprintf(l.GetString(alertKeys[level]), m.channel.Name, queueLength)
+	m.publisher.Alert(m.channel.AlertChatID, alert)
+}
+
+// NotifyStorageAvailability lets the admins of every channel know that
+// the storage backend became unavailable or that it's available again.
+// Channels sharing the same staff chat notify it once.
+func NotifyStorageAvailability(available bool) {
 
 	//
-	alert := fmt.Sprintf(l.GetString(l.POSTING_ALERTS_LOW_POSTS), postsEnqueued)
+	key := l.POSTING_ALERTS_STORAGE_UNAVAILABLE
+	if available {
+		key = l.POSTING_ALERTS_STORAGE_AVAILABLE
+	}
 
 	//
-	for cur.Next(context.TODO()) {
+	notified := make(map[int64]bool)
+	for _, m := range managers {
 
-		var user entities.User
-		err = cur.Decode(&user)
-		if err == nil {
-			_, _ = api.SendPlainText(int64(user.TelegramID), alert)
+		if notified[m.channel.AlertChatID] {
+			continue
 		}
 
+		notified[m.channel.AlertChatID] = true
+		m.publisher.Alert(m.channel.AlertChatID, l.GetString(key))
+
 	}
 
 }
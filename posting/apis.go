@@ -1,7 +1,12 @@
 package posting
 
 import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/config/structs"
 	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting/campaign"
 	"github.com/shitpostingio/autopostingbot/posting/edition"
 	"time"
 )
@@ -21,16 +26,68 @@ type RequestPostStruct struct {
 // It includes a channel to retrieve the operation result.
 type RequestPauseStruct struct {
 
-	// Duration is the amount of time to pause posting for.
-	Duration time.Duration
+	// Until is the time at which the posting will resume.
+	// The zero value pauses the posting until it's resumed.
+	Until time.Time
+
+	// PausedBy is the id of the user who requested the pause.
+	PausedBy int32
 
 	// ErrorChan is a channel to the operation results.
 	ErrorChan chan error
 }
 
-// RequestPost requests the posting of a media.
+// RequestResumeStruct represents a request to resume posting.
+// It includes a channel to retrieve the operation result.
+type RequestResumeStruct struct {
+
+	// ErrorChan is a channel to the operation results.
+	ErrorChan chan error
+}
+
+// RequestRescheduleStruct represents a request to schedule the posting anew.
+// It includes a channel to retrieve the operation result.
+type RequestRescheduleStruct struct {
+
+	// ErrorChan is a channel to the operation results.
+	ErrorChan chan error
+}
+
+// PauseState represents an active pause of the posting.
+type PauseState struct {
+
+	// Until is the time at which the posting will resume.
+	// It is zero if the posting is paused until resumed.
+	Until time.Time
+
+	// PausedBy is the id of the user who requested the pause.
+	// It is zero if the posting was paused because the channel can't be posted on.
+	PausedBy int32
+}
+
+// RequestPinStruct represents a request to pin a post to a specific time.
+// It includes a channel to retrieve the operation result.
+type RequestPinStruct struct {
+
+	// Post is the media to be pinned.
+	Post *entities.Post
+
+	// Time is the time the post will be pinned to.
+	// If nil, the post will be unpinned.
+	Time *time.Time
+
+	// ErrorChan is a channel to the operation results.
+	ErrorChan chan error
+}
+
+// RequestPost requests the posting of a media on its channel.
 func RequestPost(post *entities.Post) error {
 
+	m, err := getManager(post.ChannelID)
+	if err != nil {
+		return err
+	}
+
 	rps := RequestPostStruct{
 		Post:      post,
 		ErrorChan: make(chan error, 1),
@@ -41,11 +98,18 @@ func RequestPost(post *entities.Post) error {
 
 }
 
-// RequestPause requests a pause in the posting.
-func RequestPause(duration time.Duration) error {
+// RequestPause requests a pause in the posting on a channel until the input time.
+// A zero until pauses the posting until it's resumed.
+func RequestPause(channelID int64, until time.Time, pausedBy int32) error {
+
+	m, err := getManager(channelID)
+	if err != nil {
+		return err
+	}
 
 	rps := RequestPauseStruct{
-		Duration:  duration,
+		Until:     until,
+		PausedBy:  pausedBy,
 		ErrorChan: make(chan error, 1),
 	}
 
@@ -54,22 +118,229 @@ func RequestPause(duration time.Duration) error {
 
 }
 
-// GetPostingRate returns the current posting rate.
-func GetPostingRate() time.Duration {
-	return m.postingRate
+// RequestResume requests the end of the active pause in the posting on a channel.
+func RequestResume(channelID int64) error {
+
+	m, err := getManager(channelID)
+	if err != nil {
+		return err
+	}
+
+	rrs := RequestResumeStruct{
+		ErrorChan: make(chan error, 1),
+	}
+
+	m.requestResumeChannel <- rrs
+	return <-rrs.ErrorChan
+
+}
+
+// RequestPin requests the pinning of a post to a specific time.
+// A nil pinTime unpins the post, putting it back in the queue.
+func RequestPin(post *entities.Post, pinTime *time.Time) error {
+
+	m, err := getManager(post.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	rps := RequestPinStruct{
+		Post:      post,
+		Time:      pinTime,
+		ErrorChan: make(chan error, 1),
+	}
+
+	m.requestPinChannel <- rps
+	return <-rps.ErrorChan
+
+}
+
+// GetChannels returns the configuration of the channels the bot posts on.
+// The first one is the default channel.
+func GetChannels() []structs.ChannelConfiguration {
+	return channels
 }
 
-// GetNextPostTime returns the time at which the next post is scheduled.
-func GetNextPostTime() time.Time {
-	return m.nextPostScheduled
+// GetChannel returns the configuration of a channel the bot posts on.
+func GetChannel(channelID int64) (structs.ChannelConfiguration, bool) {
+
+	m, found := managers[channelID]
+	if !found {
+		return structs.ChannelConfiguration{}, false
+	}
+
+	return m.channel, true
+
 }
 
-// GetPostingManager returns the current posting manager edition.
-func GetPostingManager() edition.Edition {
-	return m.e
+// GetPostingRate returns the current posting rate of a channel.
+func GetPostingRate(channelID int64) time.Duration {
+
+	m, err := getManager(channelID)
+	if err != nil {
+		return 0
+	}
+
+	return m.getSchedule().postingRate
+
 }
 
-// ForcePostScheduling forces a new post scheduling.
-func ForcePostScheduling() {
-	schedulePosting(time.Unix(0, 0))
+// GetPause returns the active pause in the posting on a channel, if any.
+func GetPause(channelID int64) (PauseState, bool) {
+
+	m, err := getManager(channelID)
+	if err != nil {
+		return PauseState{}, false
+	}
+
+	s := m.getSchedule()
+	if !s.isPaused(m.clock.Now()) {
+		return PauseState{}, false
+	}
+
+	return PauseState{Until: s.pausedUntil, PausedBy: s.pausedBy}, true
+
+}
+
+// GetNextPostTime returns the time at which the next post is scheduled on a channel.
+func GetNextPostTime(channelID int64) time.Time {
+
+	m, err := getManager(channelID)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return m.getSchedule().nextPostScheduled
+
+}
+
+// EstimatePostTime estimates the time at which the post in the input queue
+// position of a channel will be posted, skipping the hours outside the posting windows
+// and the active pause. If the posting is paused until resumed, the zero time is returned
+// with paused set to true, as the post won't be posted before.
+func EstimatePostTime(channelID int64, position int) (postTime time.Time, paused bool) {
+
+	//
+	m, err := getManager(channelID)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	s := m.getSchedule()
+	if s.pausedIndefinitely {
+		return time.Time{}, true
+	}
+
+	// The estimate draws from its own edition, as the one of the manager
+	// and its random values are used by the manager goroutine
+	e, err := loadEdition(m.config, m.channel.Edition, edition.NewRandom(m.clock.Now().UnixNano()))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// Nothing is posted before the pause ends
+	postTime = m.windows.Next(latest(s.nextPostScheduled, s.pausedUntil))
+	if m.windows.IsAlwaysOpen() {
+		return postTime.Add(e.EstimatePostTime(position - 1)), false
+	}
+
+	//
+	for i := position - 1; i > 0; i-- {
+		postTime = m.windows.Next(postTime.Add(e.GetNewPostingRate(i)))
+	}
+
+	return postTime, false
+
+}
+
+// GetNextPostingWindowOpening returns the current time if posting is allowed on a channel,
+// otherwise the time at which its next posting window opens.
+func GetNextPostingWindowOpening(channelID int64) time.Time {
+
+	m, err := getManager(channelID)
+	if err != nil {
+		return time.Now()
+	}
+
+	return m.windows.Next(m.clock.Now())
+
+}
+
+// GetLocation returns the location used by the posting manager of a channel.
+func GetLocation(channelID int64) *time.Location {
+
+	m, err := getManager(channelID)
+	if err != nil {
+		return time.Local
+	}
+
+	return m.windows.Location()
+
+}
+
+// GetEdition returns the edition running on a channel.
+func GetEdition(channelID int64) (edition.Edition, error) {
+
+	m, err := getManager(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.e, nil
+
+}
+
+// GetQueueSelection returns the policy used to pick the next post from the queue of a channel,
+// drawing from the themed queue of the campaign running, if any.
+func GetQueueSelection(channelID int64) selection.Policy {
+
+	m, err := getManager(channelID)
+	if err != nil {
+		return selection.Policy{Mode: selection.FIFO}
+	}
+
+	return m.policy()
+
+}
+
+// GetCampaigns returns the campaigns of a channel, sorted by start time.
+func GetCampaigns(channelID int64) campaign.Calendar {
+
+	m, err := getManager(channelID)
+	if err != nil {
+		return nil
+	}
+
+	return m.campaigns
+
+}
+
+// ForcePostScheduling forces a new post scheduling on a channel,
+// waiting for its posting manager to carry it out.
+func ForcePostScheduling(channelID int64) {
+
+	m, err := getManager(channelID)
+	if err != nil {
+		return
+	}
+
+	rrs := RequestRescheduleStruct{
+		ErrorChan: make(chan error, 1),
+	}
+
+	m.requestRescheduleChannel <- rrs
+	<-rrs.ErrorChan
+
+}
+
+// getManager returns the posting manager of a channel.
+func getManager(channelID int64) (*Manager, error) {
+
+	m, found := managers[channelID]
+	if !found {
+		return nil, fmt.Errorf(l.GetString(l.POSTING_POSTING_UNKNOWN_CHANNEL), channelID)
+	}
+
+	return m, nil
+
 }
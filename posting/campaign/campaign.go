@@ -0,0 +1,182 @@
+package campaign
+
+import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"sort"
+	"time"
+)
+
+const (
+	dayLayout  = "2006-01-02"
+	timeLayout = "2006-01-02 15:04"
+)
+
+// Campaign represents a time-bounded campaign drawing from a themed queue.
+type Campaign struct {
+
+	// Name is the name of the campaign.
+	Name string
+
+	// Tag is the tag of the posts in the themed queue.
+	Tag string
+
+	// Start is the time the campaign starts at.
+	Start time.Time
+
+	// End is the time the campaign ends at.
+	End time.Time
+
+	// Exclusive is true if only the posts in the themed queue
+	// are posted during the campaign.
+	Exclusive bool
+}
+
+// Calendar represents the campaigns of a channel, sorted by start time.
+type Calendar []Campaign
+
+// New creates the Calendar of a channel given the campaigns configuration,
+// keeping only the campaigns that run on the channel.
+// Times are interpreted in the input location.
+func New(campaigns []structs.CampaignConfiguration, channelName string, location *time.Location) (Calendar, error) {
+
+	//
+	var calendar Calendar
+	for _, c := range campaigns {
+
+		if !runsOn(c, channelName) {
+			continue
+		}
+
+		//
+		if c.Name == "" {
+			return nil, fmt.Errorf("campaign.New: campaign without name")
+		}
+
+		tag := c.Tag
+		if tag == "" {
+			tag = c.Name
+		}
+
+		//
+		start, err := parseBound(c.Start, location, false)
+		if err != nil {
+			return nil, fmt.Errorf("campaign.New: invalid start %s of campaign %s: %w", c.Start, c.Name, err)
+		}
+
+		end, err := parseBound(c.End, location, true)
+		if err != nil {
+			return nil, fmt.Errorf("campaign.New: invalid end %s of campaign %s: %w", c.End, c.Name, err)
+		}
+
+		if !end.After(start) {
+			return nil, fmt.Errorf("campaign.New: campaign %s ends before it starts", c.Name)
+		}
+
+		calendar = append(calendar, Campaign{
+			Name:      c.Name,
+			Tag:       entities.NormalizeTag(tag),
+			Start:     start,
+			End:       end,
+			Exclusive: c.Exclusive,
+		})
+
+	}
+
+	//
+	sort.SliceStable(calendar, func(i, j int) bool {
+		return calendar[i].Start.Before(calendar[j].Start)
+	})
+
+	// Only one campaign at a time can run on a channel
+	for i := 1; i < len(calendar); i++ {
+		if calendar[i].Start.Before(calendar[i-1].End) {
+			return nil, fmt.Errorf("campaign.New: campaigns %s and %s overlap on channel %s",
+				calendar[i-1].Name, calendar[i].Name, channelName)
+		}
+	}
+
+	return calendar, nil
+
+}
+
+// IsRunning returns true if the campaign is running at the input time.
+func (c Campaign) IsRunning(t time.Time) bool {
+	return !t.Before(c.Start) && t.Before(c.End)
+}
+
+// Running returns the campaign running at the input time, if any.
+func (c Calendar) Running(t time.Time) (Campaign, bool) {
+
+	for _, campaign := range c {
+		if campaign.IsRunning(t) {
+			return campaign, true
+		}
+	}
+
+	return Campaign{}, false
+
+}
+
+// NextBoundary returns the first time after t at which a campaign starts or ends,
+// if there is any.
+func (c Calendar) NextBoundary(t time.Time) (time.Time, bool) {
+
+	for _, campaign := range c {
+
+		if campaign.Start.After(t) {
+			return campaign.Start, true
+		}
+
+		if campaign.End.After(t) {
+			return campaign.End, true
+		}
+
+	}
+
+	return time.Time{}, false
+
+}
+
+// runsOn returns true if the campaign runs on the channel with the input name.
+func runsOn(c structs.CampaignConfiguration, channelName string) bool {
+
+	if len(c.Channels) == 0 {
+		return true
+	}
+
+	for _, name := range c.Channels {
+		if name == channelName {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// parseBound parses the start or the end of a campaign.
+// Days are included as a whole, so the end of a campaign
+// given as a day is the following midnight.
+func parseBound(value string, location *time.Location, isEnd bool) (time.Time, error) {
+
+	//
+	bound, err := time.ParseInLocation(timeLayout, value, location)
+	if err == nil {
+		return bound, nil
+	}
+
+	//
+	bound, err = time.ParseInLocation(dayLayout, value, location)
+	if err != nil {
+		return bound, err
+	}
+
+	if isEnd {
+		bound = bound.AddDate(0, 0, 1)
+	}
+
+	return bound, nil
+
+}
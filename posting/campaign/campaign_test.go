@@ -0,0 +1,101 @@
+package campaign
+
+import (
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	"testing"
+	"time"
+)
+
+func TestNewRejectsOverlaps(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		campaigns []structs.CampaignConfiguration
+		wantErr   bool
+	}{
+		{"consecutive days", []structs.CampaignConfiguration{
+			{Name: "first", Start: "2020-12-01", End: "2020-12-01"},
+			{Name: "second", Start: "2020-12-02", End: "2020-12-03"},
+		}, false},
+		{"same day", []structs.CampaignConfiguration{
+			{Name: "first", Start: "2020-12-01", End: "2020-12-01"},
+			{Name: "second", Start: "2020-12-01 18:00", End: "2020-12-02"},
+		}, true},
+		{"starting when the other ends", []structs.CampaignConfiguration{
+			{Name: "first", Start: "2020-12-01 10:00", End: "2020-12-01 12:00"},
+			{Name: "second", Start: "2020-12-01 12:00", End: "2020-12-01 14:00"},
+		}, false},
+		{"out of order", []structs.CampaignConfiguration{
+			{Name: "second", Start: "2020-12-05", End: "2020-12-10"},
+			{Name: "first", Start: "2020-12-01", End: "2020-12-05"},
+		}, true},
+		{"on different channels", []structs.CampaignConfiguration{
+			{Name: "first", Start: "2020-12-01", End: "2020-12-05", Channels: []string{"test"}},
+			{Name: "second", Start: "2020-12-03", End: "2020-12-07", Channels: []string{"other"}},
+		}, false},
+		{"ending before starting", []structs.CampaignConfiguration{
+			{Name: "first", Start: "2020-12-01 12:00", End: "2020-12-01 10:00"},
+		}, true},
+	}
+
+	for _, test := range tests {
+
+		_, err := New(test.campaigns, "test", time.UTC)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%s: got error %v, want error %t", test.name, err, test.wantErr)
+		}
+
+	}
+
+}
+
+func TestCalendarBoundaries(t *testing.T) {
+
+	calendar, err := New([]structs.CampaignConfiguration{
+		{Name: "christmas", Start: "2020-12-24", End: "2020-12-25"},
+		{Name: "new year", Start: "2020-12-31 22:00", End: "2021-01-01 02:00"},
+	}, "test", time.UTC)
+	if err != nil {
+		t.Fatalf("unable to create the calendar: %v", err)
+	}
+
+	at := func(month time.Month, day, hour, minute int) time.Time {
+
+		year := 2020
+		if month == time.January {
+			year = 2021
+		}
+
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+
+	}
+
+	tests := []struct {
+		name         string
+		input        time.Time
+		wantRunning  string
+		wantBoundary time.Time
+	}{
+		{"before the first campaign", at(time.December, 1, 0, 0), "", at(time.December, 24, 0, 0)},
+		{"at the start", at(time.December, 24, 0, 0), "christmas", at(time.December, 26, 0, 0)},
+		{"during the last included day", at(time.December, 25, 23, 59), "christmas", at(time.December, 26, 0, 0)},
+		{"at the end", at(time.December, 26, 0, 0), "", at(time.December, 31, 22, 0)},
+		{"across the end of the year", at(time.January, 1, 1, 0), "new year", at(time.January, 1, 2, 0)},
+		{"after the last campaign", at(time.January, 1, 2, 0), "", time.Time{}},
+	}
+
+	for _, test := range tests {
+
+		running, _ := calendar.Running(test.input)
+		if running.Name != test.wantRunning {
+			t.Errorf("%s: got running campaign %q, want %q", test.name, running.Name, test.wantRunning)
+		}
+
+		boundary, _ := calendar.NextBoundary(test.input)
+		if !boundary.Equal(test.wantBoundary) {
+			t.Errorf("%s: got next boundary %s, want %s", test.name, boundary, test.wantBoundary)
+		}
+
+	}
+
+}
@@ -0,0 +1,53 @@
+package clock
+
+import "time"
+
+// Clock abstracts the passing of time, so that the posting
+// schedule can be driven by simulated time.
+type Clock interface {
+
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer creates a Timer that will fire after the input duration.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer abstracts a time.Timer.
+type Timer interface {
+
+	// C returns the channel on which the time is delivered when the timer fires.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing.
+	// It returns false if the timer already fired or was stopped.
+	Stop() bool
+}
+
+// Real is the Clock backed by the time package.
+type Real struct{}
+
+// realTimer is the Timer backed by a time.Timer.
+type realTimer struct {
+	timer *time.Timer
+}
+
+// Now returns the current time.
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer creates a Timer that will fire after the input duration.
+func (Real) NewTimer(d time.Duration) Timer {
+	return realTimer{timer: time.NewTimer(d)}
+}
+
+// C returns the channel on which the time is delivered when the timer fires.
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+// Stop prevents the timer from firing.
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
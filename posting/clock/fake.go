@@ -0,0 +1,119 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only moves when told to.
+// Its timers fire when the time is advanced past their deadline.
+type Fake struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// fakeTimer is a Timer driven by a Fake clock.
+type fakeTimer struct {
+	clock    *Fake
+	deadline time.Time
+	c        chan time.Time
+}
+
+// NewFake creates a Fake clock set to the input time.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the current simulated time.
+func (f *Fake) Now() time.Time {
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.now
+
+}
+
+// NewTimer creates a Timer that will fire once the simulated time
+// is advanced by at least the input duration.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	//
+	t := &fakeTimer{
+		clock:    f,
+		deadline: f.now.Add(d),
+		c:        make(chan time.Time, 1),
+	}
+
+	//
+	if d <= 0 {
+		t.c <- f.now
+		return t
+	}
+
+	f.timers = append(f.timers, t)
+	return t
+
+}
+
+// Advance moves the simulated time forward, firing the timers
+// whose deadline has been reached.
+func (f *Fake) Advance(d time.Duration) {
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	//
+	f.now = f.now.Add(d)
+
+	//
+	pending := f.timers[:0]
+	for _, t := range f.timers {
+
+		if t.deadline.After(f.now) {
+			pending = append(pending, t)
+			continue
+		}
+
+		t.c <- f.now
+
+	}
+
+	f.timers = pending
+
+}
+
+// PendingTimers returns the number of timers that have yet to fire.
+func (f *Fake) PendingTimers() int {
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.timers)
+
+}
+
+// C returns the channel on which the time is delivered when the timer fires.
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop prevents the timer from firing.
+func (t *fakeTimer) Stop() bool {
+
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	//
+	for i, pending := range t.clock.timers {
+		if pending == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+
+}
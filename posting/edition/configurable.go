@@ -0,0 +1,186 @@
+package edition
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// ConfigurableEdition represents a posting algorithm defined in the configuration file.
+// Its posting rate follows a curve of queue length breakpoints, with a random jitter
+// and optional bounds on the interval between posts.
+type ConfigurableEdition struct {
+	name        string
+	signature   string
+	selection   selection.Policy
+	curve       []structs.RateBreakpointConfiguration
+	minInterval time.Duration
+	maxInterval time.Duration
+	minJitter   time.Duration
+	maxJitter   time.Duration
+	random      *rand.Rand
+}
+
+// NewConfigurableEdition creates a ConfigurableEdition given its configuration.
+// The jitter will be drawn from random.
+func NewConfigurableEdition(cfg structs.EditionConfiguration, random *rand.Rand) (*ConfigurableEdition, error) {
+
+	//
+	if cfg.Name == "" {
+		return nil, errors.New("NewConfigurableEdition: edition name empty")
+	}
+
+	//
+	if len(cfg.RateCurve) == 0 {
+		return nil, fmt.Errorf("NewConfigurableEdition: edition %s has no rate curve", cfg.Name)
+	}
+
+	//
+	for _, breakpoint := range cfg.RateCurve {
+		if breakpoint.PostsPerHour <= 0 {
+			return nil, fmt.Errorf("NewConfigurableEdition: edition %s has a non positive rate for queue length %d", cfg.Name, breakpoint.QueueLength)
+		}
+	}
+
+	//
+	if cfg.MaxInterval != 0 && cfg.MaxInterval < cfg.MinInterval {
+		return nil, fmt.Errorf("NewConfigurableEdition: edition %s has a max interval lower than its min interval", cfg.Name)
+	}
+
+	//
+	if cfg.MaxJitter < cfg.MinJitter {
+		return nil, fmt.Errorf("NewConfigurableEdition: edition %s has a max jitter lower than its min jitter", cfg.Name)
+	}
+
+	//
+	mode, err := selection.ParseMode(cfg.QueueSelection)
+	if err != nil {
+		return nil, fmt.Errorf("NewConfigurableEdition: edition %s: %v", cfg.Name, err)
+	}
+
+	//
+	for mediaType, max := range cfg.MaxConsecutive {
+		if max < 1 {
+			return nil, fmt.Errorf("NewConfigurableEdition: edition %s allows less than one consecutive %s", cfg.Name, mediaType)
+		}
+	}
+
+	//
+	for mediaType, ratio := range cfg.MediaMix {
+		if ratio <= 0 {
+			return nil, fmt.Errorf("NewConfigurableEdition: edition %s has a non positive mix ratio for %s", cfg.Name, mediaType)
+		}
+	}
+
+	//
+	signature := cfg.Signature
+	if signature == "" {
+		signature = "@" + cfg.Name
+	}
+
+	// Keep the curve sorted by queue length
+	curve := make([]structs.RateBreakpointConfiguration, len(cfg.RateCurve))
+	copy(curve, cfg.RateCurve)
+	sort.Slice(curve, func(i, j int) bool {
+		return curve[i].QueueLength < curve[j].QueueLength
+	})
+
+	return &ConfigurableEdition{
+		name:        cfg.Name,
+		signature:   signature,
+		selection:   selection.Policy{Mode: mode, MaxConsecutive: cfg.MaxConsecutive, Mix: cfg.MediaMix},
+		curve:       curve,
+		minInterval: cfg.MinInterval,
+		maxInterval: cfg.MaxInterval,
+		minJitter:   cfg.MinJitter,
+		maxJitter:   cfg.MaxJitter,
+		random:      random,
+	}, nil
+
+}
+
+// GetEditionName returns the name of the edition.
+func (e *ConfigurableEdition) GetEditionName() string {
+	return e.name
+}
+
+// GetSignature returns the signature to append to the captions.
+func (e *ConfigurableEdition) GetSignature() string {
+	return e.signature
+}
+
+// GetQueueSelection returns the policy used to pick the next post from the queue.
+func (e *ConfigurableEdition) GetQueueSelection() selection.Policy {
+	return e.selection
+}
+
+// GetNewPostingRate returns the new posting rate according to the rate curve
+// and the queue length.
+func (e *ConfigurableEdition) GetNewPostingRate(queueLength int) time.Duration {
+
+	if queueLength == 0 {
+		return 0
+	}
+
+	//
+	jitter := e.minJitter
+	if e.maxJitter > e.minJitter {
+		jitter += time.Duration(e.random.Int63n(int64(e.maxJitter - e.minJitter)))
+	}
+
+	return e.clamp(e.interval(queueLength) + jitter)
+
+}
+
+// EstimatePostTime estimates the amount of time that will pass before
+// being able to post a certain media.
+// The average jitter is used in place of the random one.
+func (e *ConfigurableEdition) EstimatePostTime(queueLength int) (totalDuration time.Duration) {
+
+	averageJitter := (e.minJitter + e.maxJitter) / 2
+	for i := queueLength; i > 0; i-- {
+		totalDuration += e.clamp(e.interval(i) + averageJitter)
+	}
+
+	return
+
+}
+
+// interval returns the interval between two posts, given the queue length,
+// according to the last breakpoint the queue length reached.
+// Queue lengths lower than the first breakpoint follow the first breakpoint.
+func (e *ConfigurableEdition) interval(queueLength int) time.Duration {
+
+	breakpoint := e.curve[0]
+	for _, b := range e.curve {
+
+		if b.QueueLength > queueLength {
+			break
+		}
+
+		breakpoint = b
+
+	}
+
+	return time.Duration(float64(time.Hour) / breakpoint.PostsPerHour)
+
+}
+
+// clamp keeps the interval between the configured bounds.
+func (e *ConfigurableEdition) clamp(interval time.Duration) time.Duration {
+
+	if interval < e.minInterval {
+		return e.minInterval
+	}
+
+	if e.maxInterval != 0 && interval > e.maxInterval {
+		return e.maxInterval
+	}
+
+	return interval
+
+}
@@ -0,0 +1,80 @@
+package edition
+
+import (
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	"testing"
+	"time"
+)
+
+func TestNewConfigurableEditionValidation(t *testing.T) {
+
+	curve := []structs.RateBreakpointConfiguration{{QueueLength: 0, PostsPerHour: 1}}
+
+	tests := []struct {
+		name    string
+		cfg     structs.EditionConfiguration
+		wantErr bool
+	}{
+		{"valid", structs.EditionConfiguration{Name: "test", RateCurve: curve}, false},
+		{"empty name", structs.EditionConfiguration{RateCurve: curve}, true},
+		{"no rate curve", structs.EditionConfiguration{Name: "test"}, true},
+		{"zero rate", structs.EditionConfiguration{Name: "test", RateCurve: []structs.RateBreakpointConfiguration{{QueueLength: 0, PostsPerHour: 1}, {QueueLength: 10, PostsPerHour: 0}}}, true},
+		{"negative rate", structs.EditionConfiguration{Name: "test", RateCurve: []structs.RateBreakpointConfiguration{{QueueLength: 0, PostsPerHour: -1}}}, true},
+		{"max interval lower than min interval", structs.EditionConfiguration{Name: "test", RateCurve: curve, MinInterval: time.Hour, MaxInterval: time.Minute}, true},
+		{"min interval without max interval", structs.EditionConfiguration{Name: "test", RateCurve: curve, MinInterval: time.Hour}, false},
+		{"max jitter lower than min jitter", structs.EditionConfiguration{Name: "test", RateCurve: curve, MinJitter: time.Hour, MaxJitter: time.Minute}, true},
+		{"unknown queue selection", structs.EditionConfiguration{Name: "test", RateCurve: curve, QueueSelection: "random"}, true},
+		{"no consecutive media allowed", structs.EditionConfiguration{Name: "test", RateCurve: curve, MaxConsecutive: map[string]int{"video": 0}}, true},
+		{"non positive mix ratio", structs.EditionConfiguration{Name: "test", RateCurve: curve, MediaMix: map[string]float64{"video": 0}}, true},
+	}
+
+	for _, test := range tests {
+
+		_, err := NewConfigurableEdition(test.cfg, NewRandom(42))
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%s: got error %v, want error %t", test.name, err, test.wantErr)
+		}
+
+	}
+
+}
+
+func TestConfigurableEditionRateCurve(t *testing.T) {
+
+	// The breakpoints are deliberately out of order
+	e, err := NewConfigurableEdition(structs.EditionConfiguration{
+		Name: "test",
+		RateCurve: []structs.RateBreakpointConfiguration{
+			{QueueLength: 20, PostsPerHour: 4},
+			{QueueLength: 5, PostsPerHour: 1},
+			{QueueLength: 10, PostsPerHour: 2},
+		},
+		MinInterval: 20 * time.Minute,
+		MaxInterval: 45 * time.Minute,
+	}, NewRandom(42))
+	if err != nil {
+		t.Fatalf("unable to create the edition: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		queueLength int
+		want        time.Duration
+	}{
+		{"empty queue", 0, 0},
+		{"below the first breakpoint", 1, 45 * time.Minute},
+		{"at the first breakpoint", 5, 45 * time.Minute},
+		{"at the second breakpoint", 10, 30 * time.Minute},
+		{"between breakpoints", 15, 30 * time.Minute},
+		{"past the last breakpoint", 100, 20 * time.Minute},
+	}
+
+	for _, test := range tests {
+
+		if got := e.GetNewPostingRate(test.queueLength); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+
+	}
+
+}
@@ -1,6 +1,7 @@
 package edition
 
 import (
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
 	"time"
 )
 
@@ -16,4 +17,10 @@ type Edition interface {
 
 	// GetEditionName returns the name of the edition.
 	GetEditionName() string
+
+	// GetSignature returns the signature to append to the captions.
+	GetSignature() string
+
+	// GetQueueSelection returns the policy used to pick the next post from the queue.
+	GetQueueSelection() selection.Policy
 }
@@ -0,0 +1,37 @@
+package edition
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// lockedSource is a rand.Source safe for concurrent use,
+// since editions can be queried while the posting manager runs.
+type lockedSource struct {
+	mutex  sync.Mutex
+	source rand.Source
+}
+
+// NewRandom returns a random number generator seeded with the input seed,
+// safe for concurrent use. The same seed always yields the same values.
+func NewRandom(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{source: rand.NewSource(seed)})
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer.
+func (s *lockedSource) Int63() int64 {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.source.Int63()
+
+}
+
+// Seed uses the input seed to initialize the source.
+func (s *lockedSource) Seed(seed int64) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.source.Seed(seed)
+
+}
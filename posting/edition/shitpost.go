@@ -1,6 +1,7 @@
 package edition
 
 import (
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
 	"time"
 )
 
@@ -12,6 +13,16 @@ func (ShitpostEdition) GetEditionName() string {
 	return "shitpost"
 }
 
+// GetSignature returns the signature to append to the captions.
+func (e ShitpostEdition) GetSignature() string {
+	return "@" + e.GetEditionName()
+}
+
+// GetQueueSelection returns the policy used to pick the next post from the queue.
+func (ShitpostEdition) GetQueueSelection() selection.Policy {
+	return selection.Policy{Mode: selection.FIFO}
+}
+
 // GetNewPostingRate returns the new posting rate according to the algorithm
 // and the queue length.
 func (e ShitpostEdition) GetNewPostingRate(queueLength int) time.Duration {
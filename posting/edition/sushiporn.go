@@ -1,18 +1,36 @@
 package edition
 
 import (
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
 	"math/rand"
 	"time"
 )
 
-// SushiPornEdition represents the Shitpost posting algorithm.
-type SushiPornEdition struct{}
+// SushiPornEdition represents the SushiPorn posting algorithm.
+type SushiPornEdition struct {
+	random *rand.Rand
+}
+
+// NewSushiPornEdition creates a SushiPornEdition drawing its posting rates from random.
+func NewSushiPornEdition(random *rand.Rand) SushiPornEdition {
+	return SushiPornEdition{random: random}
+}
 
 // GetEditionName returns the name of the edition.
 func (SushiPornEdition) GetEditionName() string {
 	return "sushiporn"
 }
 
+// GetSignature returns the signature to append to the captions.
+func (e SushiPornEdition) GetSignature() string {
+	return "@" + e.GetEditionName()
+}
+
+// GetQueueSelection returns the policy used to pick the next post from the queue.
+func (SushiPornEdition) GetQueueSelection() selection.Policy {
+	return selection.Policy{Mode: selection.FIFO}
+}
+
 // GetNewPostingRate returns the new posting rate according to the algorithm
 // and the queue length.
 func (e SushiPornEdition) GetNewPostingRate(queueLength int) time.Duration {
@@ -21,14 +39,13 @@ func (e SushiPornEdition) GetNewPostingRate(queueLength int) time.Duration {
 
 // timeToNextPost returns the estimate time until the next post, given
 // the queue length.
-func (SushiPornEdition) timeToNextPost(queueLength int) time.Duration {
+func (e SushiPornEdition) timeToNextPost(queueLength int) time.Duration {
 
 	if queueLength == 0 {
 		return 0
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	timeToWait := rand.Intn(4) + 4
+	timeToWait := e.random.Intn(4) + 4
 	return time.Duration(timeToWait) * time.Hour
 
 }
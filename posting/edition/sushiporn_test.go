@@ -0,0 +1,26 @@
+package edition
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEditionsAreDeterministic(t *testing.T) {
+
+	first := NewSushiPornEdition(NewRandom(42))
+	second := NewSushiPornEdition(NewRandom(42))
+
+	for i := 1; i <= 10; i++ {
+
+		a, b := first.GetNewPostingRate(i), second.GetNewPostingRate(i)
+		if a != b {
+			t.Fatalf("rate %d: got %s and %s with the same seed", i, a, b)
+		}
+
+		if a < 4*time.Hour || a >= 8*time.Hour {
+			t.Errorf("rate %d: got %s, want between 4h and 8h", i, a)
+		}
+
+	}
+
+}
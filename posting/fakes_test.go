@@ -0,0 +1,442 @@
+package posting
+
+import (
+	"errors"
+	"github.com/bykovme/gotrans"
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	"github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting/clock"
+	"github.com/shitpostingio/autopostingbot/posting/edition"
+	"github.com/shitpostingio/autopostingbot/posting/window"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testChannelID = -1001234567890
+)
+
+var (
+	testStartTime = time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+	errTransient  = errors.New("network unreachable")
+)
+
+// TestMain loads the localization files the posting errors and alerts rely on.
+func TestMain(m *testing.M) {
+
+	err := gotrans.InitLocales("../localization/files")
+	if err != nil {
+		panic(err)
+	}
+
+	localization.SetLanguage("en")
+	os.Exit(m.Run())
+
+}
+
+// fakeStore is an in-memory store.
+// While unavailable, the posts enqueued can't be counted,
+// while failMarking makes marking posts as posted fail.
+type fakeStore struct {
+	mutex       sync.Mutex
+	clock       clock.Clock
+	posts       []*entities.Post
+	states      map[int64]entities.SchedulerState
+	unavailable bool
+	failMarking bool
+}
+
+// fakePublisher records the published posts and the alerts.
+type fakePublisher struct {
+	mutex     sync.Mutex
+	failures  []error
+	published chan entities.Post
+	alerts    []string
+	alertChat int64
+}
+
+// newTestManager creates a Manager for a test channel running the shitpost edition,
+// with simulated time and the input posts enqueued.
+func newTestManager(t *testing.T, posts ...*entities.Post) (*Manager, *fakeStore, *fakePublisher, *clock.Fake) {
+
+	t.Helper()
+
+	//
+	fakeClock := clock.NewFake(testStartTime)
+	s := &fakeStore{clock: fakeClock, posts: posts, states: make(map[int64]entities.SchedulerState)}
+	p := &fakePublisher{published: make(chan entities.Post, 10)}
+
+	//
+	config := &structs.Config{
+		Autoposting: structs.AutopostingConfiguration{
+			MaxPostingAttempts: 3,
+			RetryBackoff:       time.Minute,
+			AlertCooldown:      6 * time.Hour,
+			MaxRetryBackoff:    30 * time.Minute,
+		},
+	}
+
+	m := &Manager{
+		config: config,
+		channel: structs.ChannelConfiguration{
+			Name:               "test",
+			ChannelID:          testChannelID,
+			Edition:            "shitpost",
+			PostAlertThreshold: 2,
+			TimeZone:           "UTC",
+		},
+		random:    edition.NewRandom(1),
+		clock:     fakeClock,
+		store:     s,
+		publisher: p,
+	}
+
+	//
+	err := m.start()
+	if err != nil {
+		t.Fatal("unable to start the manager: ", err)
+	}
+
+	return m, s, p, fakeClock
+
+}
+
+// newTestPost creates a post for the test channel, added the input amount of time
+// before the start of the tests.
+func newTestPost(uniqueID string, addedBefore time.Duration) *entities.Post {
+	return &entities.Post{
+		ID:        primitive.NewObjectID(),
+		ChannelID: testChannelID,
+		Media:     entities.Media{FileUniqueID: uniqueID, Type: "photo"},
+		AddedAt:   testStartTime.Add(-addedBefore),
+	}
+}
+
+// mustWindows creates a posting schedule in UTC with a single window.
+func mustWindows(t *testing.T, start, end string) window.Schedule {
+
+	t.Helper()
+
+	schedule, err := window.New([]structs.PostingWindowConfiguration{{Start: start, End: end}}, "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return schedule
+
+}
+
+// add enqueues a post, as a user adding a media would.
+func (s *fakeStore) add(post *entities.Post) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.posts = append(s.posts, post)
+}
+
+// queue returns the enqueued posts of a channel the policy admits, in queue order.
+func (s *fakeStore) queue(channelID int64, policy selection.Policy) (queue []*entities.Post) {
+
+	for _, post := range s.posts {
+		if post.ChannelID == channelID && post.PostedAt == nil && !post.HasError && post.ScheduledAt == nil && policy.Admits(post) {
+			queue = append(queue, post)
+		}
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+
+		if queue[i].Priority != queue[j].Priority {
+			return queue[i].Priority > queue[j].Priority
+		}
+
+		return queue[i].AddedAt.Before(queue[j].AddedAt)
+
+	})
+
+	return
+
+}
+
+// find returns the stored post with the input ID.
+func (s *fakeStore) find(id primitive.ObjectID) *entities.Post {
+
+	for _, post := range s.posts {
+		if post.ID == id {
+			return post
+		}
+	}
+
+	return nil
+
+}
+
+// ordered returns the enqueued posts of a channel, in the order given by the policy.
+func (s *fakeStore) ordered(channelID int64, policy selection.Policy) []entities.Post {
+
+	var queue, posted []entities.Post
+	for _, post := range s.queue(channelID, policy) {
+		queue = append(queue, *post)
+	}
+
+	for _, post := range s.posts {
+		if post.ChannelID == channelID && post.PostedAt != nil {
+			posted = append(posted, *post)
+		}
+	}
+
+	// Latest first
+	sort.SliceStable(posted, func(i, j int) bool {
+		return posted[i].PostedAt.After(*posted[j].PostedAt)
+	})
+
+	history := selection.History{LastPosted: make(map[int32]time.Time)}
+	for _, post := range posted {
+
+		if _, found := history.LastPosted[post.AddedBy]; !found {
+			history.LastPosted[post.AddedBy] = *post.PostedAt
+		}
+
+		history.RecentTypes = append(history.RecentTypes, post.Media.Type)
+
+	}
+
+	return selection.Order(queue, history, policy)
+
+}
+
+func (s *fakeStore) Available() bool {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return !s.unavailable
+
+}
+
+func (s *fakeStore) GetNextPost(channelID int64, policy selection.Policy) (entities.Post, error) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	queue := s.ordered(channelID, policy)
+	if len(queue) == 0 {
+		return entities.Post{}, storage.ErrNotFound
+	}
+
+	return queue[0], nil
+
+}
+
+func (s *fakeStore) GetQueue(channelID int64, policy selection.Policy) ([]entities.Post, error) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ordered(channelID, policy), nil
+
+}
+
+func (s *fakeStore) GetQueueLength(channelID int64, policy selection.Policy) int64 {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.unavailable {
+		return storage.UnknownQueueLength
+	}
+
+	return int64(len(s.queue(channelID, policy)))
+
+}
+
+func (s *fakeStore) GetScheduledPosts(channelID int64) (posts []entities.Post, err error) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, post := range s.posts {
+		if post.ChannelID == channelID && post.PostedAt == nil && !post.HasError && post.ScheduledAt != nil {
+			posts = append(posts, *post)
+		}
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].ScheduledAt.Before(*posts[j].ScheduledAt)
+	})
+
+	return
+
+}
+
+func (s *fakeStore) SchedulePostByUniqueID(channelID int64, uniqueID string, scheduledAt *time.Time) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, post := range s.posts {
+		if post.ChannelID == channelID && post.Media.FileUniqueID == uniqueID {
+			post.ScheduledAt = scheduledAt
+			return nil
+		}
+	}
+
+	return errors.New("post not found")
+
+}
+
+func (s *fakeStore) MarkPostAsPosted(post *entities.Post, messageID int) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.failMarking {
+		return errTransient
+	}
+
+	stored := s.find(post.ID)
+	if stored == nil {
+		return errors.New("post not found")
+	}
+
+	now := s.clock.Now()
+	stored.PostedAt = &now
+	stored.MessageID = int64(messageID)
+	return nil
+
+}
+
+func (s *fakeStore) RecordFailedAttempt(post *entities.Post, lastError string, permanent bool) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := s.find(post.ID)
+	if stored == nil {
+		return errors.New("post not found")
+	}
+
+	now := s.clock.Now()
+	stored.FailedAttempts++
+	stored.LastError = lastError
+	stored.LastAttemptAt = &now
+	stored.HasError = permanent
+	return nil
+
+}
+
+func (s *fakeStore) SaveSchedulerState(state entities.SchedulerState) error {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.states[state.ChannelID] = state
+	return nil
+
+}
+
+func (s *fakeStore) GetSchedulerState(channelID int64) (entities.SchedulerState, error) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, found := s.states[channelID]
+	if !found {
+		return state, errors.New("no state saved")
+	}
+
+	return state, nil
+
+}
+
+// failNext makes the next publishing attempts fail with the input errors.
+func (p *fakePublisher) failNext(errs ...error) {
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.failures = append(p.failures, errs...)
+
+}
+
+func (p *fakePublisher) Publish(_ int64, post *entities.Post, _ string) (int64, error) {
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.failures) > 0 {
+		err := p.failures[0]
+		p.failures = p.failures[1:]
+		return 0, err
+	}
+
+	p.published <- *post
+	return int64(len(p.published)), nil
+
+}
+
+func (p *fakePublisher) Archive(*entities.Post, string) error {
+	return nil
+}
+
+func (p *fakePublisher) Alert(chatID int64, text string) {
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.alerts = append(p.alerts, text)
+	p.alertChat = chatID
+
+}
+
+// lastAlert returns the last alert sent and the chat it was sent to.
+func (p *fakePublisher) lastAlert() (int64, string) {
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.alerts) == 0 {
+		return 0, ""
+	}
+
+	return p.alertChat, p.alerts[len(p.alerts)-1]
+
+}
+
+// alertCount returns the number of alerts sent.
+func (p *fakePublisher) alertCount() int {
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.alerts)
+
+}
+
+// waitForPost waits for a post to be published, failing the test after a timeout.
+func (p *fakePublisher) waitForPost(t *testing.T) entities.Post {
+
+	t.Helper()
+
+	select {
+	case post := <-p.published:
+		return post
+	case <-time.After(time.Second):
+		t.Fatal("no post published")
+	}
+
+	return entities.Post{}
+
+}
+
+// assertNoPost fails the test if a post gets published.
+func (p *fakePublisher) assertNoPost(t *testing.T) {
+
+	t.Helper()
+
+	select {
+	case post := <-p.published:
+		t.Fatal("unexpected post published: ", post.Media.FileUniqueID)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+}
@@ -9,9 +9,9 @@ import (
 	"strings"
 )
 
-// moveToDirectory moves a file from the Tdlib directory to the
+// Archive moves a file from the Tdlib directory to the
 // persistent directory specified in the configuration file.
-func moveToDirectory(post *entities.Post) error {
+func (telegramPublisher) Archive(post *entities.Post, mediaPath string) error {
 
 	//
 	file, err := api.DownloadFile(post.Media.TdlibID)
@@ -26,7 +26,7 @@ func moveToDirectory(post *entities.Post) error {
 	extension := fileName[strings.LastIndex(fileName, ".")+1:]
 	log.Debugln("Extension: ", extension)
 
-	err = os.Rename(file.Local.Path, fmt.Sprintf("%s/%s.%s", m.config.Autoposting.MediaPath, post.Media.FileID, extension))
+	err = os.Rename(file.Local.Path, fmt.Sprintf("%s/%s.%s", mediaPath, post.Media.FileID, extension))
 	return err
 
 }
@@ -0,0 +1,169 @@
+package posting
+
+import (
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/posting/clock"
+	"github.com/shitpostingio/autopostingbot/posting/edition"
+	"sort"
+	"time"
+)
+
+// Slot represents a post in the projected posting timeline of a channel.
+type Slot struct {
+
+	// Post is the post that will be posted.
+	Post entities.Post
+
+	// Time is the estimated posting time.
+	Time time.Time
+
+	// Pinned is true if the post is pinned to its time.
+	Pinned bool
+}
+
+// Forecast projects the posting timeline of a channel, returning at most limit
+// posts with their estimated posting time, assuming no other post is added.
+func Forecast(channelID int64, limit int) ([]Slot, error) {
+
+	//
+	m, err := getManager(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	//
+	s := m.getSchedule()
+	timeline, err := m.forecast(s.nextPostScheduled, s.pausedIndefinitely)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(timeline) > limit {
+		timeline = timeline[:limit]
+	}
+
+	return timeline, nil
+
+}
+
+// Simulate projects the whole posting timeline of a channel reading
+// the document store, without starting a posting manager nor posting anything.
+// It's meant to be used while the bot is not running.
+func Simulate(config *structs.Config, channel structs.ChannelConfiguration) ([]Slot, error) {
+
+	//
+	m := &Manager{
+		config:  config,
+		channel: channel,
+		random:  edition.NewRandom(time.Now().UnixNano()),
+		clock:   clock.Real{},
+		store:   newRepositoryStore(),
+	}
+
+	err := m.load()
+	if err != nil {
+		return nil, err
+	}
+
+	//
+	nextPost := m.projectNextPost()
+	return m.forecast(nextPost, m.pausedIndefinitely)
+
+}
+
+// forecast projects the posting timeline of the channel, given the time of the next post
+// and whether the posting is paused until resumed.
+// Posts follow each other as the edition would schedule them if no other post was added,
+// while pinned posts are posted at their time.
+func (m *Manager) forecast(nextPost time.Time, pausedIndefinitely bool) ([]Slot, error) {
+
+	// The simulation draws from its own edition, so that
+	// the random values of the manager are not consumed
+	e, err := loadEdition(m.config, m.channel.Edition, edition.NewRandom(m.clock.Now().UnixNano()))
+	if err != nil {
+		return nil, err
+	}
+
+	//
+	queue, err := m.store.GetQueue(m.channel.ChannelID, m.withCampaign(e.GetQueueSelection()))
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing but the pinned posts will be posted until the posting is resumed
+	if pausedIndefinitely {
+		queue = nil
+	}
+
+	pinnedPosts, err := m.store.GetScheduledPosts(m.channel.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+
+	//
+	now := m.clock.Now()
+	if nextPost.Before(now) {
+		nextPost = m.windows.Next(now)
+	}
+
+	// After each post, the edition computes the posting rate given the remaining posts
+	timeline := make([]Slot, 0, len(queue)+len(pinnedPosts))
+	postTime := nextPost
+	for i, post := range queue {
+
+		if i > 0 {
+
+			interval := e.GetNewPostingRate(len(queue) - i)
+			if interval <= minIntervalBetweenPosts {
+				interval = minIntervalBetweenPosts
+			}
+
+			postTime = m.windows.Next(postTime.Add(interval))
+
+		}
+
+		timeline = append(timeline, Slot{Post: post, Time: postTime})
+
+	}
+
+	// Pinned posts that are due will be posted right away
+	for _, post := range pinnedPosts {
+
+		pinTime := *post.ScheduledAt
+		if pinTime.Before(now) {
+			pinTime = now
+		}
+
+		timeline = append(timeline, Slot{Post: post, Time: pinTime, Pinned: true})
+
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Time.Before(timeline[j].Time)
+	})
+
+	return timeline, nil
+
+}
+
+// projectNextPost returns the time of the next post as the manager
+// would schedule it when starting, without saving the scheduler state.
+func (m *Manager) projectNextPost() time.Time {
+
+	//
+	state, err := m.store.GetSchedulerState(m.channel.ChannelID)
+	if err == nil {
+		m.pausedUntil = state.PausedUntil
+		m.pausedIndefinitely = state.PausedIndefinitely
+	}
+
+	if err == nil && state.NextPostScheduled.After(m.clock.Now()) {
+		return m.windows.Next(state.NextPostScheduled)
+	}
+
+	//
+	queueLength := m.store.GetQueueLength(m.channel.ChannelID, m.policy())
+	return m.windows.Next(latest(m.clock.Now().Add(m.postingRateFor(queueLength)), m.pausedUntil))
+
+}
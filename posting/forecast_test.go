@@ -0,0 +1,74 @@
+package posting
+
+import (
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"testing"
+	"time"
+)
+
+func TestForecast(t *testing.T) {
+
+	pinned := newTestPost("pinned", time.Hour)
+	pinTime := testStartTime.Add(90 * time.Minute)
+	pinned.ScheduledAt = &pinTime
+
+	m, s, p, _ := newTestManager(t,
+		newTestPost("a", 3*time.Hour),
+		newTestPost("b", 2*time.Hour),
+		newTestPost("c", time.Hour),
+		pinned)
+
+	timeline, err := m.forecast(m.nextPostScheduled, m.pausedIndefinitely)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The shitpost edition posts once per hour, pinned posts keep their time
+	want := []struct {
+		uniqueID string
+		time     time.Time
+		pinned   bool
+	}{
+		{"a", testStartTime.Add(time.Hour), false},
+		{"pinned", pinTime, true},
+		{"b", testStartTime.Add(2 * time.Hour), false},
+		{"c", testStartTime.Add(3 * time.Hour), false},
+	}
+
+	if len(timeline) != len(want) {
+		t.Fatalf("timeline: got %d posts, want %d", len(timeline), len(want))
+	}
+
+	for i, slot := range timeline {
+		if slot.Post.Media.FileUniqueID != want[i].uniqueID || !slot.Time.Equal(want[i].time) || slot.Pinned != want[i].pinned {
+			t.Errorf("slot %d: got %s at %s (pinned %t), want %s at %s (pinned %t)", i,
+				slot.Post.Media.FileUniqueID, slot.Time, slot.Pinned, want[i].uniqueID, want[i].time, want[i].pinned)
+		}
+	}
+
+	// Nothing must be posted
+	if len(p.published) != 0 || s.GetQueueLength(testChannelID, selection.Policy{}) != 3 {
+		t.Errorf("the forecast posted: %d published, %d enqueued", len(p.published), s.GetQueueLength(testChannelID, selection.Policy{}))
+	}
+
+}
+
+func TestForecastSkipsClosedWindows(t *testing.T) {
+
+	m, _, _, _ := newTestManager(t,
+		newTestPost("a", 2*time.Hour),
+		newTestPost("b", time.Hour))
+
+	// Posting is only allowed from 12 to 13
+	m.windows = mustWindows(t, "12:00", "13:00")
+	timeline, err := m.forecast(testStartTime.Add(30*time.Minute), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Date(2020, time.June, 2, 12, 0, 0, 0, time.UTC)
+	if len(timeline) != 2 || !timeline[1].Time.Equal(want) {
+		t.Fatalf("timeline: got %v, want the second post at %s", timeline, want)
+	}
+
+}
@@ -1,16 +1,25 @@
 package posting
 
 import (
+	"context"
+	"fmt"
 	"github.com/shitpostingio/autopostingbot/config/structs"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"github.com/shitpostingio/autopostingbot/posting/campaign"
+	"github.com/shitpostingio/autopostingbot/posting/clock"
 	"github.com/shitpostingio/autopostingbot/posting/edition"
+	"github.com/shitpostingio/autopostingbot/posting/window"
 	log "github.com/sirupsen/logrus"
+	"math/rand"
+	"sync"
 	"time"
 )
 
-// Manager is the posting manager.
+// Manager is the posting manager of a channel.
 // It contains information on posting rates, scheduling and the running edition.
 type Manager struct {
 	config      *structs.Config
+	channel     structs.ChannelConfiguration
 	isDebugging bool
 
 	/* POSTING */
@@ -18,72 +27,327 @@ type Manager struct {
 	previousPostTime  time.Time
 	previousPauseTime time.Time
 	postingRate       time.Duration
+	unmarkedPosts     []unmarkedPost
+
+	/* PAUSE */
+	pausedUntil        time.Time
+	pausedIndefinitely bool
+	pausedBy           int32
+
+	/* ALERTS */
+	alertLevel         int
+	alertedAt          time.Time
+	alertedQueueLength int
+
+	/* SNAPSHOT */
+	snapshotMutex sync.RWMutex
+	snapshot      schedule
+
+	//
+	e      edition.Edition
+	random *rand.Rand
+
+	//
+	windows   window.Schedule
+	campaigns campaign.Calendar
 
 	//
-	e edition.Edition
+	clock       clock.Clock
+	timer       clock.Timer
+	pinnedTimer clock.Timer
 
 	//
-	timer *time.Timer
+	store     store
+	publisher publisher
 
 	//
-	requestPostChannel  chan RequestPostStruct
-	requestPauseChannel chan RequestPauseStruct
+	requestPostChannel       chan RequestPostStruct
+	requestPauseChannel      chan RequestPauseStruct
+	requestPinChannel        chan RequestPinStruct
+	requestResumeChannel     chan RequestResumeStruct
+	requestRescheduleChannel chan RequestRescheduleStruct
+}
+
+// schedule is a snapshot of the posting schedule of a Manager.
+// The Manager goroutine publishes it for the other goroutines to read,
+// as they can't access the fields it writes.
+type schedule struct {
+	nextPostScheduled  time.Time
+	postingRate        time.Duration
+	pausedUntil        time.Time
+	pausedIndefinitely bool
+	pausedBy           int32
 }
 
 var (
-	m        Manager
-	editions = map[string]edition.Edition{
-		"shitpost":  edition.ShitpostEdition{},
-		"sushiporn": edition.SushiPornEdition{},
+	managers map[int64]*Manager
+	channels []structs.ChannelConfiguration
+	editions = map[string]func(random *rand.Rand) edition.Edition{
+		"shitpost": func(*rand.Rand) edition.Edition {
+			return edition.ShitpostEdition{}
+		},
+		"sushiporn": func(random *rand.Rand) edition.Edition {
+			return edition.NewSushiPornEdition(random)
+		},
 	}
 )
 
-// Start sets the Manager up and starts the post scheduling.
+// Start sets a Manager up for each configured channel and starts the post scheduling.
+// It must be called before the updates are handled, since the Managers
+// are made available only once all of them are set up.
 func Start(config *structs.Config, debug bool) {
 
 	//
-	m.config = config
-	m.isDebugging = debug
+	configuredChannels := config.Autoposting.GetChannels()
+	startedManagers := make(map[int64]*Manager, len(configuredChannels))
+
+	//
+	for _, channel := range configuredChannels {
+
+		m := &Manager{
+			config:      config,
+			channel:     channel,
+			isDebugging: debug,
+			random:      edition.NewRandom(time.Now().UnixNano()),
+			clock:       clock.Real{},
+			store:       newRepositoryStore(),
+			publisher:   telegramPublisher{},
+		}
+
+		err := m.start()
+		if err != nil {
+			log.Fatal("unable to start the posting manager of channel ", channel.Name, ": ", err)
+		}
+
+		startedManagers[channel.ChannelID] = m
+
+	}
+
+	//
+	channels = configuredChannels
+	managers = startedManagers
+
+}
+
+// start loads the edition, the posting windows and the campaigns
+// of the Manager and starts its post scheduling.
+func (m *Manager) start() error {
 
 	//
-	var found bool
-	m.e, found = editions[config.Autoposting.Edition]
-	if !found {
-		log.Fatal("edition not found")
+	err := m.load()
+	if err != nil {
+		return err
 	}
 
 	//
 	m.requestPostChannel = make(chan RequestPostStruct)
 	m.requestPauseChannel = make(chan RequestPauseStruct)
-	m.timer = time.NewTimer(time.Minute)
+	m.requestPinChannel = make(chan RequestPinStruct)
+	m.requestResumeChannel = make(chan RequestResumeStruct)
+	m.requestRescheduleChannel = make(chan RequestRescheduleStruct)
+	m.timer = m.clock.NewTimer(time.Minute)
+	m.pinnedTimer = m.clock.NewTimer(time.Minute)
+
+	// Restore the previous scheduling, if any, so that restarts
+	// don't reset active pauses and the posting cadence
+	if !m.restoreState() {
+		m.schedulePosting(time.Unix(0, 0))
+	}
 
 	//
-	ForcePostScheduling()
+	m.armPinnedTimer()
+	m.publishSchedule()
+	return nil
 
 }
 
-// Listen makes the Manager listen for posting and pause requests.
-func Listen() {
+// load loads the edition, the posting windows and the campaigns of the Manager.
+func (m *Manager) load() error {
+
+	//
+	var err error
+	m.e, err = loadEdition(m.config, m.channel.Edition, m.random)
+	if err != nil {
+		return err
+	}
+
+	//
+	m.windows, err = window.New(m.channel.PostingWindows, m.channel.TimeZone)
+	if err != nil {
+		return fmt.Errorf("unable to load posting windows: %v", err)
+	}
+
+	//
+	m.campaigns, err = campaign.New(m.config.Campaigns, m.channel.Name, m.windows.Location())
+	if err != nil {
+		return fmt.Errorf("unable to load campaigns: %v", err)
+	}
+
+	return nil
+
+}
+
+// loadEdition returns the edition with the input name, looking for it
+// first among the built-in ones and then among the ones defined in the
+// configuration file. The edition will draw its random values from random.
+func loadEdition(config *structs.Config, name string, random *rand.Rand) (edition.Edition, error) {
+
+	//
+	newEdition, found := editions[name]
+	if found {
+		return newEdition(random), nil
+	}
+
+	//
+	for _, editionConfig := range config.Editions {
+		if editionConfig.Name == name {
+			return edition.NewConfigurableEdition(editionConfig, random)
+		}
+	}
+
+	return nil, fmt.Errorf("edition %s not found", name)
+
+}
+
+// Listen makes the Managers listen for posting, pause and pin requests,
+// each one in its own goroutine, until the context is cancelled.
+// It returns once every Manager has finished its in-flight operations
+// and persisted its state.
+func Listen(ctx context.Context) {
+
+	var wg sync.WaitGroup
+	for _, m := range managers {
+
+		wg.Add(1)
+		go func(m *Manager) {
+			defer wg.Done()
+			m.listen(ctx)
+		}(m)
+
+	}
+
+	wg.Wait()
+
+}
+
+// listen makes the Manager listen for posting, pause, pin and reschedule requests
+// until the context is cancelled.
+func (m *Manager) listen(ctx context.Context) {
 
 	var err error
 
 	for {
+
+		var reply chan error
 		select {
 
+		case <-ctx.Done():
+			m.stop()
+			return
+
 		case postRequest := <-m.requestPostChannel:
-			err = tryPosting(postRequest.Post)
-			postRequest.ErrorChan <- err
+			err = m.tryPosting(postRequest.Post)
+			reply = postRequest.ErrorChan
 		case pauseRequest := <-m.requestPauseChannel:
-			err = tryPausing(pauseRequest.Duration)
-			pauseRequest.ErrorChan <- err
-		case <-m.timer.C:
-			err = postScheduled()
+			err = m.tryPausing(pauseRequest.Until, pauseRequest.PausedBy)
+			reply = pauseRequest.ErrorChan
+		case resumeRequest := <-m.requestResumeChannel:
+			err = m.tryResuming()
+			reply = resumeRequest.ErrorChan
+		case pinRequest := <-m.requestPinChannel:
+			err = m.tryPinning(pinRequest.Post, pinRequest.Time)
+			reply = pinRequest.ErrorChan
+		case rescheduleRequest := <-m.requestRescheduleChannel:
+			m.schedulePosting(time.Unix(0, 0))
+			err = nil
+			reply = rescheduleRequest.ErrorChan
+		case <-m.timer.C():
+			err = m.postScheduled()
+		case <-m.pinnedTimer.C():
+			err = m.postPinned()
+		}
+
+		// The schedule is published before replying,
+		// so that the requesters read the one they caused
+		m.publishSchedule()
+		if reply != nil {
+			reply <- err
 		}
 
 		if err != nil {
-			log.Error(err)
+			log.Error(m.channel.Name, ": ", err)
 		}
 
 	}
 
 }
+
+// stop stops the timers of the Manager and persists its state.
+func (m *Manager) stop() {
+
+	stopTimer(m.timer)
+	stopTimer(m.pinnedTimer)
+	m.saveState()
+	log.Info("Posting manager of channel ", m.channel.Name, " stopped")
+
+}
+
+// publishSchedule publishes a snapshot of the posting schedule for the other goroutines.
+// It must be called by the Manager goroutine.
+func (m *Manager) publishSchedule() {
+
+	m.snapshotMutex.Lock()
+	defer m.snapshotMutex.Unlock()
+
+	m.snapshot = schedule{
+		nextPostScheduled:  m.nextPostScheduled,
+		postingRate:        m.postingRate,
+		pausedUntil:        m.pausedUntil,
+		pausedIndefinitely: m.pausedIndefinitely,
+		pausedBy:           m.pausedBy,
+	}
+
+}
+
+// getSchedule returns the last posting schedule published by the Manager goroutine.
+func (m *Manager) getSchedule() schedule {
+
+	m.snapshotMutex.RLock()
+	defer m.snapshotMutex.RUnlock()
+	return m.snapshot
+
+}
+
+// isPaused returns true if the schedule has an active pause at the input time.
+func (s schedule) isPaused(now time.Time) bool {
+	return s.pausedIndefinitely || now.Before(s.pausedUntil)
+}
+
+// policy returns the policy used to pick the next post from the queue,
+// drawing from the themed queue of the campaign running, if any.
+func (m *Manager) policy() selection.Policy {
+	return m.withCampaign(m.e.GetQueueSelection())
+}
+
+// withCampaign adds the campaign running on the channel to the input policy.
+func (m *Manager) withCampaign(policy selection.Policy) selection.Policy {
+
+	running, found := m.campaigns.Running(m.clock.Now())
+	if found {
+		policy.Campaign = running.Tag
+		policy.Exclusive = running.Exclusive
+	}
+
+	return policy
+
+}
+
+// since returns the time elapsed since t, according to the Manager clock.
+func (m *Manager) since(t time.Time) time.Duration {
+	return m.clock.Now().Sub(t)
+}
+
+// until returns the duration until t, according to the Manager clock.
+func (m *Manager) until(t time.Time) time.Duration {
+	return t.Sub(m.clock.Now())
+}
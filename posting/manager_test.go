@@ -0,0 +1,307 @@
+package posting
+
+import (
+	"context"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"github.com/shitpostingio/autopostingbot/posting/edition"
+	"testing"
+	"time"
+)
+
+// listen starts the Listen loop on the input manager.
+func listen(m *Manager) {
+	managers = map[int64]*Manager{m.channel.ChannelID: m}
+	go Listen(context.Background())
+}
+
+func TestListenPostsOnSchedule(t *testing.T) {
+
+	m, s, p, fakeClock := newTestManager(t,
+		newTestPost("a", 3*time.Hour),
+		newTestPost("b", 2*time.Hour),
+		newTestPost("c", time.Hour))
+	listen(m)
+
+	// Nothing is posted before the scheduled time
+	fakeClock.Advance(59 * time.Minute)
+	p.assertNoPost(t)
+
+	//
+	fakeClock.Advance(time.Minute)
+	if post := p.waitForPost(t); post.Media.FileUniqueID != "a" {
+		t.Errorf("first post: got %s, want a", post.Media.FileUniqueID)
+	}
+
+	// The resume request makes sure the manager is done with the previous post
+	_ = RequestResume(testChannelID)
+	fakeClock.Advance(time.Hour)
+	if post := p.waitForPost(t); post.Media.FileUniqueID != "b" {
+		t.Errorf("second post: got %s, want b", post.Media.FileUniqueID)
+	}
+
+	_ = RequestResume(testChannelID)
+	if s.GetQueueLength(testChannelID, selection.Policy{}) != 1 {
+		t.Errorf("queue length: got %d, want 1", s.GetQueueLength(testChannelID, selection.Policy{}))
+	}
+
+}
+
+func TestListenRetriesFailedPosts(t *testing.T) {
+
+	m, _, p, fakeClock := newTestManager(t,
+		newTestPost("a", 3*time.Hour),
+		newTestPost("b", 2*time.Hour),
+		newTestPost("c", time.Hour))
+	listen(m)
+
+	//
+	p.failNext(errTransient)
+	fakeClock.Advance(time.Hour)
+	p.assertNoPost(t)
+
+	// The first retry happens after the retry backoff
+	fakeClock.Advance(time.Minute)
+	if post := p.waitForPost(t); post.Media.FileUniqueID != "a" {
+		t.Errorf("retried post: got %s, want a", post.Media.FileUniqueID)
+	}
+
+}
+
+func TestListenHandlesRequests(t *testing.T) {
+
+	post := newTestPost("b", 2*time.Hour)
+	m, _, p, fakeClock := newTestManager(t, newTestPost("a", 3*time.Hour), post, newTestPost("c", time.Hour))
+	listen(m)
+
+	// Posts can be sent ahead of the schedule
+	err := RequestPost(post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if published := p.waitForPost(t); published.Media.FileUniqueID != "b" {
+		t.Errorf("published post: got %s, want b", published.Media.FileUniqueID)
+	}
+
+	// Manual posts restart the schedule
+	if want := fakeClock.Now().Add(time.Hour); !GetNextPostTime(testChannelID).Equal(want) {
+		t.Errorf("next post: got %s, want %s", GetNextPostTime(testChannelID), want)
+	}
+
+	// Paused posting resumes later
+	err = RequestPause(testChannelID, fakeClock.Now().Add(2*time.Hour), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fakeClock.Advance(time.Hour)
+	p.assertNoPost(t)
+
+	fakeClock.Advance(time.Hour)
+	if published := p.waitForPost(t); published.Media.FileUniqueID != "a" {
+		t.Errorf("published post: got %s, want a", published.Media.FileUniqueID)
+	}
+
+}
+
+func TestListenReschedulesWhenPostsAreAdded(t *testing.T) {
+
+	m, s, p, fakeClock := newTestManager(t)
+	listen(m)
+
+	// The empty queue leaves the manager waiting
+	fakeClock.Advance(2 * time.Hour)
+	p.assertNoPost(t)
+
+	// Adding a post wakes the manager up with the new schedule
+	s.add(newTestPost("a", 0))
+	ForcePostScheduling(testChannelID)
+	if want := fakeClock.Now().Add(time.Hour); !GetNextPostTime(testChannelID).Equal(want) {
+		t.Errorf("next post: got %s, want %s", GetNextPostTime(testChannelID), want)
+	}
+
+	fakeClock.Advance(time.Hour)
+	if published := p.waitForPost(t); published.Media.FileUniqueID != "a" {
+		t.Errorf("published post: got %s, want a", published.Media.FileUniqueID)
+	}
+
+}
+
+func TestListenPausesUntilResumed(t *testing.T) {
+
+	m, _, p, fakeClock := newTestManager(t, newTestPost("a", 2*time.Hour), newTestPost("b", time.Hour))
+	listen(m)
+
+	//
+	err := RequestPause(testChannelID, time.Time{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fakeClock.Advance(24 * time.Hour)
+	p.assertNoPost(t)
+
+	// Resuming posts right away, as the scheduled time has long passed
+	err = RequestResume(testChannelID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if published := p.waitForPost(t); published.Media.FileUniqueID != "a" {
+		t.Errorf("published post: got %s, want a", published.Media.FileUniqueID)
+	}
+
+}
+
+func TestEstimatePostTimeDuringPauses(t *testing.T) {
+
+	m, _, p, fakeClock := newTestManager(t, newTestPost("a", 2*time.Hour), newTestPost("b", time.Hour))
+	listen(m)
+
+	// The estimates start from the end of the pause
+	until := fakeClock.Now().Add(5 * time.Hour)
+	err := RequestPause(testChannelID, until, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pause, paused := GetPause(testChannelID); !paused || !pause.Until.Equal(until) {
+		t.Errorf("pause: got %t until %s, want until %s", paused, pause.Until, until)
+	}
+
+	if postTime, paused := EstimatePostTime(testChannelID, 1); paused || !postTime.Equal(until) {
+		t.Errorf("estimate during a pause: got %s, paused %t, want %s", postTime, paused, until)
+	}
+
+	// Nothing can be estimated until the posting is resumed
+	fakeClock.Advance(10 * time.Minute)
+	p.assertNoPost(t)
+
+	err = RequestPause(testChannelID, time.Time{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if postTime, paused := EstimatePostTime(testChannelID, 1); !paused || !postTime.IsZero() {
+		t.Errorf("estimate during an indefinite pause: got %s, paused %t, want paused", postTime, paused)
+	}
+
+}
+
+func TestListenPostsPinnedPosts(t *testing.T) {
+
+	pinned := newTestPost("c", time.Hour)
+	m, _, p, fakeClock := newTestManager(t, newTestPost("a", 3*time.Hour), newTestPost("b", 2*time.Hour), pinned)
+	listen(m)
+
+	//
+	pinTime := fakeClock.Now().Add(30 * time.Minute)
+	err := RequestPin(pinned, &pinTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pinned posts ignore the posting rate
+	fakeClock.Advance(30 * time.Minute)
+	if published := p.waitForPost(t); published.Media.FileUniqueID != "c" {
+		t.Errorf("published post: got %s, want c", published.Media.FileUniqueID)
+	}
+
+	// Pinned posts in the past are rejected
+	err = RequestPin(newTestPost("a", 0), &pinTime)
+	if err == nil {
+		t.Error("pinning a post in the past succeeded")
+	}
+
+}
+
+func TestListenStopsWhenCancelled(t *testing.T) {
+
+	m, s, p, fakeClock := newTestManager(t, newTestPost("a", 2*time.Hour), newTestPost("b", time.Hour))
+	managers = map[int64]*Manager{m.channel.ChannelID: m}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Listen(ctx)
+		close(done)
+	}()
+
+	//
+	err := RequestPause(testChannelID, time.Time{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Listen did not return after the context was cancelled")
+	}
+
+	// The state is persisted and nothing is posted anymore
+	state, err := s.GetSchedulerState(testChannelID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !state.PausedIndefinitely {
+		t.Error("the pause was not persisted")
+	}
+
+	fakeClock.Advance(24 * time.Hour)
+	p.assertNoPost(t)
+
+}
+
+func TestListenKeepsChannelsApart(t *testing.T) {
+
+	const otherChannelID = -1009876543210
+
+	other := newTestPost("b", 2*time.Hour)
+	other.ChannelID = otherChannelID
+	m, s, p, fakeClock := newTestManager(t, newTestPost("a", 3*time.Hour), other)
+
+	// A second manager sharing the store, the publisher and the clock
+	o := &Manager{
+		config:    m.config,
+		channel:   m.channel,
+		random:    edition.NewRandom(2),
+		clock:     fakeClock,
+		store:     s,
+		publisher: p,
+	}
+
+	o.channel.Name = "other"
+	o.channel.ChannelID = otherChannelID
+	err := o.start()
+	if err != nil {
+		t.Fatal("unable to start the manager: ", err)
+	}
+
+	managers = map[int64]*Manager{testChannelID: m, otherChannelID: o}
+	go Listen(context.Background())
+
+	// Pausing a channel leaves the other one posting its own queue
+	err = RequestPause(testChannelID, time.Time{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fakeClock.Advance(24 * time.Hour)
+	if published := p.waitForPost(t); published.ChannelID != otherChannelID || published.Media.FileUniqueID != "b" {
+		t.Errorf("published post: got %s on %d, want b on %d", published.Media.FileUniqueID, published.ChannelID, otherChannelID)
+	}
+
+	p.assertNoPost(t)
+	if length := s.GetQueueLength(testChannelID, selection.Policy{}); length != 1 {
+		t.Errorf("queue length: got %d, want 1", length)
+	}
+
+	// Unknown channels are rejected
+	if RequestResume(-1) == nil {
+		t.Error("resuming an unknown channel succeeded")
+	}
+
+}
@@ -0,0 +1,143 @@
+package posting
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// tryPinning tries to pin a post to a specific time, or to unpin it if
+// pinTime is nil. Pinned posts are posted at their time regardless of the
+// edition posting rate, the posting windows and pauses.
+func (m *Manager) tryPinning(post *entities.Post, pinTime *time.Time) error {
+
+	//
+	if post.PostedAt != nil {
+		return errors.New(l.GetString(l.POSTING_POSTING_ALREADY_POSTED))
+	}
+
+	//
+	if pinTime != nil && !pinTime.After(m.clock.Now()) {
+		return fmt.Errorf(l.GetString(l.POSTING_POSTING_PIN_IN_THE_PAST), pinTime.Format("15:04"))
+	}
+
+	//
+	err := m.store.SchedulePostByUniqueID(post.ChannelID, post.Media.FileUniqueID, pinTime)
+	if err != nil {
+		return err
+	}
+
+	//
+	m.armPinnedTimer()
+	return nil
+
+}
+
+// armPinnedTimer sets the pinned posts timer to fire at the time of the closest
+// pinned post, returning true if it's already due. If the pinned posts
+// can't be retrieved, they're checked again after the minimum interval between posts.
+func (m *Manager) armPinnedTimer() (due bool) {
+
+	//
+	stopTimer(m.pinnedTimer)
+
+	//
+	posts, err := m.store.GetScheduledPosts(m.channel.ChannelID)
+	if err != nil {
+		log.Error("Unable to retrieve the pinned posts: ", err)
+		m.pinnedTimer = m.clock.NewTimer(minIntervalBetweenPosts)
+		return false
+	}
+
+	//
+	if len(posts) == 0 {
+		return false
+	}
+
+	wait := m.until(*posts[0].ScheduledAt)
+	m.pinnedTimer = m.clock.NewTimer(wait)
+	return wait <= 0
+
+}
+
+// retryPinned sets the pinned posts timer to fire
+// after the minimum interval between posts.
+func (m *Manager) retryPinned() {
+	stopTimer(m.pinnedTimer)
+	m.pinnedTimer = m.clock.NewTimer(minIntervalBetweenPosts)
+}
+
+// postPinned posts the pinned posts whose time has come.
+func (m *Manager) postPinned() error {
+
+	// The pinned posts are checked again later if they can't be retrieved
+	// or if the posts already published can't be marked as posted
+	if !m.store.Available() {
+		m.retryPinned()
+		return errors.New(l.GetString(l.POSTING_POSTING_STORAGE_UNAVAILABLE))
+	}
+
+	err := m.markPublishedPosts()
+	if err != nil {
+		m.retryPinned()
+		return err
+	}
+
+	posts, err := m.store.GetScheduledPosts(m.channel.ChannelID)
+	if err != nil {
+		m.retryPinned()
+		return err
+	}
+
+	//
+	failed := false
+	for _, post := range posts {
+
+		if post.ScheduledAt.After(m.clock.Now()) {
+			break
+		}
+
+		//
+		err = m.sendPost(&post)
+		if err == nil {
+			continue
+		}
+
+		failed = true
+		log.Error("Unable to post pinned post ", post.ID, ": ", err)
+
+		// Posts that won't be retried are marked as failed
+		var pe *postingError
+		if errors.As(err, &pe) && !pe.retry {
+			continue
+		}
+
+		// Pinned posts that will be retried or that were sent but not marked
+		// as posted are moved forward, so that the timer doesn't fire continuously
+		retryTime := m.clock.Now().Add(minIntervalBetweenPosts)
+		if pe != nil {
+			retryTime = m.clock.Now().Add(m.retryBackoff(pe.attempt))
+		}
+
+		_ = m.store.SchedulePostByUniqueID(post.ChannelID, post.Media.FileUniqueID, &retryTime)
+
+		// Nothing else is posted until the post is marked as posted
+		var me *markingError
+		if errors.As(err, &me) {
+			break
+		}
+
+	}
+
+	// Failed posts that are still due because they couldn't be moved forward
+	// or marked as failed are checked again later
+	if m.armPinnedTimer() && failed {
+		m.retryPinned()
+	}
+
+	return nil
+
+}
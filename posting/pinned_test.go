@@ -0,0 +1,96 @@
+package posting
+
+import (
+	"github.com/zelenin/go-tdlib/client"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPostPinnedMovesFailedPostsForward(t *testing.T) {
+
+	pinned := newTestPost("c", time.Hour)
+	m, s, p, fakeClock := newTestManager(t, newTestPost("a", 2*time.Hour), pinned)
+
+	pinTime := fakeClock.Now().Add(30 * time.Minute)
+	err := m.tryPinning(pinned, &pinTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		failure   error
+		wantDelay time.Duration
+		wantError bool
+	}{
+		{"transient failures wait for the retry backoff", errTransient, time.Minute, false},
+		{"the backoff grows with the attempts", errTransient, 2 * time.Minute, false},
+		{"permanent failures mark the post as failed", client.ResponseError{Err: &client.Error{Code: http.StatusBadRequest, Message: "MEDIA_INVALID"}}, 0, true},
+	}
+
+	for _, test := range tests {
+
+		fakeClock.Advance(m.until(*s.find(pinned.ID).ScheduledAt))
+		p.failNext(test.failure)
+		if err := m.postPinned(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		stored := s.find(pinned.ID)
+		if stored.HasError != test.wantError {
+			t.Errorf("%s: failed: got %t, want %t", test.name, stored.HasError, test.wantError)
+		}
+
+		if !test.wantError && !stored.ScheduledAt.Equal(fakeClock.Now().Add(test.wantDelay)) {
+			t.Errorf("%s: pinned at %s, want %s", test.name, stored.ScheduledAt, fakeClock.Now().Add(test.wantDelay))
+		}
+
+	}
+
+	if len(p.published) != 0 {
+		t.Errorf("published %d failed posts", len(p.published))
+	}
+
+}
+
+func TestPostPinnedRetriesMarking(t *testing.T) {
+
+	pinned := newTestPost("c", time.Hour)
+	m, s, p, fakeClock := newTestManager(t, newTestPost("a", 2*time.Hour), pinned)
+
+	pinTime := fakeClock.Now().Add(30 * time.Minute)
+	err := m.tryPinning(pinned, &pinTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The post is sent, then moved forward instead of being sent again right away
+	s.failMarking = true
+	fakeClock.Advance(30 * time.Minute)
+	if err := m.postPinned(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := fakeClock.Now().Add(minIntervalBetweenPosts); len(p.published) != 1 || !s.find(pinned.ID).ScheduledAt.Equal(want) {
+		t.Fatalf("unmarked pinned post: got %d published, pinned at %s, want one, pinned at %s", len(p.published), s.find(pinned.ID).ScheduledAt, want)
+	}
+
+	// While the post can't be marked nothing is posted
+	fakeClock.Advance(minIntervalBetweenPosts)
+	if err := m.postPinned(); err == nil || len(p.published) != 1 {
+		t.Errorf("still unmarked: got %v with %d published, want an error and one post", err, len(p.published))
+	}
+
+	// Once marked, the post is not sent again
+	s.failMarking = false
+	fakeClock.Advance(minIntervalBetweenPosts)
+	if err := m.postPinned(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.published) != 1 || s.find(pinned.ID).PostedAt == nil {
+		t.Errorf("marked pinned post: got %d published, posted at %v, want one post marked as posted", len(p.published), s.find(pinned.ID).PostedAt)
+	}
+
+}
@@ -1,11 +1,12 @@
 package posting
 
 import (
+	"errors"
 	"fmt"
-	"github.com/shitpostingio/autopostingbot/api"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
 	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
 	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/posting/clock"
 	log "github.com/sirupsen/logrus"
 	"strings"
 	"time"
@@ -16,107 +17,287 @@ const (
 	minIntervalBetweenPauses = 5 * time.Minute
 )
 
-// tryPosting tries to post on the channel.
-func tryPosting(post *entities.Post) error {
+// tryPosting tries to post on the channel, following the posting schedule.
+func (m *Manager) tryPosting(post *entities.Post) error {
 
-	// Check post time
-	if time.Since(m.previousPostTime) <= minIntervalBetweenPosts {
-		return fmt.Errorf(l.GetString(l.POSTING_POSTING_PREVIOUS_POST_TOO_CLOSE), time.Since(m.previousPostTime))
+	// Posts can't be marked as posted while the document store is unavailable
+	if !m.store.Available() {
+		return errors.New(l.GetString(l.POSTING_POSTING_STORAGE_UNAVAILABLE))
 	}
 
-	// Prepare caption
-	caption := post.Caption
-	if !strings.Contains(post.Caption, "@"+m.e.GetEditionName()) {
-		caption = fmt.Sprintf("%s\n\n@%s", caption, m.e.GetEditionName())
+	//
+	err := m.markPublishedPosts()
+	if err != nil {
+		return err
 	}
 
-	ft, err := api.GetFormattedText(caption)
-	if err != nil {
-		return fmt.Errorf(l.GetString(l.POSTING_POSTING_UNABLE_TO_PARSE_CAPTION), err)
+	// Check post time
+	if m.since(m.previousPostTime) <= minIntervalBetweenPosts {
+		return fmt.Errorf(l.GetString(l.POSTING_POSTING_PREVIOUS_POST_TOO_CLOSE), m.since(m.previousPostTime))
 	}
 
 	//
-	message, err := api.SendMedia(post.Media.Type, m.config.Autoposting.ChannelID, api.NoReply, post.Media.FileID, ft.Text, ft.Entities)
-	if err != nil {
-		_ = dbwrapper.MarkPostAsFailed(post)
+	err = m.sendPost(post)
+
+	// The post was not sent
+	var pe *postingError
+	if errors.As(err, &pe) {
 		return err
 	}
 
 	//
-	err = dbwrapper.MarkPostAsPosted(post, int(message.Id))
+	m.schedulePosting(m.clock.Now())
+	return err
+
+}
+
+// sendPost sends a post on the channel and marks it as posted,
+// without affecting the posting schedule.
+// Failed attempts are returned as postingError, while posts that
+// were sent but couldn't be marked as posted are returned as markingError
+// and marked again before the next post.
+func (m *Manager) sendPost(post *entities.Post) error {
+
+	// Prepare caption
+	caption := post.Caption
+	if !strings.Contains(post.Caption, m.e.GetSignature()) {
+		caption = fmt.Sprintf("%s\n\n%s", caption, m.e.GetSignature())
+	}
+
+	messageID, err := m.publisher.Publish(m.channel.ChannelID, post, caption)
 	if err != nil {
-		log.Error("Unable to mark post ", post.ID, " as posted")
+		return m.recordFailure(post, err)
 	}
 
 	//
-	schedulePosting(time.Now())
+	err = m.store.MarkPostAsPosted(post, int(messageID))
+	if err != nil {
+		log.Error("Unable to mark post ", post.ID, " as posted, retrying later: ", err)
+		m.unmarkedPosts = append(m.unmarkedPosts, unmarkedPost{post: *post, messageID: int(messageID)})
+		err = &markingError{err: err}
+	}
 
 	//
-	_ = moveToDirectory(post)
+	_ = m.publisher.Archive(post, m.config.Autoposting.MediaPath)
 	return err
 
 }
 
-// tryPausing tries pausing the posting.
-func tryPausing(duration time.Duration) error {
+// tryPausing tries pausing the posting until the input time.
+// A zero until pauses the posting until it's resumed.
+// Pinned posts are posted regardless of pauses.
+func (m *Manager) tryPausing(until time.Time, pausedBy int32) error {
 
-	if time.Since(m.previousPauseTime) <= minIntervalBetweenPauses {
-		return fmt.Errorf(l.GetString(l.POSTING_POSTING_PREVIOUS_PAUSE_TOO_CLOSE), time.Since(m.previousPauseTime))
+	//
+	if m.since(m.previousPauseTime) <= minIntervalBetweenPauses {
+		return fmt.Errorf(l.GetString(l.POSTING_POSTING_PREVIOUS_PAUSE_TOO_CLOSE), m.since(m.previousPauseTime))
 	}
 
 	//
-	newTime := m.nextPostScheduled.Add(duration)
-	m.nextPostScheduled = newTime
+	if !until.IsZero() && !until.After(m.clock.Now()) {
+		return fmt.Errorf(l.GetString(l.POSTING_POSTING_PAUSE_IN_THE_PAST), until.Format("15:04"))
+	}
 
 	//
-	if !m.timer.Stop() {
-		select {
-		case <-m.timer.C:
-		default:
-		}
+	m.pausedUntil = until
+	m.pausedIndefinitely = until.IsZero()
+	m.pausedBy = pausedBy
+	m.previousPauseTime = m.clock.Now()
+
+	// The posting will resume at the first available posting window
+	m.nextPostScheduled = m.windows.Next(latest(m.nextPostScheduled, until))
+	m.armTimer()
+	m.saveState()
+	return nil
+
+}
+
+// tryResuming tries resuming the posting, cancelling the active pause.
+// The posting restarts from where the posting rate would have brought it.
+func (m *Manager) tryResuming() error {
+
+	//
+	if !m.isPaused() {
+		return errors.New(l.GetString(l.POSTING_POSTING_NOT_PAUSED))
 	}
 
-	m.timer = time.NewTimer(time.Until(newTime))
+	//
+	m.pausedUntil = time.Time{}
+	m.pausedIndefinitely = false
+	m.nextPostScheduled = m.windows.Next(latest(m.clock.Now(), m.previousPostTime.Add(m.postingRate)))
+
+	//
+	m.armTimer()
+	m.saveState()
 	return nil
 
 }
 
+// isPaused returns true if there is an active pause.
+func (m *Manager) isPaused() bool {
+	return m.pausedIndefinitely || m.clock.Now().Before(m.pausedUntil)
+}
+
 // schedulePosting schedules a new post.
-func schedulePosting(postTime time.Time) {
+func (m *Manager) schedulePosting(postTime time.Time) {
+
+	//
+	queueLength := m.store.GetQueueLength(m.channel.ChannelID, m.policy())
+	newRate := m.postingRateFor(queueLength)
+	m.postingRate = newRate
+
+	// Posts falling outside the posting windows or
+	// during a pause are delayed until posting is allowed
+	m.previousPostTime = postTime
+	m.nextPostScheduled = m.windows.Next(latest(m.clock.Now().Add(newRate), m.pausedUntil))
+	m.armTimer()
+
+	// Send alerts if there are less than X amount of posts enqueued
+	if queueLength != storage.UnknownQueueLength {
+		m.checkQueueAlerts(int(queueLength))
+	}
+
+	m.saveState()
+
+}
+
+// postingRateFor returns the posting rate for the input queue length.
+// If the posts enqueued couldn't be counted, the current posting rate is kept
+// or, if there is none yet, the minimum interval between posts is used.
+func (m *Manager) postingRateFor(queueLength int64) time.Duration {
+
+	//
+	if queueLength != storage.UnknownQueueLength {
+		return m.e.GetNewPostingRate(int(queueLength))
+	}
+
+	//
+	log.Warn(m.channel.Name, ": unable to count the posts enqueued, keeping the current posting rate")
+	if m.postingRate > 0 {
+		return m.postingRate
+	}
+
+	return minIntervalBetweenPosts
+
+}
+
+// armTimer sets the posting timer to fire at the time of the next post,
+// unless the posting is paused until resumed.
+func (m *Manager) armTimer() {
+
+	if m.pausedIndefinitely {
+		stopTimer(m.timer)
+		return
+	}
+
+	m.resetTimer(m.until(m.nextPostScheduled))
+
+}
+
+// resetTimer stops the posting timer and sets it to fire after the input duration.
+func (m *Manager) resetTimer(duration time.Duration) {
+	stopTimer(m.timer)
+	m.timer = m.clock.NewTimer(duration)
+}
+
+// stopTimer stops a timer, draining its channel if need be.
+func stopTimer(timer clock.Timer) {
 
-	// Stop the timer and drain the channel if need be
-	if !m.timer.Stop() {
+	if !timer.Stop() {
 		select {
-		case <-m.timer.C:
+		case <-timer.C():
 		default:
 		}
 	}
 
-	//
-	queueLength := dbwrapper.GetQueueLength()
-	newRate := m.e.GetNewPostingRate(int(queueLength))
-	m.postingRate = newRate
-	m.timer = time.NewTimer(newRate)
+}
 
-	//
-	m.previousPostTime = postTime
-	m.nextPostScheduled = time.Now().Add(newRate)
+// latest returns the latest between two times.
+func latest(a, b time.Time) time.Time {
 
-	// Send alerts if there are less than X amount of posts enqueued
-	if int(queueLength) < m.config.Autoposting.PostAlertThreshold {
-		sendLowPostAlerts(int(queueLength))
+	if a.After(b) {
+		return a
 	}
 
+	return b
+
+}
+
+// rejectionRetry returns the time to wait before retrying a post that wasn't sent,
+// which is when the minimum interval since the previous post is over.
+func (m *Manager) rejectionRetry() time.Duration {
+
+	retry := minIntervalBetweenPosts - m.since(m.previousPostTime)
+	if retry <= 0 {
+		return minIntervalBetweenPosts
+	}
+
+	return retry
+
+}
+
+// emptyQueueRetry returns the time to wait before checking an empty queue again.
+func (m *Manager) emptyQueueRetry() time.Duration {
+
+	boundary, found := m.campaigns.NextBoundary(m.clock.Now())
+	if found && m.until(boundary) < minIntervalBetweenPosts {
+		return m.until(boundary)
+	}
+
+	return minIntervalBetweenPosts
+
 }
 
 // postScheduled posts a scheduled media.
-func postScheduled() error {
+func (m *Manager) postScheduled() error {
 
-	post, err := dbwrapper.GetNextPost()
+	// The posting is held while the document store is unavailable
+	// or the posts already published can't be marked as posted
+	if !m.store.Available() {
+		m.scheduleRetry(minIntervalBetweenPosts)
+		return errors.New(l.GetString(l.POSTING_POSTING_STORAGE_UNAVAILABLE))
+	}
+
+	err := m.markPublishedPosts()
 	if err != nil {
+		m.scheduleRetry(minIntervalBetweenPosts)
 		return err
 	}
 
-	return tryPosting(&post)
+	// With an empty queue, the queue is checked again after the minimum interval
+	// or, if sooner, when a campaign starts or ends and other posts may be admitted
+	post, err := m.store.GetNextPost(m.channel.ChannelID, m.policy())
+	if errors.Is(err, storage.ErrNotFound) {
+		m.scheduleRetry(m.emptyQueueRetry())
+		return err
+	}
+
+	if err != nil {
+		m.scheduleRetry(minIntervalBetweenPosts)
+		return err
+	}
+
+	//
+	err = m.tryPosting(&post)
+	if err == nil {
+		return nil
+	}
+
+	// Failed posts are retried with a backoff, while posts that
+	// won't be retried are skipped and the posting goes on.
+	// Posts that were sent keep the schedule, only their marking is retried
+	var pe *postingError
+	var me *markingError
+	switch {
+	case errors.As(err, &pe) && pe.retry:
+		m.scheduleRetry(m.retryBackoff(pe.attempt))
+	case errors.As(err, &pe):
+		m.schedulePosting(m.previousPostTime)
+	case errors.As(err, &me):
+	default:
+		m.scheduleRetry(m.rejectionRetry())
+	}
+
+	return err
 
 }
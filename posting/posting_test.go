@@ -0,0 +1,565 @@
+package posting
+
+import (
+	"errors"
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"github.com/zelenin/go-tdlib/client"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchedulePosting(t *testing.T) {
+
+	m, s, p, _ := newTestManager(t,
+		newTestPost("a", 3*time.Hour),
+		newTestPost("b", 2*time.Hour),
+		newTestPost("c", time.Hour))
+
+	// With less than 24 posts the shitpost edition posts once per hour
+	if m.postingRate != time.Hour {
+		t.Errorf("posting rate: got %s, want %s", m.postingRate, time.Hour)
+	}
+
+	if want := testStartTime.Add(time.Hour); !m.nextPostScheduled.Equal(want) {
+		t.Errorf("next post: got %s, want %s", m.nextPostScheduled, want)
+	}
+
+	// The schedule must be persisted
+	state, err := s.GetSchedulerState(testChannelID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !state.NextPostScheduled.Equal(m.nextPostScheduled) {
+		t.Errorf("saved next post: got %s, want %s", state.NextPostScheduled, m.nextPostScheduled)
+	}
+
+	// Three posts are above the alert threshold
+	if p.alertCount() != 0 {
+		t.Errorf("alerts: got %d, want 0", p.alertCount())
+	}
+
+}
+
+func TestSchedulePostingSendsLowPostAlerts(t *testing.T) {
+
+	m, _, p, fakeClock := newTestManager(t, newTestPost("a", time.Hour))
+	if p.alertCount() != 1 {
+		t.Fatalf("alerts: got %d, want 1", p.alertCount())
+	}
+
+	// The queue didn't change, no need to alert again
+	m.schedulePosting(fakeClock.Now())
+	if p.alertCount() != 1 {
+		t.Errorf("alerts: got %d, want 1", p.alertCount())
+	}
+
+}
+
+func TestCheckQueueAlerts(t *testing.T) {
+
+	m, s, p, fakeClock := newTestManager(t, newTestPost("a", 2*time.Hour), newTestPost("b", time.Hour))
+	m.channel.PostAlertThreshold = 10
+	m.channel.AlertChatID = -100
+
+	steps := []struct {
+		name        string
+		advance     time.Duration
+		queueLength int
+		wantAlerts  int
+		wantPrefix  string
+	}{
+		{"below the threshold", 0, 9, 1, "🚨"},
+		{"small drop", 0, 8, 1, "🚨"},
+		{"halved", 0, 4, 2, "🚨"},
+		{"empty", 0, 0, 3, "🆘"},
+		{"recovering", 0, 1, 3, "🆘"},
+		{"after the cooldown", 6 * time.Hour, 1, 4, "🚨"},
+		{"back to normal", 0, 12, 5, "✅"},
+		{"below the threshold within the cooldown", time.Hour, 9, 5, "✅"},
+		{"empty within the cooldown", 0, 0, 6, "🆘"},
+	}
+
+	for _, step := range steps {
+
+		fakeClock.Advance(step.advance)
+		m.checkQueueAlerts(step.queueLength)
+
+		chatID, alert := p.lastAlert()
+		if p.alertCount() != step.wantAlerts || !strings.HasPrefix(alert, step.wantPrefix) || chatID != -100 {
+			t.Errorf("%s: got %d alerts, last %q to %d, want %d alerts, last starting with %s to -100",
+				step.name, p.alertCount(), alert, chatID, step.wantAlerts, step.wantPrefix)
+		}
+
+	}
+
+	// The alert state survives restarts
+	m.saveState()
+	state, _ := s.GetSchedulerState(testChannelID)
+	if state.AlertLevel != alertLevelCritical || state.AlertedQueueLength != 0 || !state.AlertedAt.Equal(fakeClock.Now()) {
+		t.Errorf("saved alert state: got level %d, length %d at %s", state.AlertLevel, state.AlertedQueueLength, state.AlertedAt)
+	}
+
+}
+
+func TestUnavailableStorage(t *testing.T) {
+
+	m, s, p, fakeClock := newTestManager(t, newTestPost("a", 2*time.Hour), newTestPost("b", time.Hour))
+	alerts := p.alertCount()
+
+	// The posting rate is kept when the posts enqueued can't be counted
+	s.unavailable = true
+	fakeClock.Advance(time.Hour)
+	m.schedulePosting(fakeClock.Now())
+	if m.postingRate != time.Hour || p.alertCount() != alerts {
+		t.Errorf("unknown queue length: got rate %s and %d alerts, want %s and %d", m.postingRate, p.alertCount(), time.Hour, alerts)
+	}
+
+	// Posts are retried later instead of being sent
+	if err := m.postScheduled(); err == nil {
+		t.Error("posted while the storage was unavailable")
+	}
+
+	if want := fakeClock.Now().Add(minIntervalBetweenPosts); !m.nextPostScheduled.Equal(want) || len(p.published) != 0 {
+		t.Errorf("next post: got %s with %d published, want %s with none", m.nextPostScheduled, len(p.published), want)
+	}
+
+	// The posting goes on once the storage is back
+	s.unavailable = false
+	fakeClock.Advance(time.Hour)
+	if err := m.postScheduled(); err != nil || len(p.published) != 1 {
+		t.Errorf("storage back: got %v with %d published, want one post", err, len(p.published))
+	}
+
+}
+
+func TestSchedulePostingSkipsClosedWindows(t *testing.T) {
+
+	m, _, _, fakeClock := newTestManager(t,
+		newTestPost("a", 2*time.Hour),
+		newTestPost("b", time.Hour),
+		newTestPost("c", time.Minute))
+
+	// Posting is only allowed from 9 to 12
+	m.windows = mustWindows(t, "09:00", "12:00")
+	m.schedulePosting(fakeClock.Now())
+
+	want := time.Date(2020, time.June, 2, 9, 0, 0, 0, time.UTC)
+	if !m.nextPostScheduled.Equal(want) {
+		t.Errorf("next post: got %s, want %s", m.nextPostScheduled, want)
+	}
+
+}
+
+func TestTryPosting(t *testing.T) {
+
+	post := newTestPost("a", 2*time.Hour)
+	m, s, p, fakeClock := newTestManager(t, post, newTestPost("b", time.Hour), newTestPost("c", time.Minute))
+
+	//
+	fakeClock.Advance(10 * time.Minute)
+	err := m.tryPosting(post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//
+	published := p.waitForPost(t)
+	if published.Media.FileUniqueID != "a" {
+		t.Errorf("published post: got %s, want a", published.Media.FileUniqueID)
+	}
+
+	if post.PostedAt == nil {
+		t.Error("the post was not marked as posted")
+	}
+
+	// The schedule restarts from the time of the post
+	if !m.previousPostTime.Equal(fakeClock.Now()) {
+		t.Errorf("previous post: got %s, want %s", m.previousPostTime, fakeClock.Now())
+	}
+
+	if want := fakeClock.Now().Add(time.Hour); !m.nextPostScheduled.Equal(want) {
+		t.Errorf("next post: got %s, want %s", m.nextPostScheduled, want)
+	}
+
+	if s.GetQueueLength(testChannelID, selection.Policy{}) != 2 {
+		t.Errorf("queue length: got %d, want 2", s.GetQueueLength(testChannelID, selection.Policy{}))
+	}
+
+}
+
+func TestCampaigns(t *testing.T) {
+
+	xmas := newTestPost("x", time.Minute)
+	xmas.Tags = []string{"christmas"}
+	m, s, _, fakeClock := newTestManager(t, newTestPost("a", 2*time.Hour), xmas)
+
+	//
+	m.config.Campaigns = []structs.CampaignConfiguration{
+		{Name: "christmas", Start: "2020-06-02", End: "2020-06-02", Exclusive: true},
+	}
+
+	err := m.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		after  time.Duration
+		want   string
+		length int64
+	}{
+		{"tagged posts are held before the campaign", 0, "a", 1},
+		{"only tagged posts during exclusive campaigns", 12 * time.Hour, "x", 1},
+		{"tagged posts are held after the campaign", 36 * time.Hour, "a", 1},
+	}
+
+	for _, test := range tests {
+
+		fakeClock.Advance(test.after - fakeClock.Now().Sub(testStartTime))
+		next, err := s.GetNextPost(testChannelID, m.policy())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if next.Media.FileUniqueID != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, next.Media.FileUniqueID, test.want)
+		}
+
+		if length := s.GetQueueLength(testChannelID, m.policy()); length != test.length {
+			t.Errorf("%s: queue length: got %d, want %d", test.name, length, test.length)
+		}
+
+	}
+
+}
+
+func TestPostScheduledRetriesMarking(t *testing.T) {
+
+	m, s, p, fakeClock := newTestManager(t, newTestPost("a", 2*time.Hour), newTestPost("b", time.Hour))
+
+	// The post is sent, so the schedule goes on
+	s.failMarking = true
+	fakeClock.Advance(time.Hour)
+	if err := m.postScheduled(); err == nil || len(p.published) != 1 {
+		t.Fatalf("unmarked post: got %v with %d published, want an error and one post", err, len(p.published))
+	}
+
+	if want := fakeClock.Now().Add(time.Hour); !m.nextPostScheduled.Equal(want) {
+		t.Errorf("next post: got %s, want %s", m.nextPostScheduled, want)
+	}
+
+	// Nothing is posted until the published post is marked
+	fakeClock.Advance(time.Hour)
+	if err := m.postScheduled(); err == nil || len(p.published) != 1 {
+		t.Errorf("still unmarked: got %v with %d published, want an error and one post", err, len(p.published))
+	}
+
+	// Once marked, the published post isn't sent again
+	s.failMarking = false
+	fakeClock.Advance(minIntervalBetweenPosts)
+	if err := m.postScheduled(); err != nil {
+		t.Fatal(err)
+	}
+
+	<-p.published
+	if published := <-p.published; published.Media.FileUniqueID != "b" {
+		t.Errorf("published post: got %s, want b", published.Media.FileUniqueID)
+	}
+
+}
+
+func TestPostScheduledAfterExclusiveCampaigns(t *testing.T) {
+
+	m, _, p, fakeClock := newTestManager(t, newTestPost("a", time.Hour))
+
+	// The exclusive campaign has no posts in its themed queue
+	m.config.Campaigns = []structs.CampaignConfiguration{
+		{Name: "flash", Start: "2020-06-01 12:00", End: "2020-06-01 13:02", Exclusive: true},
+	}
+
+	err := m.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The queue is checked again when the campaign ends
+	fakeClock.Advance(time.Hour)
+	if err := m.postScheduled(); err == nil || len(p.published) != 0 {
+		t.Fatalf("posted %d posts during an empty exclusive campaign", len(p.published))
+	}
+
+	if want := fakeClock.Now().Add(2 * time.Minute); !m.nextPostScheduled.Equal(want) {
+		t.Errorf("next post: got %s, want %s", m.nextPostScheduled, want)
+	}
+
+	fakeClock.Advance(2 * time.Minute)
+	if err := m.postScheduled(); err != nil || len(p.published) != 1 {
+		t.Errorf("after the campaign: got %v with %d published, want one post", err, len(p.published))
+	}
+
+	// An empty queue keeps being checked
+	if err := m.postScheduled(); err == nil {
+		t.Fatal("posted from an empty queue")
+	}
+
+	if want := fakeClock.Now().Add(minIntervalBetweenPosts); !m.nextPostScheduled.Equal(want) {
+		t.Errorf("next check: got %s, want %s", m.nextPostScheduled, want)
+	}
+
+}
+
+func TestTryPostingRejectsPostsTooClose(t *testing.T) {
+
+	first := newTestPost("a", 2*time.Hour)
+	second := newTestPost("b", time.Hour)
+	m, _, p, fakeClock := newTestManager(t, first, second, newTestPost("c", time.Minute))
+
+	//
+	err := m.tryPosting(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.waitForPost(t)
+
+	//
+	fakeClock.Advance(minIntervalBetweenPosts)
+	err = m.tryPosting(second)
+	if err == nil {
+		t.Fatal("posting too close to the previous post succeeded")
+	}
+
+	p.assertNoPost(t)
+
+	//
+	fakeClock.Advance(time.Second)
+	err = m.tryPosting(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.waitForPost(t)
+
+}
+
+func TestTryPostingRecordsFailures(t *testing.T) {
+
+	permanent := client.ResponseError{Err: &client.Error{Code: http.StatusBadRequest, Message: "MEDIA_INVALID"}}
+
+	tests := []struct {
+		name          string
+		err           error
+		attempts      int
+		wantRetry     bool
+		wantHasError  bool
+		wantScheduled bool
+	}{
+		{name: "transient", err: errTransient, attempts: 0, wantRetry: true},
+		{name: "last attempt", err: errTransient, attempts: 2, wantHasError: true},
+		{name: "permanent", err: permanent, attempts: 0, wantHasError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			post := newTestPost("a", time.Hour)
+			post.FailedAttempts = test.attempts
+			m, _, p, fakeClock := newTestManager(t, post)
+			scheduled := m.nextPostScheduled
+
+			//
+			fakeClock.Advance(10 * time.Minute)
+			p.failNext(test.err)
+			err := m.tryPosting(post)
+
+			var pe *postingError
+			if !errors.As(err, &pe) {
+				t.Fatalf("error: got %v, want a postingError", err)
+			}
+
+			if pe.retry != test.wantRetry {
+				t.Errorf("retry: got %t, want %t", pe.retry, test.wantRetry)
+			}
+
+			if post.HasError != test.wantHasError {
+				t.Errorf("has error: got %t, want %t", post.HasError, test.wantHasError)
+			}
+
+			if post.FailedAttempts != test.attempts+1 {
+				t.Errorf("failed attempts: got %d, want %d", post.FailedAttempts, test.attempts+1)
+			}
+
+			// Failed attempts don't affect the schedule
+			if !m.nextPostScheduled.Equal(scheduled) {
+				t.Errorf("next post: got %s, want %s", m.nextPostScheduled, scheduled)
+			}
+
+		})
+	}
+
+}
+
+func TestTryPostingPausesOnChannelFailures(t *testing.T) {
+
+	post := newTestPost("a", time.Hour)
+	m, s, p, fakeClock := newTestManager(t, post, newTestPost("b", time.Minute))
+
+	//
+	fakeClock.Advance(10 * time.Minute)
+	p.failNext(client.ResponseError{Err: &client.Error{Code: http.StatusBadRequest, Message: "Chat not found"}})
+	err := m.tryPosting(post)
+
+	var pe *postingError
+	if !errors.As(err, &pe) || !pe.retry {
+		t.Fatalf("error: got %v, want a postingError to retry", err)
+	}
+
+	// The post is not to blame
+	if post.HasError || post.FailedAttempts != 0 {
+		t.Errorf("post: has error %t after %d attempts, want no failed attempts", post.HasError, post.FailedAttempts)
+	}
+
+	// The posting stops until the admins fix the channel
+	if !m.pausedIndefinitely || m.pausedBy != 0 || !s.states[testChannelID].PausedIndefinitely {
+		t.Errorf("pause: paused %t by %d, saved %t", m.pausedIndefinitely, m.pausedBy, s.states[testChannelID].PausedIndefinitely)
+	}
+
+	if _, alert := p.lastAlert(); !strings.Contains(alert, "Chat not found") {
+		t.Errorf("alert: got %q, want the channel failure", alert)
+	}
+
+	// The admins are alerted once
+	fakeClock.Advance(10 * time.Minute)
+	p.failNext(client.ResponseError{Err: &client.Error{Code: http.StatusForbidden, Message: "CHAT_WRITE_FORBIDDEN"}})
+	_ = m.tryPosting(post)
+	if len(p.alerts) != 1 {
+		t.Errorf("alerts: got %d, want 1", len(p.alerts))
+	}
+
+}
+
+func TestTryPausing(t *testing.T) {
+
+	m, s, _, fakeClock := newTestManager(t, newTestPost("a", 2*time.Hour), newTestPost("b", time.Hour), newTestPost("c", time.Minute))
+
+	// No posts until the end of the pause
+	until := fakeClock.Now().Add(2 * time.Hour)
+	err := m.tryPausing(until, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.nextPostScheduled.Equal(until) {
+		t.Errorf("next post: got %s, want %s", m.nextPostScheduled, until)
+	}
+
+	state, _ := s.GetSchedulerState(testChannelID)
+	if !state.PreviousPauseTime.Equal(fakeClock.Now()) || !state.PausedUntil.Equal(until) || state.PausedBy != 42 {
+		t.Errorf("saved pause: got %s until %s by %d, want %s until %s by 42",
+			state.PreviousPauseTime, state.PausedUntil, state.PausedBy, fakeClock.Now(), until)
+	}
+
+	// Pauses too close between each other are rejected
+	fakeClock.Advance(minIntervalBetweenPauses)
+	err = m.tryPausing(time.Time{}, 42)
+	if err == nil {
+		t.Fatal("pausing too close to the previous pause succeeded")
+	}
+
+	// Pauses can't end in the past
+	fakeClock.Advance(time.Second)
+	err = m.tryPausing(fakeClock.Now().Add(-time.Minute), 42)
+	if err == nil {
+		t.Fatal("pausing until a past time succeeded")
+	}
+
+	// Indefinite pauses stop the posting timer
+	err = m.tryPausing(time.Time{}, 43)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, _ = s.GetSchedulerState(testChannelID)
+	if !m.isPaused() || !state.PausedIndefinitely || state.PausedBy != 43 {
+		t.Errorf("indefinite pause: paused %t, saved %t by %d", m.isPaused(), state.PausedIndefinitely, state.PausedBy)
+	}
+
+	if m.timer.Stop() {
+		t.Error("the posting timer is still running")
+	}
+
+}
+
+func TestTryResuming(t *testing.T) {
+
+	m, s, _, fakeClock := newTestManager(t, newTestPost("a", 2*time.Hour), newTestPost("b", time.Hour))
+	m.schedulePosting(fakeClock.Now())
+	scheduled := m.nextPostScheduled
+
+	//
+	err := m.tryResuming()
+	if err == nil {
+		t.Fatal("resuming without a pause succeeded")
+	}
+
+	//
+	err = m.tryPausing(fakeClock.Now().Add(5*time.Hour), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The schedule goes back to the one given by the posting rate
+	fakeClock.Advance(10 * time.Minute)
+	err = m.tryResuming()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.isPaused() || !m.nextPostScheduled.Equal(scheduled) {
+		t.Errorf("after resuming: paused %t, next post %s, want %s", m.isPaused(), m.nextPostScheduled, scheduled)
+	}
+
+	state, _ := s.GetSchedulerState(testChannelID)
+	if !state.PausedUntil.IsZero() || state.PausedIndefinitely {
+		t.Errorf("saved pause after resuming: until %s, indefinitely %t", state.PausedUntil, state.PausedIndefinitely)
+	}
+
+}
+
+func TestRestoreState(t *testing.T) {
+
+	m, s, _, fakeClock := newTestManager(t, newTestPost("a", 2*time.Hour), newTestPost("b", time.Hour), newTestPost("c", time.Minute))
+
+	// A pending schedule is kept across restarts
+	pending := fakeClock.Now().Add(3 * time.Hour)
+	_ = s.SaveSchedulerState(entities.SchedulerState{ChannelID: testChannelID, NextPostScheduled: pending, PostingRate: time.Hour})
+	if !m.restoreState() {
+		t.Fatal("no state restored")
+	}
+
+	if !m.nextPostScheduled.Equal(pending) {
+		t.Errorf("next post: got %s, want %s", m.nextPostScheduled, pending)
+	}
+
+	// A schedule that expired while offline is computed again
+	previousPost := fakeClock.Now().Add(-2 * time.Hour)
+	_ = s.SaveSchedulerState(entities.SchedulerState{ChannelID: testChannelID, NextPostScheduled: fakeClock.Now().Add(-time.Hour), PreviousPostTime: previousPost})
+	if !m.restoreState() {
+		t.Fatal("no state restored")
+	}
+
+	if want := fakeClock.Now().Add(time.Hour); !m.nextPostScheduled.Equal(want) {
+		t.Errorf("next post: got %s, want %s", m.nextPostScheduled, want)
+	}
+
+	if !m.previousPostTime.Equal(previousPost) {
+		t.Errorf("previous post: got %s, want %s", m.previousPostTime, previousPost)
+	}
+
+}
@@ -0,0 +1,93 @@
+package posting
+
+import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/repository"
+	log "github.com/sirupsen/logrus"
+	"sync"
+)
+
+var (
+
+	// alertRecipients are the authorized users the last alert was sent to.
+	// They are alerted when the users can't be retrieved from the document store.
+	alertRecipients      []entities.User
+	alertRecipientsMutex sync.Mutex
+)
+
+// publisher represents the Telegram operations the Manager relies on.
+type publisher interface {
+
+	// Publish sends a post with the input caption on a channel
+	// and returns the id of the message.
+	Publish(channelID int64, post *entities.Post, caption string) (int64, error)
+
+	// Archive moves the media of a post to the persistent directory.
+	Archive(post *entities.Post, mediaPath string) error
+
+	// Alert sends a message to a staff chat or, if chatID is zero,
+	// to all the authorized users.
+	Alert(chatID int64, text string)
+}
+
+// telegramPublisher is the publisher backed by tdlib.
+type telegramPublisher struct{}
+
+// Publish sends a post with the input caption on a channel
+// and returns the id of the message.
+func (telegramPublisher) Publish(channelID int64, post *entities.Post, caption string) (int64, error) {
+
+	//
+	ft, err := api.GetFormattedText(caption)
+	if err != nil {
+		return 0, &permanentError{fmt.Errorf(l.GetString(l.POSTING_POSTING_UNABLE_TO_PARSE_CAPTION), err)}
+	}
+
+	//
+	message, err := api.SendMedia(post.Media.Type, channelID, api.NoReply, post.Media.FileID, ft.Text, ft.Entities)
+	if err != nil {
+		return 0, err
+	}
+
+	return message.Id, nil
+
+}
+
+// Alert sends a message to a staff chat or, if chatID is zero,
+// to all the authorized users. If the users can't be retrieved,
+// the ones that received the last alert are alerted.
+func (telegramPublisher) Alert(chatID int64, text string) {
+
+	//
+	if chatID != 0 {
+
+		_, err := api.SendPlainText(chatID, text)
+		if err != nil {
+			log.Error("Unable to send the alert to chat ", chatID, ": ", err)
+		}
+
+		return
+
+	}
+
+	//
+	users, err := repository.Users.GetUsers()
+	alertRecipientsMutex.Lock()
+	if err == nil {
+		alertRecipients = users
+	} else {
+		log.Error("Unable to retrieve the users to alert, alerting the previous recipients: ", err)
+		users = alertRecipients
+	}
+
+	alertRecipientsMutex.Unlock()
+
+	//
+	for _, user := range users {
+		_, _ = api.SendPlainText(int64(user.TelegramID), text)
+	}
+
+}
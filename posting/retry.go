@@ -0,0 +1,280 @@
+package posting
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	log "github.com/sirupsen/logrus"
+	"github.com/zelenin/go-tdlib/client"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// failureKind represents how a failed posting attempt is handled.
+type failureKind int
+
+const (
+
+	// failureTransient is a failure that may go away on retry,
+	// such as a flood wait, a server-side error or a network issue.
+	// The post is retried with a backoff.
+	failureTransient failureKind = iota
+
+	// failurePermanent is a failure that depends on the post itself,
+	// such as an invalid media. The post is marked as failed.
+	failurePermanent
+
+	// failureChannel is a failure that depends on the channel, such as a deleted channel
+	// or the bot having been removed from it. Every post would fail the same way,
+	// so the posting is paused and the admins are alerted.
+	failureChannel
+)
+
+var (
+
+	// channelErrorMessages are the tdlib error messages, or parts of them,
+	// reporting that the bot can't post on the channel at all.
+	channelErrorMessages = []string{
+		"chat not found",
+		"channel_invalid",
+		"channel_private",
+		"chat_admin_required",
+		"chat_write_forbidden",
+		"user_banned_in_channel",
+		"have no write access to the chat",
+		"have no rights to send a message",
+		"need administrator rights",
+		"bot was kicked",
+		"bot is not a member",
+	}
+
+	// mediaErrorMessages are the tdlib error messages, or parts of them,
+	// reporting that the channel doesn't accept the media of the post.
+	mediaErrorMessages = []string{
+		"chat_send_",
+		"not enough rights to send",
+	}
+)
+
+// postingError represents a failed attempt at posting a media,
+// along with the decision on whether it should be retried.
+type postingError struct {
+	err     error
+	attempt int
+	retry   bool
+}
+
+// Error returns the error message of the failed attempt.
+func (e *postingError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error of the failed attempt.
+func (e *postingError) Unwrap() error {
+	return e.err
+}
+
+// markingError represents a post that was published
+// but could not be marked as posted.
+type markingError struct {
+	err error
+}
+
+// Error returns the error message of the failed marking.
+func (e *markingError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error of the failed marking.
+func (e *markingError) Unwrap() error {
+	return e.err
+}
+
+// permanentError represents a failure that depends on the post itself,
+// such as a caption that can't be parsed, and won't go away on retry.
+type permanentError struct {
+	err error
+}
+
+// Error returns the error message of the failure.
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error of the failure.
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// unmarkedPost represents a published post that is yet to be marked as posted.
+type unmarkedPost struct {
+	post      entities.Post
+	messageID int
+}
+
+// markPublishedPosts tries again to mark as posted the published posts
+// that couldn't be, returning an error if any of them still isn't.
+// Nothing else must be posted until they are, or they would be published again.
+func (m *Manager) markPublishedPosts() error {
+
+	//
+	var remaining []unmarkedPost
+	for _, unmarked := range m.unmarkedPosts {
+
+		err := m.store.MarkPostAsPosted(&unmarked.post, unmarked.messageID)
+		if err != nil {
+			remaining = append(remaining, unmarked)
+		}
+
+	}
+
+	//
+	m.unmarkedPosts = remaining
+	if len(remaining) > 0 {
+		return fmt.Errorf(l.GetString(l.POSTING_POSTING_UNMARKED_POSTS), len(remaining))
+	}
+
+	return nil
+
+}
+
+// recordFailure records a failed posting attempt in the document store
+// and decides whether the post will be retried or marked as failed.
+func (m *Manager) recordFailure(post *entities.Post, err error) error {
+
+	// The post isn't charged for the failures of the channel
+	kind := classifyFailure(err)
+	if kind == failureChannel {
+		m.pauseForChannelFailure(err)
+		return &postingError{
+			err:     err,
+			attempt: post.FailedAttempts,
+			retry:   true,
+		}
+	}
+
+	//
+	attempt := post.FailedAttempts + 1
+	retry := kind == failureTransient && attempt < m.config.Autoposting.MaxPostingAttempts
+
+	//
+	dbErr := m.store.RecordFailedAttempt(post, err.Error(), !retry)
+	if dbErr != nil {
+		log.Error("Unable to record the failed attempt for post ", post.ID, ": ", dbErr)
+	}
+
+	//
+	if !retry {
+		log.Warn("Post ", post.ID, " marked as failed after ", attempt, " attempts: ", err)
+	}
+
+	return &postingError{
+		err:     err,
+		attempt: attempt,
+		retry:   retry,
+	}
+
+}
+
+// classifyFailure tells how a failed posting attempt must be handled.
+// Bad requests depend on the post, unless tdlib reports that the channel can't be posted on,
+// while forbidden requests depend on the channel, unless it only refuses the type of the media.
+// Flood waits, server-side errors and network issues are considered transient.
+func classifyFailure(err error) failureKind {
+
+	//
+	var pe *permanentError
+	if errors.As(err, &pe) || errors.Is(err, api.ErrUnsupportedMediaType) {
+		return failurePermanent
+	}
+
+	//
+	var responseError client.ResponseError
+	if !errors.As(err, &responseError) {
+		return failureTransient
+	}
+
+	//
+	message := strings.ToLower(responseError.Err.Message)
+	switch responseError.Err.Code {
+	case http.StatusBadRequest:
+		if containsAny(message, channelErrorMessages) {
+			return failureChannel
+		}
+
+		return failurePermanent
+	case http.StatusForbidden:
+		if containsAny(message, mediaErrorMessages) {
+			return failurePermanent
+		}
+
+		return failureChannel
+	default:
+		return failureTransient
+	}
+
+}
+
+// containsAny returns true if s contains any of the input substrings.
+func containsAny(s string, substrings []string) bool {
+
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// pauseForChannelFailure pauses the posting until it's resumed, since the channel can't be posted on,
+// and alerts the admins so that they can fix the channel and resume the posting.
+// Nothing is done if the posting is already paused until resumed.
+func (m *Manager) pauseForChannelFailure(err error) {
+
+	//
+	if m.pausedIndefinitely {
+		return
+	}
+
+	log.Error(m.channel.Name, ": unable to post on the channel, pausing the posting: ", err)
+	m.pausedUntil = time.Time{}
+	m.pausedIndefinitely = true
+	m.pausedBy = 0
+	m.armTimer()
+	m.saveState()
+
+	//
+	alert := fmt.Sprintf(l.GetString(l.POSTING_ALERTS_CHANNEL_UNAVAILABLE), m.channel.Name, err)
+	m.publisher.Alert(m.channel.AlertChatID, alert)
+
+}
+
+// retryBackoff returns the time to wait before retrying a post
+// that failed for the input amount of attempts.
+func (m *Manager) retryBackoff(attempt int) time.Duration {
+
+	backoff := m.config.Autoposting.RetryBackoff
+	for i := 1; i < attempt && backoff < m.config.Autoposting.MaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > m.config.Autoposting.MaxRetryBackoff {
+		return m.config.Autoposting.MaxRetryBackoff
+	}
+
+	return backoff
+
+}
+
+// scheduleRetry schedules a new posting attempt after the input duration,
+// without changing the posting rate.
+func (m *Manager) scheduleRetry(after time.Duration) {
+	m.nextPostScheduled = m.windows.Next(m.clock.Now().Add(after))
+	m.armTimer()
+	m.saveState()
+}
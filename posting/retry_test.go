@@ -0,0 +1,48 @@
+package posting
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/api"
+	"github.com/zelenin/go-tdlib/client"
+	"net/http"
+	"testing"
+)
+
+func TestClassifyFailure(t *testing.T) {
+
+	responseError := func(code int32, message string) error {
+		return client.ResponseError{Err: &client.Error{Code: code, Message: message}}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want failureKind
+	}{
+		{"invalid media", responseError(http.StatusBadRequest, "MEDIA_INVALID"), failurePermanent},
+		{"wrapped invalid media", fmt.Errorf("unable to send: %w", responseError(http.StatusBadRequest, "MEDIA_INVALID")), failurePermanent},
+		{"unparsable caption", &permanentError{errors.New("unable to parse the caption")}, failurePermanent},
+		{"wrapped unparsable caption", fmt.Errorf("attempt failed: %w", &permanentError{errors.New("unable to parse the caption")}), failurePermanent},
+		{"unsupported media type", fmt.Errorf("send function not found for media type sticker: %w", api.ErrUnsupportedMediaType), failurePermanent},
+		{"media type forbidden on the channel", responseError(http.StatusForbidden, "CHAT_SEND_GIFS_FORBIDDEN"), failurePermanent},
+		{"missing rights for the media type", responseError(http.StatusForbidden, "Not enough rights to send animations to the chat"), failurePermanent},
+		{"deleted channel", responseError(http.StatusBadRequest, "Chat not found"), failureChannel},
+		{"private channel", responseError(http.StatusBadRequest, "CHANNEL_PRIVATE"), failureChannel},
+		{"bot removed from the channel", responseError(http.StatusBadRequest, "Have no write access to the chat"), failureChannel},
+		{"missing rights on the channel", responseError(http.StatusForbidden, "CHAT_WRITE_FORBIDDEN"), failureChannel},
+		{"unknown forbidden request", responseError(http.StatusForbidden, "FORBIDDEN"), failureChannel},
+		{"flood wait", responseError(http.StatusTooManyRequests, "FLOOD_WAIT_30"), failureTransient},
+		{"server error", responseError(http.StatusInternalServerError, "INTERNAL"), failureTransient},
+		{"network error", errTransient, failureTransient},
+	}
+
+	for _, test := range tests {
+
+		if got := classifyFailure(test.err); got != test.want {
+			t.Errorf("%s: got %d, want %d", test.name, got, test.want)
+		}
+
+	}
+
+}
@@ -0,0 +1,68 @@
+package posting
+
+import (
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	log "github.com/sirupsen/logrus"
+)
+
+// saveState persists the scheduler state of the channel in the document store,
+// so that it can be restored after a restart.
+func (m *Manager) saveState() {
+
+	state := entities.SchedulerState{
+		ChannelID:          m.channel.ChannelID,
+		NextPostScheduled:  m.nextPostScheduled,
+		PreviousPostTime:   m.previousPostTime,
+		PreviousPauseTime:  m.previousPauseTime,
+		PostingRate:        m.postingRate,
+		PausedUntil:        m.pausedUntil,
+		PausedIndefinitely: m.pausedIndefinitely,
+		PausedBy:           m.pausedBy,
+		AlertLevel:         m.alertLevel,
+		AlertedAt:          m.alertedAt,
+		AlertedQueueLength: m.alertedQueueLength,
+	}
+
+	err := m.store.SaveSchedulerState(state)
+	if err != nil {
+		log.Error("Unable to save the scheduler state: ", err)
+	}
+
+}
+
+// restoreState restores the scheduler state saved in the document store.
+// It returns false if there was no state to restore.
+func (m *Manager) restoreState() bool {
+
+	//
+	state, err := m.store.GetSchedulerState(m.channel.ChannelID)
+	if err != nil {
+		log.Debugln("No scheduler state restored for channel ", m.channel.Name, ": ", err)
+		return false
+	}
+
+	//
+	m.previousPostTime = state.PreviousPostTime
+	m.previousPauseTime = state.PreviousPauseTime
+	m.postingRate = state.PostingRate
+	m.pausedUntil = state.PausedUntil
+	m.pausedIndefinitely = state.PausedIndefinitely
+	m.pausedBy = state.PausedBy
+	m.alertLevel = state.AlertLevel
+	m.alertedAt = state.AlertedAt
+	m.alertedQueueLength = state.AlertedQueueLength
+
+	// If the scheduled post was due while we were offline,
+	// compute a new schedule keeping the previous post time
+	if !state.NextPostScheduled.After(m.clock.Now()) {
+		m.schedulePosting(state.PreviousPostTime)
+		return true
+	}
+
+	//
+	m.nextPostScheduled = m.windows.Next(state.NextPostScheduled)
+	m.armTimer()
+	log.Info("Scheduler state restored for channel ", m.channel.Name, ", next post at ", m.nextPostScheduled)
+	return true
+
+}
@@ -0,0 +1,67 @@
+package posting
+
+import (
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/health"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"time"
+)
+
+// store represents the document store operations the Manager relies on.
+type store interface {
+
+	// Available returns false while the document store can't be reached.
+	Available() bool
+
+	// GetNextPost retrieves the next post in the queue of a channel,
+	// according to the input selection policy.
+	// storage.ErrNotFound is returned if the queue is empty.
+	GetNextPost(channelID int64, policy selection.Policy) (entities.Post, error)
+
+	// GetQueue retrieves the queue of a channel,
+	// in the order given by the input selection policy.
+	GetQueue(channelID int64, policy selection.Policy) ([]entities.Post, error)
+
+	// GetQueueLength returns the number of the posts enqueued on a channel
+	// the input selection policy admits, or storage.UnknownQueueLength if they can't be counted.
+	GetQueueLength(channelID int64, policy selection.Policy) int64
+
+	// GetScheduledPosts retrieves the posts scheduled for a specific time on a channel.
+	GetScheduledPosts(channelID int64) ([]entities.Post, error)
+
+	// SchedulePostByUniqueID schedules a post for a specific time given its channel and uniqueID.
+	SchedulePostByUniqueID(channelID int64, uniqueID string, scheduledAt *time.Time) error
+
+	// MarkPostAsPosted marks a post as posted.
+	MarkPostAsPosted(post *entities.Post, messageID int) error
+
+	// RecordFailedAttempt records a failed posting attempt.
+	RecordFailedAttempt(post *entities.Post, lastError string, permanent bool) error
+
+	// SaveSchedulerState saves the state of the posting scheduler of a channel.
+	SaveSchedulerState(state entities.SchedulerState) error
+
+	// GetSchedulerState retrieves the last saved state of the posting scheduler of a channel.
+	GetSchedulerState(channelID int64) (entities.SchedulerState, error)
+}
+
+// repositoryStore is the store backed by the storage backend in the repository.
+type repositoryStore struct {
+	storage.PostStore
+	storage.SchedulerStore
+}
+
+// newRepositoryStore creates a store backed by the storage backend in the repository.
+func newRepositoryStore() repositoryStore {
+	return repositoryStore{
+		PostStore:      repository.Posts,
+		SchedulerStore: repository.Scheduler,
+	}
+}
+
+// Available returns false while the storage backend can't be reached.
+func (repositoryStore) Available() bool {
+	return health.Available()
+}
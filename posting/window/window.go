@@ -0,0 +1,129 @@
+package window
+
+import (
+	"fmt"
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	"time"
+)
+
+const (
+	timeOfDayLayout = "15:04"
+	day             = 24 * time.Hour
+)
+
+// Window represents a daily time range in which posting is allowed.
+type Window struct {
+	hour   int
+	minute int
+	length time.Duration
+}
+
+// Schedule represents the set of daily posting windows in a given location.
+// A Schedule without windows is always open.
+type Schedule struct {
+	windows  []Window
+	location *time.Location
+}
+
+// New creates a Schedule given the posting windows configuration and
+// the name of the time zone the windows refer to.
+func New(windows []structs.PostingWindowConfiguration, timeZone string) (s Schedule, err error) {
+
+	//
+	s.location, err = time.LoadLocation(timeZone)
+	if err != nil {
+		return s, fmt.Errorf("window.New: unable to load time zone %s: %w", timeZone, err)
+	}
+
+	//
+	for _, w := range windows {
+
+		start, err := time.Parse(timeOfDayLayout, w.Start)
+		if err != nil {
+			return s, fmt.Errorf("window.New: invalid window start %s: %w", w.Start, err)
+		}
+
+		end, err := time.Parse(timeOfDayLayout, w.End)
+		if err != nil {
+			return s, fmt.Errorf("window.New: invalid window end %s: %w", w.End, err)
+		}
+
+		// Windows ending before they start span across midnight,
+		// windows with the same start and end last the whole day
+		length := end.Sub(start)
+		if length <= 0 {
+			length += day
+		}
+
+		s.windows = append(s.windows, Window{
+			hour:   start.Hour(),
+			minute: start.Minute(),
+			length: length,
+		})
+
+	}
+
+	return s, nil
+
+}
+
+// IsAlwaysOpen returns true if the Schedule has no windows.
+func (s Schedule) IsAlwaysOpen() bool {
+	return len(s.windows) == 0
+}
+
+// Location returns the location the Schedule refers to.
+func (s Schedule) Location() *time.Location {
+
+	if s.location == nil {
+		return time.Local
+	}
+
+	return s.location
+
+}
+
+// IsOpen returns true if posting is allowed at the input time.
+func (s Schedule) IsOpen(t time.Time) bool {
+	return s.Next(t).Equal(t)
+}
+
+// Next returns the input time if posting is allowed at that moment,
+// otherwise the time at which the next window opens.
+// The returned time is always expressed in the Schedule location.
+func (s Schedule) Next(t time.Time) time.Time {
+
+	//
+	t = t.In(s.Location())
+	if s.IsAlwaysOpen() {
+		return t
+	}
+
+	//
+	var nextOpening time.Time
+	for _, w := range s.windows {
+
+		// A window opened on the previous day may still be open,
+		// while every window opens at least once in the next 24 hours
+		for offset := -1; offset <= 1; offset++ {
+
+			// The closing is computed on the wall clock, so that windows
+			// keep ending at the configured time when the clocks change
+			opening := time.Date(t.Year(), t.Month(), t.Day()+offset, w.hour, w.minute, 0, 0, t.Location())
+			closing := time.Date(t.Year(), t.Month(), t.Day()+offset, w.hour, w.minute+int(w.length/time.Minute), 0, 0, t.Location())
+
+			if !t.Before(opening) && t.Before(closing) {
+				return t
+			}
+
+			if opening.After(t) && (nextOpening.IsZero() || opening.Before(nextOpening)) {
+				nextOpening = opening
+			}
+
+		}
+
+	}
+
+	return nextOpening
+
+}
@@ -0,0 +1,77 @@
+package window
+
+import (
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Fatalf("unable to load the time zone: %v", err)
+	}
+
+	newSchedule := func(windows ...structs.PostingWindowConfiguration) Schedule {
+
+		s, err := New(windows, "Europe/Rome")
+		if err != nil {
+			t.Fatalf("unable to create the schedule: %v", err)
+		}
+
+		return s
+
+	}
+
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, rome)
+	}
+
+	alwaysOpen := newSchedule()
+	daily := newSchedule(
+		structs.PostingWindowConfiguration{Start: "09:00", End: "12:00"},
+		structs.PostingWindowConfiguration{Start: "22:00", End: "02:00"},
+	)
+	fullDay := newSchedule(structs.PostingWindowConfiguration{Start: "07:00", End: "07:00"})
+	night := newSchedule(structs.PostingWindowConfiguration{Start: "01:00", End: "05:00"})
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		input    time.Time
+		want     time.Time
+	}{
+		{"always open", alwaysOpen, at(2020, time.June, 1, 4, 0), at(2020, time.June, 1, 4, 0)},
+		{"at the opening", daily, at(2020, time.June, 1, 9, 0), at(2020, time.June, 1, 9, 0)},
+		{"inside a window", daily, at(2020, time.June, 1, 10, 30), at(2020, time.June, 1, 10, 30)},
+		{"at the closing", daily, at(2020, time.June, 1, 12, 0), at(2020, time.June, 1, 22, 0)},
+		{"before the first window", daily, at(2020, time.June, 1, 5, 0), at(2020, time.June, 1, 9, 0)},
+		{"across midnight, before it", daily, at(2020, time.June, 1, 23, 30), at(2020, time.June, 1, 23, 30)},
+		{"across midnight, after it", daily, at(2020, time.June, 2, 1, 30), at(2020, time.June, 2, 1, 30)},
+		{"across midnight, after the closing", daily, at(2020, time.June, 2, 2, 0), at(2020, time.June, 2, 9, 0)},
+		{"across the end of the year", daily, at(2020, time.December, 31, 23, 0), at(2020, time.December, 31, 23, 0)},
+		{"full day", fullDay, at(2020, time.June, 1, 6, 59), at(2020, time.June, 1, 6, 59)},
+		{"input in another time zone", daily, time.Date(2020, time.June, 1, 21, 0, 0, 0, time.UTC), at(2020, time.June, 1, 23, 0)},
+		{"clocks going forward, inside the window", night, at(2020, time.March, 29, 4, 30), at(2020, time.March, 29, 4, 30)},
+		{"clocks going forward, after the closing", night, at(2020, time.March, 29, 5, 30), at(2020, time.March, 30, 1, 0)},
+		{"clocks going back, inside the window", night, at(2020, time.October, 25, 4, 30), at(2020, time.October, 25, 4, 30)},
+		{"clocks going back, after the closing", night, at(2020, time.October, 25, 5, 0), at(2020, time.October, 26, 1, 0)},
+		{"clocks going forward, across midnight", daily, at(2020, time.March, 29, 1, 30), at(2020, time.March, 29, 1, 30)},
+		{"clocks going forward, after the skipped closing", daily, at(2020, time.March, 29, 3, 0), at(2020, time.March, 29, 9, 0)},
+	}
+
+	for _, test := range tests {
+
+		got := test.schedule.Next(test.input)
+		if !got.Equal(test.want) {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+
+		if got.Location().String() != rome.String() {
+			t.Errorf("%s: got location %s, want %s", test.name, got.Location(), rome)
+		}
+
+	}
+
+}
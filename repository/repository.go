@@ -2,6 +2,7 @@ package repository
 
 import (
 	"github.com/shitpostingio/autopostingbot/config/structs"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
 	"github.com/zelenin/go-tdlib/client"
 )
 
@@ -14,4 +15,16 @@ var (
 
 	// Me represents the current bot as a Telegram client.User.
 	Me *client.User
+
+	// Posts is the store of the posts.
+	Posts storage.PostStore
+
+	// Users is the store of the authorized users.
+	Users storage.UserStore
+
+	// Scheduler is the store of the posting scheduler states.
+	Scheduler storage.SchedulerStore
+
+	// Statistics is the store of the activity statistics.
+	Statistics storage.StatisticsStore
 )
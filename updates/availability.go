@@ -0,0 +1,44 @@
+package updates
+
+import (
+	"github.com/shitpostingio/autopostingbot/api"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	"github.com/shitpostingio/autopostingbot/repository"
+	log "github.com/sirupsen/logrus"
+	"github.com/zelenin/go-tdlib/client"
+)
+
+var (
+	// authorizedUsers records the users that were authorized when they last
+	// talked to the bot, so that they can be told when the storage backend
+	// is unavailable and their authorization can't be checked.
+	authorizedUsers = make(map[int32]bool)
+)
+
+// userIsAuthorized returns true if the user can interact with the bot.
+func userIsAuthorized(userID int32) bool {
+
+	authorized := repository.Users.UserIsAuthorized(userID)
+	if authorized {
+		authorizedUsers[userID] = true
+	}
+
+	return authorized
+
+}
+
+// replyStorageUnavailable lets the sender of the message know that the storage backend
+// is unavailable, if they were authorized before. Other users are ignored.
+func replyStorageUnavailable(message *client.Message) {
+
+	if !authorizedUsers[message.SenderUserId] {
+		log.Println("Storage backend unavailable, ignoring message from user with id ", message.SenderUserId)
+		return
+	}
+
+	_, err := api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.UPDATES_STORAGE_UNAVAILABLE))
+	if err != nil {
+		log.Error(err)
+	}
+
+}
@@ -0,0 +1,68 @@
+package updates
+
+import (
+	"github.com/shitpostingio/autopostingbot/api"
+	"github.com/shitpostingio/autopostingbot/commands"
+	"github.com/shitpostingio/autopostingbot/documentstore/health"
+	l "github.com/shitpostingio/autopostingbot/localization"
+	log "github.com/sirupsen/logrus"
+	"github.com/zelenin/go-tdlib/client"
+	"strings"
+)
+
+var (
+	// callbackHandlers maps the prefix of the callback data
+	// to the handler of the command that sent the keyboard.
+	callbackHandlers = map[string]commands.CallbackHandler{
+		"queue": commands.QueueCommandHandler{},
+	}
+)
+
+// handleCallbackQuery handles the callback queries sent by inline keyboard buttons.
+// Callback data is in the form prefix:data, where prefix selects the handler.
+func handleCallbackQuery(query *client.UpdateNewCallbackQuery) {
+
+	// Requests can't be handled while the storage backend is unavailable,
+	// users that were authorized before are told so
+	if !health.Available() {
+
+		text := ""
+		if authorizedUsers[query.SenderUserId] {
+			text = l.GetString(l.UPDATES_STORAGE_UNAVAILABLE)
+		}
+
+		_ = api.AnswerCallbackQuery(query.Id, text, true)
+		return
+
+	}
+
+	// Users need to be authorized to talk to the bot
+	if !userIsAuthorized(query.SenderUserId) {
+		log.Println("Received callback query from unauthorized user with id ", query.SenderUserId)
+		_ = api.AnswerCallbackQuery(query.Id, "", false)
+		return
+	}
+
+	//
+	payload, isData := query.Payload.(*client.CallbackQueryPayloadData)
+	if !isData {
+		_ = api.AnswerCallbackQuery(query.Id, "", false)
+		return
+	}
+
+	//
+	fields := strings.SplitN(string(payload.Data), ":", 2)
+	handler, found := callbackHandlers[fields[0]]
+	if !found || len(fields) != 2 {
+		log.Error("No callback handler found for ", string(payload.Data))
+		_ = api.AnswerCallbackQuery(query.Id, "", false)
+		return
+	}
+
+	//
+	err := handler.HandleCallback(fields[1], query)
+	if err != nil {
+		log.Error(err)
+	}
+
+}
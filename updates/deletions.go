@@ -1,7 +1,7 @@
 package updates
 
 import (
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
+	"github.com/shitpostingio/autopostingbot/posting"
 	"github.com/shitpostingio/autopostingbot/repository"
 	log "github.com/sirupsen/logrus"
 	"github.com/zelenin/go-tdlib/client"
@@ -15,8 +15,9 @@ const (
 // deleted channel posts as deleted in the database.
 func handleNewDeletion(messages *client.UpdateDeleteMessages) {
 
-	// We care only about permanent deletions in the channel
-	if messages.ChatId != repository.Config.Autoposting.ChannelID || !messages.IsPermanent {
+	// We care only about permanent deletions in the channels
+	_, found := posting.GetChannel(messages.ChatId)
+	if !found || !messages.IsPermanent {
 		return
 	}
 
@@ -25,7 +26,7 @@ func handleNewDeletion(messages *client.UpdateDeleteMessages) {
 	for _, id := range messages.MessageIds {
 
 		id -= tdlibDeletedMessageConversionFactor
-		err := dbwrapper.MarkPostAsDeletedByMessageID(id)
+		err := repository.Posts.MarkPostAsDeletedByMessageID(messages.ChatId, id)
 		if err != nil {
 			log.Error(err)
 		}
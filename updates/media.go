@@ -1,13 +1,16 @@
 package updates
 
 import (
+	"errors"
 	"github.com/shitpostingio/autopostingbot/analysisadapter"
 	"github.com/shitpostingio/autopostingbot/api"
 	"github.com/shitpostingio/autopostingbot/caption"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
+	"github.com/shitpostingio/autopostingbot/commands"
 	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
 	l "github.com/shitpostingio/autopostingbot/localization"
 	"github.com/shitpostingio/autopostingbot/posting"
+	"github.com/shitpostingio/autopostingbot/repository"
 	log "github.com/sirupsen/logrus"
 	"github.com/zelenin/go-tdlib/client"
 )
@@ -38,30 +41,6 @@ func handleMedia(message *client.Message, mediatype string, skipDuplicateChecks
 		return
 	}
 
-	//
-	if !skipDuplicateChecks {
-
-		post, err := dbwrapper.FindPostByUniqueID(fileInfo.Remote.UniqueId)
-		if err != nil {
-			post, err = dbwrapper.FindPostByFeatures(fingerprint.Histogram, fingerprint.PHash)
-		}
-
-		if err == nil {
-			log.Debugln("Match found: ", post)
-			formattedText, err := getDuplicateCaption(&post)
-			if err != nil {
-				_, _ = api.SendMedia(mediatype, message.ChatId, message.Id, post.Media.FileID, l.GetString(l.UPDATES_MEDIA_UNABLE_TO_GET_DUPLICATE_CAPTION), nil)
-			} else {
-				_, _ = api.SendMedia(mediatype, message.ChatId, message.Id, post.Media.FileID, formattedText.Text, formattedText.Entities)
-			}
-
-			return
-		}
-
-	}
-
-	log.Debugln("Adding the post to the database")
-
 	// Remove caption from forwarded posts
 	var c string
 	if message.ForwardInfo == nil {
@@ -81,17 +60,79 @@ func handleMedia(message *client.Message, mediatype string, skipDuplicateChecks
 		PHash:            fingerprint.PHash,
 	}
 
-	err = dbwrapper.AddPost(message.SenderUserId, media, c)
+	//
+	channelID := commands.GetTargetChannelID(message.SenderUserId)
+	duplicate, err := addPost(message.SenderUserId, channelID, media, c, skipDuplicateChecks)
 	if err != nil {
-		log.Error(err)
+		log.Error("handleMedia: ", err)
+		_, _ = api.SendPlainReplyText(message.ChatId, message.Id, l.GetString(l.UPDATES_MEDIA_UNABLE_TO_ADD_MEDIA))
+		return
+	}
+
+	//
+	if duplicate != nil {
+		commands.NotifyDuplicate(message, message.SenderUserId, channelID, mediatype, duplicate)
+		return
 	}
 
 	//
 	_, _ = api.SendPlainReplyText(message.ChatId, message.Id, "Media added!")
 
 	//
-	if dbwrapper.GetQueueLength() == 1 {
-		posting.ForcePostScheduling()
+	if repository.Posts.GetQueueLength(channelID, posting.GetQueueSelection(channelID)) == 1 {
+		posting.ForcePostScheduling(channelID)
 	}
 
 }
+
+// addPost adds a post with the input media to the queue of a channel, unless
+// a post with the same or a similar media was already added to the channel, in which case that post is returned.
+// The features of the media are reserved in the store during the duplicate checks and the addition,
+// so that of concurrent submissions of similar media only one is added, even across instances of the bot.
+// If skipDuplicateChecks is true, only posts with the same media are reported.
+func addPost(addedBy int32, channelID int64, media entities.Media, caption string, skipDuplicateChecks bool) (*entities.Post, error) {
+
+	if !skipDuplicateChecks {
+
+		//
+		release, err := repository.Posts.ReserveFeatures(channelID, media.Histogram)
+		if err != nil {
+			return nil, err
+		}
+
+		defer release()
+
+		// A removed post with the same media doesn't hide a similar post that wasn't removed
+		post, err := repository.Posts.FindPostByUniqueID(channelID, media.FileUniqueID)
+		if err != nil || post.RemovedAt != nil {
+
+			similar, similarErr := repository.Posts.FindPostByFeatures(channelID, media.Histogram, media.PHash)
+			if similarErr == nil && (err != nil || similar.RemovedAt == nil) {
+				post, err = similar, nil
+			}
+
+		}
+
+		// Removed posts are replaced by the new one, unless they still count as duplicates
+		if err == nil && (post.RemovedAt == nil || repository.Config.Autoposting.RemovedPostsAreDuplicates) {
+			return &post, nil
+		}
+
+	}
+
+	//
+	log.Debugln("Adding the post to the database")
+	err := repository.Posts.AddPost(addedBy, channelID, media, caption)
+	if !errors.Is(err, storage.ErrDuplicate) {
+		return nil, err
+	}
+
+	// The same media was added in the meantime
+	post, err := repository.Posts.FindPostByUniqueID(channelID, media.FileUniqueID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &post, nil
+
+}
@@ -0,0 +1,96 @@
+package updates
+
+import (
+	"github.com/shitpostingio/autopostingbot/config/structs"
+	"github.com/shitpostingio/autopostingbot/documentstore/entities"
+	"github.com/shitpostingio/autopostingbot/documentstore/memorystore"
+	"github.com/shitpostingio/autopostingbot/documentstore/selection"
+	"github.com/shitpostingio/autopostingbot/documentstore/storage"
+	"github.com/shitpostingio/autopostingbot/repository"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// slowPostStore is a post store whose similarity search returns a while after looking for the posts,
+// so that concurrent submissions would add their posts after all of them checked for duplicates.
+type slowPostStore struct {
+	storage.PostStore
+}
+
+// FindPostByFeatures finds a post of a channel similar to the media with the input features, slowly.
+func (s slowPostStore) FindPostByFeatures(channelID int64, histogram []float64, pHash string) (entities.Post, error) {
+
+	post, err := s.PostStore.FindPostByFeatures(channelID, histogram, pHash)
+	time.Sleep(10 * time.Millisecond)
+	return post, err
+
+}
+
+func TestAddPostConcurrentSimilarMedia(t *testing.T) {
+
+	const (
+		channelID   = -1001234567890
+		submissions = 8
+	)
+
+	repository.Config = &structs.Config{}
+	repository.Posts = slowPostStore{PostStore: memorystore.New(0.08, 6)}
+
+	//
+	histogram := make([]float64, 32)
+	for i := range histogram {
+		histogram[i] = float64(i % 4)
+	}
+
+	average, sum := entities.GetHistogramAverageAndSum(histogram)
+
+	// The same picture is submitted concurrently as different files
+	type result struct {
+		duplicate *entities.Post
+		err       error
+	}
+
+	results := make(chan result, submissions)
+	for i := 0; i < submissions; i++ {
+		go func(uniqueID string) {
+
+			duplicate, err := addPost(1, channelID, entities.Media{
+				Type:             "photo",
+				FileUniqueID:     uniqueID,
+				Histogram:        histogram,
+				HistogramAverage: average,
+				HistogramSum:     sum,
+				PHash:            "p:ffffffffffffffff",
+			}, "", false)
+
+			results <- result{duplicate: duplicate, err: err}
+
+		}(strconv.Itoa(i))
+	}
+
+	//
+	added := 0
+	for i := 0; i < submissions; i++ {
+
+		r := <-results
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+
+		if r.duplicate == nil {
+			added++
+		}
+
+	}
+
+	if added != 1 {
+		t.Errorf("added posts: got %d, want 1", added)
+	}
+
+	length := repository.Posts.GetQueueLength(channelID, selection.Policy{})
+	if length != 1 {
+		t.Errorf("queue length: got %d, want 1", length)
+	}
+
+}
@@ -3,7 +3,8 @@ package updates
 import (
 	"github.com/shitpostingio/autopostingbot/api"
 	"github.com/shitpostingio/autopostingbot/caption"
-	"github.com/shitpostingio/autopostingbot/documentstore/dbwrapper"
+	"github.com/shitpostingio/autopostingbot/commands"
+	"github.com/shitpostingio/autopostingbot/documentstore/health"
 	"github.com/shitpostingio/autopostingbot/repository"
 	log "github.com/sirupsen/logrus"
 	"github.com/zelenin/go-tdlib/client"
@@ -18,8 +19,14 @@ func handleNewMessage(message *client.Message) {
 		return
 	}
 
+	// Requests can't be handled while the storage backend is unavailable
+	if !health.Available() {
+		replyStorageUnavailable(message)
+		return
+	}
+
 	// Users need to be authorized to talk to the bot
-	if !dbwrapper.UserIsAuthorized(message.SenderUserId) {
+	if !userIsAuthorized(message.SenderUserId) {
 		log.Println("Received message from unauthorized user with id ", message.SenderUserId)
 		return
 	}
@@ -58,8 +65,19 @@ func handleUpdatedMessage(umc *client.UpdateMessageContent) {
 		return
 	}
 
+	// Requests can't be handled while the storage backend is unavailable
+	if !health.Available() {
+
+		if !message.IsChannelPost {
+			replyStorageUnavailable(message)
+		}
+
+		return
+
+	}
+
 	// Users need to be authorized to talk to the bot
-	if !message.IsChannelPost && !dbwrapper.UserIsAuthorized(message.SenderUserId) {
+	if !message.IsChannelPost && !userIsAuthorized(message.SenderUserId) {
 		log.Println("Received message from unauthorized user with id ", message.SenderUserId)
 		return
 	}
@@ -85,7 +103,7 @@ func handleUpdatedMessage(umc *client.UpdateMessageContent) {
 	}
 
 	c := caption.ToHTMLCaption(api.GetMediaFormattedText(message))
-	err = dbwrapper.UpdatePostCaptionByUniqueID(fileInfo.Remote.UniqueId, c)
+	err = repository.Posts.UpdatePostCaptionByUniqueID(commands.GetTargetChannelID(message.SenderUserId), fileInfo.Remote.UniqueId, c, message.SenderUserId)
 
 	if err != nil {
 		log.Debugln("handleUpdatedMessage:", err)
@@ -12,17 +12,31 @@ import (
 
 var (
 	handlers = map[string]commands.Handler{
-		"status":  commands.StatusCommandHandler{},
-		"peek":    commands.PeekCommandHandler{},
-		"pause":   commands.PauseCommandHandler{},
-		"delete":  commands.DeleteCommandHandler{},
-		"info":    commands.InfoCommandHandler{},
-		"postnow": commands.PostNowCommandHandler{},
-		"add":     commands.AddCommandHandler{},
-		"caption": commands.CaptionCommandHandler{},
-		"thanks":  commands.ThanksCommandHandler{},
-		"preview": commands.PreviewCommandHandler{},
-		"credit":  commands.CreditCommandHandler{},
+		"status":    commands.StatusCommandHandler{},
+		"peek":      commands.PeekCommandHandler{},
+		"pause":     commands.PauseCommandHandler{},
+		"resume":    commands.ResumeCommandHandler{},
+		"delete":    commands.DeleteCommandHandler{},
+		"info":      commands.InfoCommandHandler{},
+		"postnow":   commands.PostNowCommandHandler{},
+		"add":       commands.AddCommandHandler{},
+		"caption":   commands.CaptionCommandHandler{},
+		"thanks":    commands.ThanksCommandHandler{},
+		"preview":   commands.PreviewCommandHandler{},
+		"credit":    commands.CreditCommandHandler{},
+		"failed":    commands.FailedCommandHandler{},
+		"priority":  commands.PriorityCommandHandler{},
+		"schedule":  commands.ScheduleCommandHandler{},
+		"channel":   commands.ChannelCommandHandler{},
+		"upcoming":  commands.UpcomingCommandHandler{},
+		"tag":       commands.TagCommandHandler{},
+		"untag":     commands.UntagCommandHandler{},
+		"campaigns": commands.CampaignsCommandHandler{},
+		"history":   commands.HistoryCommandHandler{},
+		"revert":    commands.RevertCommandHandler{},
+		"undo":      commands.UndoCommandHandler{},
+		"queue":     commands.QueueCommandHandler{},
+		"stats":     commands.StatsCommandHandler{},
 	}
 )
 
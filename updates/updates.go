@@ -1,33 +1,54 @@
 package updates
 
 import (
+	"context"
 	log "github.com/sirupsen/logrus"
 	"github.com/zelenin/go-tdlib/client"
 )
 
 // HandleUpdates handles incoming updates, dispatching them
-// to the appropriate sub-handlers.
-func HandleUpdates(listener *client.Listener) {
-
-	for update := range listener.Updates {
-
-		if update.GetClass() == client.ClassUpdate {
-
-			switch update.GetType() {
-			case client.TypeUpdateNewMessage:
-				handleNewMessage(update.(*client.UpdateNewMessage).Message)
-			case client.TypeUpdateMessageContent:
-				log.Debugln(update.(*client.UpdateMessageContent).NewContent.MessageContentType())
-				handleUpdatedMessage(update.(*client.UpdateMessageContent))
-				//handleUpdatedMessage(update.(*client.UpdateMessageContent).NewContent)
-			case client.TypeUpdateDeleteMessages:
-				handleNewDeletion(update.(*client.UpdateDeleteMessages))
-			default:
-				log.Debugf("Type: %s, Value: %#v", update.GetType(), update)
+// to the appropriate sub-handlers, until the context is cancelled
+// or the listener is closed. The update being handled when the
+// context is cancelled is handled completely.
+func HandleUpdates(ctx context.Context, listener *client.Listener) {
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-listener.Updates:
+
+			if !ok {
+				return
 			}
 
+			handleUpdate(update)
+
 		}
+	}
+
+}
+
+// handleUpdate dispatches an update to the appropriate sub-handler.
+func handleUpdate(update client.Type) {
+
+	if update.GetClass() != client.ClassUpdate {
+		return
+	}
 
+	switch update.GetType() {
+	case client.TypeUpdateNewMessage:
+		handleNewMessage(update.(*client.UpdateNewMessage).Message)
+	case client.TypeUpdateMessageContent:
+		log.Debugln(update.(*client.UpdateMessageContent).NewContent.MessageContentType())
+		handleUpdatedMessage(update.(*client.UpdateMessageContent))
+		//handleUpdatedMessage(update.(*client.UpdateMessageContent).NewContent)
+	case client.TypeUpdateDeleteMessages:
+		handleNewDeletion(update.(*client.UpdateDeleteMessages))
+	case client.TypeUpdateNewCallbackQuery:
+		handleCallbackQuery(update.(*client.UpdateNewCallbackQuery))
+	default:
+		log.Debugf("Type: %s, Value: %#v", update.GetType(), update)
 	}
 
 }